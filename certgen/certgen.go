@@ -0,0 +1,277 @@
+// Package certgen issues the certificates of an mTLS deployment: a CA, server certificates for
+// the hosts the server is reached on, and client certificates carrying a distinct identity.
+package certgen
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"time"
+)
+
+// DefaultTrustDomain is the SPIFFE trust domain of the client URI SANs.
+const DefaultTrustDomain = "grpc.local"
+
+// KeyType is the algorithm of the generated keys.
+type KeyType string
+
+const (
+	ECDSA   KeyType = "ecdsa" // ECDSA on P-256
+	Ed25519 KeyType = "ed25519"
+)
+
+// ParseKeyType returns the key type named name, "ecdsa" or "ed25519".
+func ParseKeyType(name string) (KeyType, error) {
+	switch keyType := KeyType(name); keyType {
+	case ECDSA, Ed25519:
+		return keyType, nil
+	default:
+		return "", fmt.Errorf("unknown key type %q, want ecdsa or ed25519", name)
+	}
+}
+
+// GenerateKey creates a private key of keyType.
+func GenerateKey(keyType KeyType) (crypto.Signer, error) {
+	switch keyType {
+	case ECDSA:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case Ed25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unknown key type %q, want ecdsa or ed25519", keyType)
+	}
+}
+
+// KeyPair is a certificate and its private key.
+type KeyPair struct {
+	Certificate *x509.Certificate
+	Key         crypto.Signer
+}
+
+// CertPEM returns the certificate PEM encoded.
+func (pair *KeyPair) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pair.Certificate.Raw})
+}
+
+// KeyPEM returns the private key PEM encoded in PKCS #8.
+func (pair *KeyPair) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(pair.Key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// TLSCertificate returns the key pair to present in TLS handshakes.
+func (pair *KeyPair) TLSCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{pair.Certificate.Raw}, PrivateKey: pair.Key, Leaf: pair.Certificate}
+}
+
+// WriteFiles writes the PEM certificate and key, the key readable by the owner only.
+func (pair *KeyPair) WriteFiles(certFile, keyFile string) error {
+	keyPEM, err := pair.KeyPEM()
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(keyFile, keyPEM, 0o600)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(certFile, pair.CertPEM(), 0o644)
+}
+
+// LoadKeyPair reads a PEM certificate and its PKCS #8, EC or PKCS #1 private key.
+func LoadKeyPair(certFile, keyFile string) (*KeyPair, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := certificate.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T in %s", certificate.PrivateKey, keyFile)
+	}
+	return &KeyPair{Certificate: certificate.Leaf, Key: key}, nil
+}
+
+// ClientIdentity is the principal a client certificate is issued to.
+// It is written as CN=Name,OU=Role,O=Tenant with the URI SAN spiffe://<trust domain>/client/<Name>.
+type ClientIdentity struct {
+	Name   string
+	Role   string
+	Tenant string
+}
+
+// Authority is a CA issuing server and client certificates.
+type Authority struct {
+	KeyPair
+	TrustDomain string // SPIFFE trust domain of the client URI SANs
+}
+
+// NewAuthority creates a self-signed CA named commonName.
+func NewAuthority(commonName string, keyType KeyType, lifetime time.Duration) (*Authority, error) {
+	key, err := GenerateKey(keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := newTemplate(pkix.Name{CommonName: commonName}, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	certificate, err := sign(template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	return &Authority{KeyPair: KeyPair{Certificate: certificate, Key: key}, TrustDomain: DefaultTrustDomain}, nil
+}
+
+// LoadAuthority reads the CA certificate and key written by WriteFiles.
+func LoadAuthority(certFile, keyFile string) (*Authority, error) {
+	pair, err := LoadKeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if !pair.Certificate.IsCA {
+		return nil, fmt.Errorf("%s is not a CA certificate", certFile)
+	}
+	return &Authority{KeyPair: *pair, TrustDomain: DefaultTrustDomain}, nil
+}
+
+// CertPool returns a pool trusting the CA only.
+func (ca *Authority) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Certificate)
+	return pool
+}
+
+// IssueServer issues a server certificate for hosts, DNS names or IP addresses.
+func (ca *Authority) IssueServer(commonName string, hosts []string, keyType KeyType, lifetime time.Duration) (*KeyPair, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("a server certificate needs at least one host")
+	}
+
+	template, err := newTemplate(pkix.Name{CommonName: commonName}, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	return ca.issue(template, keyType)
+}
+
+// IssueClient issues a client certificate to identity.
+func (ca *Authority) IssueClient(identity ClientIdentity, keyType KeyType, lifetime time.Duration) (*KeyPair, error) {
+	if identity.Name == "" || identity.Role == "" {
+		return nil, errors.New("a client identity needs a name and a role")
+	}
+
+	subject := pkix.Name{CommonName: identity.Name, OrganizationalUnit: []string{identity.Role}}
+	if identity.Tenant != "" {
+		subject.Organization = []string{identity.Tenant}
+	}
+	template, err := newTemplate(subject, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	template.URIs = []*url.URL{ca.ClientURI(identity.Name)}
+
+	return ca.issue(template, keyType)
+}
+
+// ClientURI returns the URI SAN of the client certificates issued to name.
+func (ca *Authority) ClientURI(name string) *url.URL {
+	return &url.URL{Scheme: "spiffe", Host: ca.TrustDomain, Path: "/client/" + name}
+}
+
+// ServerTLSConfig returns a TLS config presenting pair and requiring client certificates issued by the CA.
+func (ca *Authority) ServerTLSConfig(pair *KeyPair) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{pair.TLSCertificate()},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.CertPool(),
+	}
+}
+
+// ClientTLSConfig returns a TLS config presenting pair and trusting the server certificates issued by the CA.
+func (ca *Authority) ClientTLSConfig(pair *KeyPair) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{pair.TLSCertificate()},
+		RootCAs:      ca.CertPool(),
+	}
+}
+
+func (ca *Authority) issue(template *x509.Certificate, keyType KeyType) (*KeyPair, error) {
+	key, err := GenerateKey(keyType)
+	if err != nil {
+		return nil, err
+	}
+	// ECDSA and Ed25519 keys only sign, the TLS key exchange is ephemeral
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	// a certificate outliving its CA could not be verified past the CA expiry
+	if template.NotAfter.After(ca.Certificate.NotAfter) {
+		template.NotAfter = ca.Certificate.NotAfter
+	}
+
+	certificate, err := sign(template, ca.Certificate, key.Public(), ca.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{Certificate: certificate, Key: key}, nil
+}
+
+// newTemplate returns a certificate template with a random serial number, valid from a minute
+// ago, to tolerate clock skew, for lifetime.
+func newTemplate(subject pkix.Name, lifetime time.Duration) (*x509.Certificate, error) {
+	if lifetime <= 0 {
+		return nil, fmt.Errorf("certificate lifetime must be positive, got %s", lifetime)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               subject,
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(lifetime),
+		BasicConstraintsValid: true,
+	}, nil
+}
+
+func sign(template, parent *x509.Certificate, public crypto.PublicKey, signer crypto.Signer) (*x509.Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, public, signer)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
@@ -0,0 +1,82 @@
+package certgen_test
+
+import (
+	"crypto/x509"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-http-server/grpc/certgen"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthority(t *testing.T) {
+	t.Parallel()
+
+	for _, keyType := range []certgen.KeyType{certgen.ECDSA, certgen.Ed25519} {
+		t.Run(string(keyType), func(t *testing.T) {
+			t.Parallel()
+
+			ca, err := certgen.NewAuthority("test CA", keyType, 24*time.Hour)
+			require.NoError(t, err)
+			require.True(t, ca.Certificate.IsCA)
+
+			// the CA written to files issues certificates the original CA verifies
+			dir := t.TempDir()
+			certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+			require.NoError(t, ca.WriteFiles(certFile, keyFile))
+			loaded, err := certgen.LoadAuthority(certFile, keyFile)
+			require.NoError(t, err)
+			require.Equal(t, ca.Certificate.Raw, loaded.Certificate.Raw)
+
+			server, err := loaded.IssueServer("localhost", []string{"localhost", "127.0.0.1"}, keyType, 48*time.Hour)
+			require.NoError(t, err)
+			require.Equal(t, []string{"localhost"}, server.Certificate.DNSNames)
+			require.True(t, server.Certificate.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")))
+			require.Equal(t, ca.Certificate.NotAfter, server.Certificate.NotAfter, "a certificate must not outlive its CA")
+			_, err = server.Certificate.Verify(x509.VerifyOptions{DNSName: "127.0.0.1", Roots: ca.CertPool()})
+			require.NoError(t, err)
+
+			client, err := loaded.IssueClient(certgen.ClientIdentity{Name: "batch", Role: "admin", Tenant: "acme"}, keyType, time.Hour)
+			require.NoError(t, err)
+			require.Equal(t, "CN=batch,OU=admin,O=acme", client.Certificate.Subject.String())
+			require.Len(t, client.Certificate.URIs, 1)
+			require.Equal(t, "spiffe://grpc.local/client/batch", client.Certificate.URIs[0].String())
+			_, err = client.Certificate.Verify(x509.VerifyOptions{
+				Roots:     ca.CertPool(),
+				KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			})
+			require.NoError(t, err)
+
+			// a client certificate cannot authenticate a server
+			_, err = client.Certificate.Verify(x509.VerifyOptions{Roots: ca.CertPool()})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestAuthorityInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := certgen.ParseKeyType("rsa")
+	require.Error(t, err)
+	_, err = certgen.NewAuthority("test CA", certgen.ECDSA, 0)
+	require.Error(t, err)
+
+	ca, err := certgen.NewAuthority("test CA", certgen.ECDSA, time.Hour)
+	require.NoError(t, err)
+	_, err = ca.IssueServer("localhost", nil, certgen.ECDSA, time.Hour)
+	require.Error(t, err)
+	_, err = ca.IssueClient(certgen.ClientIdentity{Name: "batch"}, certgen.ECDSA, time.Hour)
+	require.Error(t, err)
+
+	// a leaf certificate is not a CA
+	leaf, err := ca.IssueServer("localhost", []string{"localhost"}, certgen.ECDSA, time.Hour)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	require.NoError(t, leaf.WriteFiles(certFile, keyFile))
+	_, err = certgen.LoadAuthority(certFile, keyFile)
+	require.Error(t, err)
+}
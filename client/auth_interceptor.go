@@ -14,6 +14,7 @@ type AuthInterceptor struct {
 	authClient  *AuthClient
 	authMethods map[string]bool
 	accessToken string
+	apiKey      string
 }
 
 // NewAuthInterceptor creates a new AuthInterceptor instance.
@@ -31,6 +32,11 @@ func NewAuthInterceptor(authClient *AuthClient, authMethods map[string]bool, ref
 	return interceptor, nil
 }
 
+// NewAPIKeyInterceptor creates a new AuthInterceptor sending a long-lived API key instead of logging in.
+func NewAPIKeyInterceptor(apiKey string, authMethods map[string]bool) *AuthInterceptor {
+	return &AuthInterceptor{authMethods: authMethods, apiKey: apiKey}
+}
+
 // scheduleRefreshToken schedules the token refresh operation at a specified interval.
 func (interceptor *AuthInterceptor) scheduleRefreshToken(refreshTokenDuration time.Duration) error {
 	err := interceptor.refreshToken()
@@ -92,5 +98,8 @@ func (interceptor *AuthInterceptor) Stream() grpc.StreamClientInterceptor {
 }
 
 func (interceptor *AuthInterceptor) attachToken(ctx context.Context) context.Context {
+	if interceptor.apiKey != "" {
+		return metadata.AppendToOutgoingContext(ctx, "x-api-key", interceptor.apiKey)
+	}
 	return metadata.AppendToOutgoingContext(ctx, "authorization", interceptor.accessToken)
 }
@@ -5,11 +5,16 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/go-http-server/grpc/protoc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // RouteGuideClient is a client for interacting with the RouteGuide service.
@@ -62,29 +67,132 @@ func (rgCli *RouteGuideClient) ListFeatures(rectangle *protoc.Rectangle) error {
 	return nil
 }
 
-func (rgCli *RouteGuideClient) RecordRoute(points []*protoc.Point) error {
+func (rgCli *RouteGuideClient) FindNearest(point *protoc.Point, k int32) ([]*protoc.FeatureDistance, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := rgCli.service.FindNearest(ctx, &protoc.FindNearestRequest{Location: point, K: k}, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetFeatures(), nil
+}
+
+func (rgCli *RouteGuideClient) ListFeaturesWithinRadius(point *protoc.Point, radius int32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := rgCli.service.ListFeaturesWithinRadius(ctx, &protoc.RadiusRequest{Location: point, Radius: radius}, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		return err
+	}
+
+	for {
+		found, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Feature: name: %q, distance: %dm", found.GetFeature().GetName(), found.GetDistance())
+	}
+
+	return nil
+}
+
+// RecordRoute streams points and returns the route summary, simplified at simplifyTolerance metres when it is positive.
+func (rgCli *RouteGuideClient) RecordRoute(points []*protoc.Point, simplifyTolerance float64) (*protoc.RouteSummary, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if simplifyTolerance > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-simplify-tolerance", strconv.FormatFloat(simplifyTolerance, 'f', -1, 64))
+	}
+
 	stream, err := rgCli.service.RecordRoute(ctx, grpc.UseCompressor(gzip.Name))
 	if err != nil {
-		return fmt.Errorf("failed to record route: %s", err)
+		return nil, fmt.Errorf("failed to record route: %s", err)
 	}
 
 	for _, point := range points {
 		err = stream.Send(point)
 		if err != nil {
-			return fmt.Errorf("failed send point to server: %s, %s", err, stream.RecvMsg(nil))
+			return nil, fmt.Errorf("failed send point to server: %s, %s", err, stream.RecvMsg(nil))
 		}
 	}
 
 	res, err := stream.CloseAndRecv()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	log.Printf("record route summary: %+v", res)
-	return nil
+	return res, nil
+}
+
+// ListRoutes returns the routes recorded by the caller that started in [from, to), zero times leave the period open.
+func (rgCli *RouteGuideClient) ListRoutes(from, to time.Time) ([]*protoc.Route, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req := &protoc.ListRoutesRequest{}
+	if !from.IsZero() {
+		req.From = timestamppb.New(from)
+	}
+	if !to.IsZero() {
+		req.To = timestamppb.New(to)
+	}
+
+	res, err := rgCli.service.ListRoutes(ctx, req, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetRoutes(), nil
+}
+
+func (rgCli *RouteGuideClient) GetRoute(id string) (*protoc.Route, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return rgCli.service.GetRoute(ctx, &protoc.GetRouteRequest{Id: id}, grpc.UseCompressor(gzip.Name))
+}
+
+// ExportRoute returns a route recorded by the caller as a GeoJSON LineString Feature.
+func (rgCli *RouteGuideClient) ExportRoute(id string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := rgCli.service.ExportRoute(ctx, &protoc.GetRouteRequest{Id: id}, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(res.GetData()), nil
+}
+
+// WatchRegion calls handle with every event of the region until ctx is done or the stream fails.
+func (rgCli *RouteGuideClient) WatchRegion(ctx context.Context, req *protoc.WatchRegionRequest, handle func(*protoc.RegionEvent)) error {
+	stream, err := rgCli.service.WatchRegion(ctx, req, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if status.Code(err) == codes.Canceled && ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		handle(event)
+	}
 }
 
 func (rgCli *RouteGuideClient) RouteChat(notes []*protoc.RouteNote) error {
@@ -0,0 +1,194 @@
+/*
+*
+* certgen creates the certificates of an mTLS deployment without the openssl CLI:
+* a CA, a server certificate for the hosts the server is reached on, and one certificate per
+* client carrying its identity (CN and SPIFFE URI SAN) and role.
+*
+* go run ./cmd/certgen -out certs -clients client:admin:default,reporting:user:default
+*
+* An existing CA in the output directory is reused, so adding clients keeps the issued
+* certificates valid. The cert identities file maps the client certificates to principals
+* for the server -cert-identities flag.
+*
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-http-server/grpc/certgen"
+	"github.com/go-http-server/grpc/service"
+	"gopkg.in/yaml.v3"
+)
+
+// parseClients parses comma separated name:role[:tenant] client identities.
+func parseClients(value string) ([]certgen.ClientIdentity, error) {
+	var clients []certgen.ClientIdentity
+	names := make(map[string]bool)
+	for _, spec := range strings.Split(value, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		parts := strings.Split(spec, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid client %q, want name:role[:tenant]", spec)
+		}
+		if names[parts[0]] {
+			return nil, fmt.Errorf("duplicate client %q", parts[0])
+		}
+		names[parts[0]] = true
+
+		identity := certgen.ClientIdentity{Name: parts[0], Role: parts[1], Tenant: "default"}
+		if len(parts) == 3 {
+			identity.Tenant = parts[2]
+		}
+		clients = append(clients, identity)
+	}
+	return clients, nil
+}
+
+// loadOrCreateAuthority reuses the CA of the output directory unless renew is set.
+func loadOrCreateAuthority(certFile, keyFile, commonName string, keyType certgen.KeyType, lifetime time.Duration, renew bool) (*certgen.Authority, error) {
+	if !renew {
+		ca, err := certgen.LoadAuthority(certFile, keyFile)
+		if err == nil {
+			log.Printf("reusing CA %s, expires %s", certFile, ca.Certificate.NotAfter.Format(time.RFC3339))
+			return ca, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("cannot reuse CA %s, pass -new-ca to replace it: %w", certFile, err)
+		}
+	}
+
+	ca, err := certgen.NewAuthority(commonName, keyType, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("creating CA %s", certFile)
+	return ca, ca.WriteFiles(certFile, keyFile)
+}
+
+// writeIdentities writes the cert identities file mapping each client URI SAN to its principal.
+// The identities of an existing file are kept, except those of the clients issued again.
+func writeIdentities(filename string, ca *certgen.Authority, clients []certgen.ClientIdentity) error {
+	identities := &service.CertIdentities{}
+	if _, err := os.Stat(filename); err == nil {
+		identities, err = service.LoadCertIdentities(filename)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, client := range clients {
+		identity := service.CertIdentity{
+			URI:      ca.ClientURI(client.Name).String(),
+			Username: client.Name,
+			Role:     client.Role,
+			Tenant:   client.Tenant,
+		}
+		i := slices.IndexFunc(identities.Identities, func(existing service.CertIdentity) bool {
+			return existing.URI == identity.URI
+		})
+		if i >= 0 {
+			identities.Identities[i] = identity
+		} else {
+			identities.Identities = append(identities.Identities, identity)
+		}
+	}
+
+	var data bytes.Buffer
+	data.WriteString("# Generated by cmd/certgen, maps the client certificates to principals for -cert-identities.\n")
+	encoder := yaml.NewEncoder(&data)
+	encoder.SetIndent(2)
+	err := encoder.Encode(identities)
+	if err != nil {
+		return err
+	}
+	err = encoder.Close()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data.Bytes(), 0o644)
+}
+
+func main() {
+	out := flag.String("out", "certs", "Directory the PEM certificates and keys are written to")
+	keyTypeName := flag.String("key-type", "ecdsa", "Algorithm of the generated keys: ecdsa (P-256) or ed25519")
+	caName := flag.String("ca-cn", "grpc CA", "Common name of a new CA")
+	caLifetime := flag.Duration("ca-lifetime", 10*365*24*time.Hour, "Lifetime of a new CA")
+	newCA := flag.Bool("new-ca", false, "Replace the CA of the output directory, invalidating every certificate it issued")
+	lifetime := flag.Duration("lifetime", 365*24*time.Hour, "Lifetime of the server and client certificates")
+	serverName := flag.String("server-cn", "localhost", "Common name of the server certificate")
+	serverHosts := flag.String("server-hosts", "localhost,127.0.0.1,::1", "Comma separated DNS names and IP addresses of the server certificate, empty to skip it")
+	clientsValue := flag.String("clients", "client:admin:default", "Comma separated name:role[:tenant] client certificates, written to <name>.crt and <name>.key")
+	trustDomain := flag.String("trust-domain", certgen.DefaultTrustDomain, "SPIFFE trust domain of the client URI SANs")
+	identitiesFile := flag.String("identities", "cert_identities.yaml", "Cert identities file written to the output directory for the server -cert-identities flag, empty to skip it")
+	flag.Parse()
+
+	keyType, err := certgen.ParseKeyType(*keyTypeName)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	clients, err := parseClients(*clientsValue)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	err = os.MkdirAll(*out, 0o755)
+	if err != nil {
+		log.Fatalf("cannot create output directory: %v", err)
+	}
+	path := func(name string) string {
+		return filepath.Join(*out, name)
+	}
+
+	ca, err := loadOrCreateAuthority(path("ca.crt"), path("ca.key"), *caName, keyType, *caLifetime, *newCA)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	ca.TrustDomain = *trustDomain
+
+	if *serverHosts != "" {
+		pair, err := ca.IssueServer(*serverName, strings.Split(*serverHosts, ","), keyType, *lifetime)
+		if err != nil {
+			log.Fatalf("cannot issue server certificate: %v", err)
+		}
+		err = pair.WriteFiles(path("server.crt"), path("server.key"))
+		if err != nil {
+			log.Fatalf("cannot write server certificate: %v", err)
+		}
+		log.Printf("issued server certificate %s for %s", path("server.crt"), *serverHosts)
+	}
+
+	for _, client := range clients {
+		pair, err := ca.IssueClient(client, keyType, *lifetime)
+		if err != nil {
+			log.Fatalf("cannot issue client certificate %s: %v", client.Name, err)
+		}
+		err = pair.WriteFiles(path(client.Name+".crt"), path(client.Name+".key"))
+		if err != nil {
+			log.Fatalf("cannot write client certificate %s: %v", client.Name, err)
+		}
+		log.Printf("issued client certificate %s to %s with role %s", path(client.Name+".crt"), ca.ClientURI(client.Name), client.Role)
+	}
+
+	if *identitiesFile != "" && len(clients) > 0 {
+		err = writeIdentities(path(*identitiesFile), ca, clients)
+		if err != nil {
+			log.Fatalf("cannot write cert identities: %v", err)
+		}
+		log.Printf("wrote cert identities %s", path(*identitiesFile))
+	}
+}
@@ -21,12 +21,12 @@
 package main
 
 import (
-	"crypto/tls"
-	"crypto/x509"
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"math/rand/v2"
+	"net"
 	"os"
 	"strings"
 	"time"
@@ -34,26 +34,28 @@ import (
 	"github.com/go-http-server/grpc/client"
 	"github.com/go-http-server/grpc/protoc"
 	"github.com/go-http-server/grpc/sample"
+	"github.com/go-http-server/grpc/tlsreload"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 )
 
+// retryPolicy retries the LaptopService calls shed by an overloaded server, backing off up to a second.
 const retryPolicy = `
 {
   "methodConfig": [
     {
       "name": [
         {
-          "service": "/LaptopService/CreateLaptop"
+          "service": "LaptopService"
         }
       ],
       "retryPolicy": {
         "MaxAttempts": 4,
-        "InitialBackoff": ".01s",
-        "MaxBackoff": ".01s",
-        "BackoffMultiplier": 1,
+        "InitialBackoff": ".1s",
+        "MaxBackoff": "1s",
+        "BackoffMultiplier": 2,
         "RetryableStatusCodes": [
           "UNAVAILABLE"
         ]
@@ -123,43 +125,24 @@ func authMethods() map[string]bool {
 	const laptopServiceMethod = "/LaptopService/"
 	const routeGuideServiceMethod = "/RouteGuide/"
 	return map[string]bool{
-		laptopServiceMethod + "CreateLaptop":     true,
-		laptopServiceMethod + "SearchLaptop":     false,
-		laptopServiceMethod + "RateLaptop":       true,
-		laptopServiceMethod + "UploadImage":      true,
-		routeGuideServiceMethod + "GetFeature":   true,
-		routeGuideServiceMethod + "ListFeatures": true,
-		routeGuideServiceMethod + "RecordRoute":  true,
+		laptopServiceMethod + "CreateLaptop":                 true,
+		laptopServiceMethod + "GetLaptop":                    true,
+		laptopServiceMethod + "SearchLaptop":                 true,
+		laptopServiceMethod + "RateLaptop":                   true,
+		laptopServiceMethod + "UploadImage":                  true,
+		routeGuideServiceMethod + "GetFeature":               true,
+		routeGuideServiceMethod + "ListFeatures":             true,
+		routeGuideServiceMethod + "FindNearest":              true,
+		routeGuideServiceMethod + "ListFeaturesWithinRadius": true,
+		routeGuideServiceMethod + "RecordRoute":              true,
+		routeGuideServiceMethod + "ListRoutes":               true,
+		routeGuideServiceMethod + "GetRoute":                 true,
+		routeGuideServiceMethod + "ExportRoute":              true,
+		routeGuideServiceMethod + "RouteChat":                true,
+		routeGuideServiceMethod + "WatchRegion":              true,
 	}
 }
 
-func loadTLSCredentials() (credentials.TransportCredentials, error) {
-	// Load TLS credentials from a file or other source
-	// For simplicity, we are returning insecure credentials here.
-	// In a real application, you would load your TLS certs and keys.
-	pemServerCA, err := os.ReadFile("certs/ca.crt")
-	if err != nil {
-		return nil, err
-	}
-
-	clientCert, err := tls.LoadX509KeyPair("certs/client.crt", "certs/client.key")
-	if err != nil {
-		return nil, err
-	}
-
-	certPool := x509.NewCertPool()
-	if !certPool.AppendCertsFromPEM(pemServerCA) {
-		return nil, fmt.Errorf("failed to append server CA certificate")
-	}
-
-	// Create credentials from the loaded certs
-	config := &tls.Config{
-		RootCAs:      certPool,
-		Certificates: []tls.Certificate{clientCert},
-	}
-	return credentials.NewTLS(config), nil
-}
-
 func randomPoint() *protoc.Point {
 	lat := (rand.Int32N(180) - 90) * 1e7
 	long := (rand.Int32N(360) - 180) * 1e7
@@ -169,15 +152,30 @@ func randomPoint() *protoc.Point {
 func main() {
 	addr := flag.String("address", "localhost:8080", "Server address in the format host:port")
 	enableTLS := flag.Bool("tls", false, "Enable TLS for the connection")
+	tlsCert := flag.String("tls-cert", "certs/client.crt", "PEM certificate of the client")
+	tlsKey := flag.String("tls-key", "certs/client.key", "PEM private key of the client")
+	tlsCA := flag.String("tls-ca", "certs/ca.crt", "PEM CA certificates verifying the server certificate")
+	tlsReloadInterval := flag.Duration("tls-reload-interval", 30*time.Second, "How often the TLS certificate, key and CA files are checked for rotation")
+	apiKey := flag.String("api-key", "", "API key sent instead of logging in with a username and password")
+	simplifyTolerance := flag.Float64("simplify-tolerance", 0, "Tolerance in metres of the simplified recorded route, 0 to skip the simplification")
+	routeGeoJSON := flag.String("route-geojson", "", "File the recorded route is exported to by the server as a GeoJSON LineString, empty to skip the export")
 	flag.Parse()
 
 	transportOpts := grpc.WithTransportCredentials(insecure.NewCredentials())
 	if *enableTLS {
-		tlsCredentials, err := loadTLSCredentials()
+		certWatcher, err := tlsreload.NewWatcher(*tlsCert, *tlsKey, *tlsCA, 30*24*time.Hour)
 		if err != nil {
 			log.Fatalf("Failed to load TLS credentials: %v", err)
 		}
-		transportOpts = grpc.WithTransportCredentials(tlsCredentials)
+		// new connections use the rotated certificates without restarting the client
+		watchCtx, stopWatch := context.WithCancel(context.Background())
+		defer stopWatch()
+		go certWatcher.Watch(watchCtx, *tlsReloadInterval)
+		serverName, _, err := net.SplitHostPort(*addr)
+		if err != nil {
+			log.Fatalf("Invalid server address %q: %v", *addr, err)
+		}
+		transportOpts = grpc.WithTransportCredentials(credentials.NewTLS(certWatcher.ClientConfig(serverName)))
 	}
 
 	// keepalive option in connection
@@ -197,10 +195,13 @@ func main() {
 	}
 	defer conn.Close()
 
-	authClient := client.NewAuthClient(conn, "admin_valid", "password")
-	interceptor, err := client.NewAuthInterceptor(authClient, authMethods(), 5*time.Second)
-	if err != nil {
-		log.Fatalf("Failed to create auth interceptor: %v", err)
+	interceptor := client.NewAPIKeyInterceptor(*apiKey, authMethods())
+	if *apiKey == "" {
+		authClient := client.NewAuthClient(conn, "admin_valid", "Laptop#Store1")
+		interceptor, err = client.NewAuthInterceptor(authClient, authMethods(), 5*time.Second)
+		if err != nil {
+			log.Fatalf("Failed to create auth interceptor: %v", err)
+		}
 	}
 
 	connAuth, err := grpc.NewClient(*addr,
@@ -240,17 +241,52 @@ func main() {
 		log.Fatalf("Failed to list features: %v", err)
 	}
 
+	nearest, err := routeGuideClient.FindNearest(&protoc.Point{Latitude: 409146138, Longitude: -746188906}, 3)
+	if err != nil {
+		log.Fatalf("Failed to find nearest features: %v", err)
+	}
+	for _, found := range nearest {
+		log.Printf("Nearest feature: name: %q, distance: %dm", found.GetFeature().GetName(), found.GetDistance())
+	}
+
+	err = routeGuideClient.ListFeaturesWithinRadius(&protoc.Point{Latitude: 409146138, Longitude: -746188906}, 20000)
+	if err != nil {
+		log.Fatalf("Failed to list features within radius: %v", err)
+	}
+
 	// Create a random number of random points
 	pointCount := int(rand.Int32N(100)) + 2 // Traverse at least two points
 	var points []*protoc.Point
 	for range pointCount {
 		points = append(points, randomPoint())
 	}
-	err = routeGuideClient.RecordRoute(points)
+	summary, err := routeGuideClient.RecordRoute(points, *simplifyTolerance)
 	if err != nil {
 		log.Fatalf("Failed to record route: %v", err)
 	}
 
+	routes, err := routeGuideClient.ListRoutes(time.Now().Add(-24*time.Hour), time.Time{})
+	if err != nil {
+		log.Fatalf("Failed to list routes: %v", err)
+	}
+	log.Printf("Recorded %d routes today", len(routes))
+
+	route, err := routeGuideClient.GetRoute(summary.GetRouteId())
+	if err != nil {
+		log.Fatalf("Failed to get route: %v", err)
+	}
+	log.Printf("Route %s has %d points", route.GetId(), len(route.GetPoints()))
+	if *routeGeoJSON != "" {
+		data, err := routeGuideClient.ExportRoute(route.GetId())
+		if err != nil {
+			log.Fatalf("Failed to export route: %v", err)
+		}
+		err = os.WriteFile(*routeGeoJSON, data, 0o644)
+		if err != nil {
+			log.Fatalf("Failed to export route: %v", err)
+		}
+	}
+
 	// bidirectional streaming
 	notes := []*protoc.RouteNote{
 		{Location: &protoc.Point{Latitude: 0, Longitude: 1}, Message: "First message"},
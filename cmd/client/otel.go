@@ -110,7 +110,7 @@ func MAIN() {
 	}
 	defer cc.Close()
 
-	authc := client.NewAuthClient(cc, "admin_valid", "password")
+	authc := client.NewAuthClient(cc, "admin_valid", "Laptop#Store1")
 	interceptor, err := client.NewAuthInterceptor(authc, authMethods(), 5*time.Second)
 	if err != nil {
 		log.Fatalf("Failed to create auth interceptor: %v", err)
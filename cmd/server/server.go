@@ -19,24 +19,29 @@
 package main
 
 import (
+	"cmp"
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"maps"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
 	"syscall"
 	"time"
 
 	"aidanwoods.dev/go-paseto"
 	"buf.build/go/protovalidate"
+	"github.com/go-http-server/grpc/config"
+	"github.com/go-http-server/grpc/gateway"
 	"github.com/go-http-server/grpc/protoc"
 	"github.com/go-http-server/grpc/service"
+	"github.com/go-http-server/grpc/tlsreload"
 	protovalidate_middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/protovalidate"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel/exporters/prometheus"
@@ -47,6 +52,8 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	_ "google.golang.org/grpc/encoding/gzip" // gzip compression
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 
@@ -57,70 +64,76 @@ import (
 	otelgrpc "google.golang.org/grpc/stats/opentelemetry"
 )
 
-func createAccount(accStore service.AccountStore, username, password, role string) error {
-	user, err := service.NewAccount(username, password, role)
+// createAccount registers an account whose password meets the password policy.
+func createAccount(accStore service.AccountStore, hasher service.PasswordHasher, policy service.PasswordPolicy, username, password, role, tenant string) error {
+	err := policy.Validate(username, password)
 	if err != nil {
-		return err
+		return fmt.Errorf("account %s: %w", username, err)
 	}
 
-	return accStore.Save(user)
-}
-
-func seedAccounts(accStore service.AccountStore) error {
-	err := createAccount(accStore, "admin_valid", "password", "admin")
+	user, err := service.NewAccount(username, password, role, hasher)
 	if err != nil {
 		return err
 	}
+	user.Tenant = tenant
 
-	return createAccount(accStore, "user", "password", "user")
+	return accStore.Save(user)
 }
 
-func accessableRoles() map[string][]string {
-	const laptopServiceMethod = "/LaptopService/"
-	const routeGuideServiceMethod = "/RouteGuide/"
-	return map[string][]string{
-		laptopServiceMethod + "CreateLaptop":     {"admin"},
-		laptopServiceMethod + "RateLaptop":       {"admin", "user"},
-		laptopServiceMethod + "UploadImage":      {"admin"},
-		routeGuideServiceMethod + "GetFeature":   {"admin", "user"},
-		routeGuideServiceMethod + "ListFeatures": {"admin"},
-		routeGuideServiceMethod + "RecordRoute":  {"admin", "user"},
+// seedAccounts registers the accounts configured to exist at startup.
+func seedAccounts(accStore service.AccountStore, hasher service.PasswordHasher, policy service.PasswordPolicy, accounts []config.SeedAccount) error {
+	for _, account := range accounts {
+		err := createAccount(accStore, hasher, policy, account.Username, account.Password, account.Role, account.Tenant)
+		if err != nil {
+			return err
+		}
 	}
-}
 
-func loadTLSCredentials() (credentials.TransportCredentials, error) {
-	// Load TLS credentials from a file or other source
-	// For simplicity, we are returning nil here, which means no TLS is used.
-	// In a real application, you would load your TLS certs and keys.
-	serverCert, err := tls.LoadX509KeyPair("certs/server.crt", "certs/server.key")
-	if err != nil {
-		return nil, err
-	}
+	return nil
+}
 
-	pemClientCA, err := os.ReadFile("certs/ca.crt")
-	if err != nil {
-		return nil, err
+// newPasswordHasher returns the hasher of the given algorithm, "argon2id" or "bcrypt".
+func newPasswordHasher(algorithm string, bcryptCost int, argon2Params service.Argon2idParams) (service.PasswordHasher, error) {
+	switch algorithm {
+	case "argon2id":
+		return service.NewArgon2idHasher(argon2Params)
+	case "bcrypt":
+		return service.NewBcryptHasher(bcryptCost)
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", algorithm)
 	}
+}
 
-	clientCertPool := x509.NewCertPool()
-	if !clientCertPool.AppendCertsFromPEM(pemClientCA) {
-		return nil, fmt.Errorf("failed to append client CA certificate")
+// listen opens the socket of listener. A socket file left behind by a previous run is removed.
+func listen(listener config.Listener) (net.Listener, error) {
+	if listener.Network == "unix" {
+		info, err := os.Lstat(listener.Address)
+		if err == nil && info.Mode()&os.ModeSocket != 0 {
+			err = os.Remove(listener.Address)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	// create credentials from the loaded certs
-	config := &tls.Config{
-		Certificates: []tls.Certificate{serverCert},
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-		ClientCAs:    clientCertPool,
-	}
-	return credentials.NewTLS(config), nil
+	return net.Listen(listener.Network, listener.Address)
 }
 
 func main() {
-	port := flag.Int("port", 8080, "Port to run the server on")
-	enableTLS := flag.Bool("tls", false, "Enable TLS for the server")
-	promAddr := flag.String("prometheus_endpoint", ":9464", "the Prometheus exporter endpoint for metrics")
-	flag.Parse()
+	cfg, printConfig, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if printConfig {
+		err = cfg.Print(os.Stdout)
+		if err != nil {
+			log.Fatalf("cannot print config: %v", err)
+		}
+		return
+	}
 
 	// configuration open telemetry for grpc server
 	// prometheus exporter
@@ -189,29 +202,96 @@ func main() {
 	})
 
 	// run metrics server in a separate goroutine
-	go http.ListenAndServe(*promAddr, promhttp.Handler())
+	go http.ListenAndServe(cfg.PrometheusAddr, promhttp.Handler())
 
-	laptopServer := service.NewLaptopServer(service.NewInMemoryLaptopStore(), service.NewDiskImageStore("images"), service.NewInMemoryRatingStore())
+	laptopStore := service.NewInMemoryLaptopStore()
+	imageStore := service.NewDiskImageStore("images")
+	laptopServer := service.NewLaptopServer(laptopStore, imageStore, service.NewInMemoryRatingStore())
 	accountStore := service.NewInMemoryAccountStore()
-	tokenMaker := service.NewPasetoMaker(paseto.NewV4AsymmetricSecretKey(), paseto.NewParserWithoutExpiryCheck())
-	authServer := service.NewAuthServer(accountStore, tokenMaker)
-	routeGuideServer, err := service.NewRouteGuideServer()
+	tokenMaker := service.NewPasetoMaker(paseto.NewV4AsymmetricSecretKey(), service.TokenConfig{
+		Issuer:    cfg.Token.Issuer,
+		Audience:  cfg.Token.Audience,
+		ClockSkew: time.Duration(cfg.Token.ClockSkew),
+	})
+	loginLimiter := service.NewLoginLimiter(service.LoginLimiterConfig{
+		MaxFailures: cfg.Login.MaxFailures,
+		Window:      time.Duration(cfg.Login.Window),
+		BaseLockout: time.Duration(cfg.Login.BaseLockout),
+		MaxLockout:  time.Duration(cfg.Login.MaxLockout),
+	})
+	apiKeyStore, err := service.OpenFileAPIKeyStore(cfg.APIKeysFile)
 	if err != nil {
-		log.Fatalf("failed to create route guide server: %v", err)
+		log.Fatalf("failed to open api keys: %v", err)
 	}
-
-	authInterceptor := service.NewAuthInterceptor(tokenMaker, accessableRoles())
+	defer apiKeyStore.Close()
+	auditLog, err := service.OpenFileAuditLog(cfg.AuditLogFile)
+	if err != nil {
+		log.Fatalf("failed to open audit log: %v", err)
+	}
+	defer auditLog.Close()
+	if err := auditLog.Verified(); err != nil {
+		log.Printf("audit log verification failed: %v", err)
+	}
+	argon2Params := service.DefaultArgon2idParams
+	argon2Params.Time, argon2Params.Memory, argon2Params.Threads = uint32(cfg.Password.Argon2Time), uint32(cfg.Password.Argon2Memory), uint8(cfg.Password.Argon2Threads)
+	passwordHasher, err := newPasswordHasher(cfg.Password.Hash, cfg.Password.BcryptCost, argon2Params)
+	if err != nil {
+		log.Fatalf("failed to create password hasher: %v", err)
+	}
+	authServer, err := service.NewAuthServer(accountStore, passwordHasher, tokenMaker, time.Duration(cfg.Token.Duration), loginLimiter, apiKeyStore, auditLog)
+	if err != nil {
+		log.Fatalf("failed to create auth server: %v", err)
+	}
+	err = service.SeedFeatureFile(cfg.FeaturesFile, cfg.FeaturesSeedFile)
+	if err != nil {
+		log.Fatalf("failed to seed features: %v", err)
+	}
+	featureStore, err := service.OpenFileFeatureStore(cfg.FeaturesFile)
+	if err != nil {
+		log.Fatalf("failed to load features: %v", err)
+	}
+	routeStore, err := service.OpenFileRouteStore(cfg.RoutesFile)
+	if err != nil {
+		log.Fatalf("failed to load routes: %v", err)
+	}
+	defer routeStore.Close()
+	chatBackpressure, err := service.ParseChatBackpressure(cfg.Chat.Backpressure)
+	if err != nil {
+		log.Fatalf("invalid chat configuration: %v", err)
+	}
+	chatBroker, err := service.OpenChatBroker(service.ChatBrokerOptions{
+		BufferSize:   cfg.Chat.Buffer,
+		Backpressure: chatBackpressure,
+		HistoryLimit: cfg.Chat.History,
+		MaxLocations: cfg.Chat.MaxLocations,
+		HistoryFile:  cfg.Chat.HistoryFile,
+	})
+	if err != nil {
+		log.Fatalf("failed to load chat history: %v", err)
+	}
+	defer chatBroker.Close()
+	routeGuideServer := service.NewRouteGuideServer(featureStore, routeStore, chatBroker, cfg.RouteMaxPoints)
 
 	validator, err := protovalidate.New(
 		protovalidate.WithFailFast(),
 		protovalidate.WithMessages(
 			&protoc.LoginRequest{}, // make ensures validator has pre-warmed messages
+			&protoc.CreateApiKeyRequest{},
+			&protoc.RevokeApiKeyRequest{},
+			&protoc.ListAuditEventsRequest{},
 			&protoc.CreateLaptopRequest{},
+			&protoc.GetLaptopRequest{},
 			&protoc.SearchLaptopRequest{},
 			&protoc.RateLaptopRequest{},
 			&protoc.UploadImageRequest{},
 			&protoc.Point{},
 			&protoc.Rectangle{},
+			&protoc.FindNearestRequest{},
+			&protoc.RadiusRequest{},
+			&protoc.UpdateFeatureRequest{},
+			&protoc.GeoJson{},
+			&protoc.GetRouteRequest{},
+			&protoc.WatchRegionRequest{},
 			&protoc.RouteNote{},
 		),
 		// protovalidate.WithMessages(protoc.File_auth_auth_service_proto.Options()), // wrong pre-warn declaration, haven't error runtime, but no effect
@@ -222,86 +302,317 @@ func main() {
 
 	// keepalive grpc server option make keepalive working.
 	kaep := keepalive.EnforcementPolicy{
-		MinTime:             5 * time.Second, // if a client pings more than once every 5 seconds, terminate the connection
-		PermitWithoutStream: true,            // allow pings even when there are no active streams
+		MinTime:             time.Duration(cfg.Keepalive.MinTime), // a client pinging more often is disconnected
+		PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,    // allow pings even when there are no active streams
 	}
 	kasp := keepalive.ServerParameters{
-		MaxConnectionIdle:     15 * time.Second, // If a client is idle for 15 seconds, send a GOAWAY
-		MaxConnectionAge:      30 * time.Second, // If any connection is alive for more than 30 seconds, send a GOAWAY
-		MaxConnectionAgeGrace: 5 * time.Second,  // Allow 5 seconds for pending RPCs to complete before forcibly closing connections
-		Time:                  5 * time.Second,  // Ping the client if it is idle for 5 seconds to ensure the connection is still active
-		Timeout:               1 * time.Second,  // Wait 1 second for the ping ack before assuming the connection is dead
-	}
-
-	// configure gRPC server options, enabling authentication and optionally TLS
-	grpcServerOpts := []grpc.ServerOption{
-		grpc.KeepaliveEnforcementPolicy(kaep),
-		grpc.KeepaliveParams(kasp),
-		grpc.ChainUnaryInterceptor(
+		MaxConnectionIdle:     time.Duration(cfg.Keepalive.MaxConnectionIdle),     // send a GOAWAY to a client idle this long
+		MaxConnectionAge:      time.Duration(cfg.Keepalive.MaxConnectionAge),      // send a GOAWAY to a connection alive this long
+		MaxConnectionAgeGrace: time.Duration(cfg.Keepalive.MaxConnectionAgeGrace), // time for pending RPCs to complete before forcibly closing connections
+		Time:                  time.Duration(cfg.Keepalive.Time),                  // ping a client idle this long to ensure the connection is still active
+		Timeout:               time.Duration(cfg.Keepalive.Timeout),               // wait for the ping ack before assuming the connection is dead
+	}
+
+	listeners := cfg.EffectiveListeners()
+
+	var certWatcher *tlsreload.Watcher
+	if slices.ContainsFunc(listeners, func(listener config.Listener) bool { return listener.TLS }) {
+		// certificates are reloaded when rotated, every handshake uses the current ones
+		certWatcher, err = tlsreload.NewWatcher(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile, time.Duration(cfg.TLS.ExpiryWarning))
+		if err != nil {
+			log.Fatalf("failed to load TLS credentials: %v", err)
+		}
+		err = certWatcher.RegisterMetrics(mp.Meter("github.com/go-http-server/grpc/tlsreload"))
+		if err != nil {
+			log.Fatalf("failed to register TLS metrics: %v", err)
+		}
+	}
+
+	// throttle hot clients on every listener, the buckets of a caller are shared by the listeners
+	rateLimitRules := make([]service.RateLimitRule, len(cfg.RateLimits))
+	for i, limit := range cfg.RateLimits {
+		rateLimitRules[i] = service.RateLimitRule{
+			Method:        limit.Method,
+			Per:           service.RateLimitKey(limit.Per),
+			Rate:          limit.Rate,
+			Burst:         limit.Burst,
+			MaxConcurrent: limit.MaxConcurrent,
+		}
+	}
+	rateLimitInterceptor, err := service.NewRateLimitInterceptor(rateLimitRules)
+	if err != nil {
+		log.Fatalf("invalid rate limits: %v", err)
+	}
+	err = rateLimitInterceptor.RegisterMetrics(mp.Meter("github.com/go-http-server/grpc/service"))
+	if err != nil {
+		log.Fatalf("failed to register rate limit metrics: %v", err)
+	}
+
+	// shed low priority calls when the latency grows, the limit is shared by the listeners
+	var loadShedInterceptor *service.LoadShedInterceptor
+	if cfg.LoadShedding.Enabled {
+		rolePriorities := make(map[string]service.Priority, len(cfg.LoadShedding.RolePriorities))
+		for role, name := range cfg.LoadShedding.RolePriorities {
+			rolePriorities[role], err = service.ParsePriority(name)
+			if err != nil {
+				log.Fatalf("invalid load shedding priority of role %s: %v", role, err)
+			}
+		}
+		loadShedInterceptor, err = service.NewLoadShedInterceptor(service.LoadShedConfig{
+			InitialLimit:   cfg.LoadShedding.InitialLimit,
+			MinLimit:       cfg.LoadShedding.MinLimit,
+			MaxLimit:       cfg.LoadShedding.MaxLimit,
+			Tolerance:      cfg.LoadShedding.Tolerance,
+			Smoothing:      cfg.LoadShedding.Smoothing,
+			RolePriorities: rolePriorities,
+		})
+		if err != nil {
+			log.Fatalf("invalid load shedding: %v", err)
+		}
+		err = loadShedInterceptor.RegisterMetrics(mp.Meter("github.com/go-http-server/grpc/service"))
+		if err != nil {
+			log.Fatalf("failed to register load shedding metrics: %v", err)
+		}
+	}
+
+	// report each service NOT_SERVING while a store backing it fails, on every listener
+	healthServer := health.NewServer()
+	healthMonitor := service.NewHealthMonitor(healthServer)
+	healthMonitor.Add(protoc.LaptopService_ServiceDesc.ServiceName, laptopStore, imageStore)
+	healthMonitor.Add(protoc.AuthService_ServiceDesc.ServiceName, apiKeyStore)
+	healthMonitor.Add(protoc.RouteGuide_ServiceDesc.ServiceName, featureStore, routeStore)
+
+	registerServices := map[string]func(*grpc.Server){
+		protoc.LaptopService_ServiceDesc.ServiceName: func(s *grpc.Server) { protoc.RegisterLaptopServiceServer(s, laptopServer) },
+		protoc.AuthService_ServiceDesc.ServiceName:   func(s *grpc.Server) { protoc.RegisterAuthServiceServer(s, authServer) },
+		protoc.RouteGuide_ServiceDesc.ServiceName:    func(s *grpc.Server) { protoc.RegisterRouteGuideServer(s, routeGuideServer) },
+	}
+
+	// each listener has its own gRPC server, as credentials and interceptors are set per server;
+	// listeners naming the same policy or identities file share one watcher
+	policyWatchers := make(map[string]*service.PolicyWatcher)
+	policyMethods := make(map[string][]string)
+	identities := make(map[string]*service.CertIdentities)
+	grpcServers := make([]*grpc.Server, len(listeners))
+	httpServers := make([]*http.Server, len(listeners)) // set for the http listeners, serving the gateway
+	gatewayOptions := gateway.Options{CORS: gateway.CORS{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           time.Duration(cfg.CORS.MaxAge),
+	}}
+	for i, listener := range listeners {
+		policyWatcher, ok := policyWatchers[listener.PolicyFile]
+		if !ok {
+			policyWatcher, err = service.NewPolicyWatcher(listener.PolicyFile)
+			if err != nil {
+				log.Fatalf("failed to load policy: %v", err)
+			}
+			if cfg.Roles != nil {
+				policyWatcher.SetRoles(cfg.Roles)
+			}
+			policyWatchers[listener.PolicyFile] = policyWatcher
+		}
+
+		certIdentities, ok := identities[listener.CertIdentitiesFile]
+		if !ok && listener.CertIdentitiesFile != "" {
+			certIdentities, err = service.LoadCertIdentities(listener.CertIdentitiesFile)
+			if err != nil {
+				log.Fatalf("failed to load certificate identities: %v", err)
+			}
+			identities[listener.CertIdentitiesFile] = certIdentities
+		}
+
+		authInterceptor := service.NewAuthInterceptor(tokenMaker, policyWatcher, certIdentities, apiKeyStore)
+		auditInterceptor := service.NewAuditInterceptor(auditLog, policyWatcher)
+
+		// configure gRPC server options, enabling authentication and the credentials of the listener
+		// the load shedding runs before the costly interceptors, then by role right before the handler
+		var unaryInterceptors []grpc.UnaryServerInterceptor
+		var streamInterceptors []grpc.StreamServerInterceptor
+		if loadShedInterceptor != nil {
+			unaryInterceptors = append(unaryInterceptors, loadShedInterceptor.Unary())
+			streamInterceptors = append(streamInterceptors, loadShedInterceptor.Stream())
+		}
+		unaryInterceptors = append(unaryInterceptors,
+			auditInterceptor.Unary(),
 			protovalidate_middleware.UnaryServerInterceptor(validator),
 			authInterceptor.Unary(),
-		),
-		grpc.ChainStreamInterceptor(
+		)
+		streamInterceptors = append(streamInterceptors,
+			auditInterceptor.Stream(),
 			protovalidate_middleware.StreamServerInterceptor(validator),
 			authInterceptor.Stream(),
-		),
-		so,
-	}
-	if *enableTLS {
-		tlsCredentials, err := loadTLSCredentials()
+		)
+		unaryInterceptors = append(unaryInterceptors, rateLimitInterceptor.Unary())
+		streamInterceptors = append(streamInterceptors, rateLimitInterceptor.Stream())
+		if loadShedInterceptor != nil {
+			unaryInterceptors = append(unaryInterceptors, loadShedInterceptor.RoleUnary())
+			streamInterceptors = append(streamInterceptors, loadShedInterceptor.RoleStream())
+		}
+		grpcServerOpts := []grpc.ServerOption{
+			grpc.KeepaliveEnforcementPolicy(kaep),
+			grpc.KeepaliveParams(kasp),
+			grpc.ChainUnaryInterceptor(unaryInterceptors...),
+			grpc.ChainStreamInterceptor(streamInterceptors...),
+			so,
+		}
+		switch {
+		case listener.Protocol == "http":
+			// the HTTP server terminates TLS, the gateway hands the connection state to the calls of
+			// every protocol
+		case listener.TLS:
+			grpcServerOpts = append(grpcServerOpts, grpc.Creds(credentials.NewTLS(certWatcher.ServerConfig())))
+		case listener.Network == "unix":
+			// record the uid of socket peers, so cert identities can trust local processes
+			grpcServerOpts = append(grpcServerOpts, grpc.Creds(service.NewPeerCredentials()))
+		}
+
+		// create a new gRPC server with the configured options and register the services of the listener
+		grpcServer := grpc.NewServer(grpcServerOpts...)
+		services := listener.Services
+		if len(services) == 0 {
+			services = slices.Sorted(maps.Keys(registerServices))
+		}
+		for _, name := range services {
+			register, ok := registerServices[name]
+			if !ok {
+				log.Fatalf("listener %s: unknown service %q", listener.Name, name)
+			}
+			register(grpcServer)
+		}
+		healthpb.RegisterHealthServer(grpcServer, healthServer)
+		reflection.Register(grpcServer)
+		grpcServers[i] = grpcServer
+		if listener.Protocol == "http" {
+			httpServers[i] = &http.Server{Handler: gateway.New(grpcServer, gatewayOptions)}
+			if listener.TLS {
+				httpServers[i].TLSConfig = certWatcher.HTTPServerConfig()
+			} else {
+				// native gRPC clients need HTTP/2, without TLS they speak it as h2c
+				httpServers[i].Protocols = new(http.Protocols)
+				httpServers[i].Protocols.SetHTTP1(true)
+				httpServers[i].Protocols.SetUnencryptedHTTP2(true)
+			}
+		}
+
+		methods, err := service.ServiceMethods(grpcServer)
 		if err != nil {
-			log.Fatalf("failed to load TLS credentials: %v", err)
+			log.Fatalf("cannot list registered methods: %v", err)
 		}
-		grpcServerOpts = append(grpcServerOpts, grpc.Creds(tlsCredentials))
+		policyMethods[listener.PolicyFile] = append(policyMethods[listener.PolicyFile], methods...)
 	}
 
-	// create a new gRPC server with the configured options
-	grpcServer := grpc.NewServer(grpcServerOpts...)
-
-	// register the services with the gRPC server
-	protoc.RegisterLaptopServiceServer(grpcServer, laptopServer)
-	protoc.RegisterAuthServiceServer(grpcServer, authServer)
-	protoc.RegisterRouteGuideServer(grpcServer, routeGuideServer)
-	reflection.Register(grpcServer)
+	// validate the policies against the services of their listeners, so a typo in a method name fails fast
+	for filename, policyWatcher := range policyWatchers {
+		methods := slices.Compact(slices.Sorted(slices.Values(policyMethods[filename])))
+		err = policyWatcher.Validate(methods)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
 
-	err = seedAccounts(accountStore)
+	passwordPolicy := service.DefaultPasswordPolicy
+	passwordPolicy.MinLength, passwordPolicy.MinCharClasses = cfg.Password.MinLength, cfg.Password.MinClasses
+	err = seedAccounts(accountStore, passwordHasher, passwordPolicy, cfg.SeedAccounts)
 	if err != nil {
 		log.Fatalf("cannot seed accounts: %s", err)
 	}
 
-	addr := fmt.Sprintf("0.0.0.0:%d", *port)
-	listener, err := net.Listen("tcp", addr)
-	if err != nil {
-		log.Fatalf("cannot create server on port :%d, err: %s", *port, err)
+	netListeners := make([]net.Listener, len(listeners))
+	for i, listener := range listeners {
+		netListeners[i], err = listen(listener)
+		if err != nil {
+			log.Fatalf("cannot create %s listener on %s %s, err: %s", listener.Name, listener.Network, listener.Address, err)
+		}
 	}
 
 	// create signal context to handle graceful shutdown from interrupt, sigterm, sisint signals
 	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 	defer stop()
 
-	// create group to manage server goroutine and shutdown goroutine, ctx serve task handler in worker pool
+	// create group to manage server goroutines and shutdown goroutine, ctx serve task handler in worker pool
 	gr, ctx := errgroup.WithContext(sigCtx)
 
-	gr.Go(func() error {
-		log.Printf("Starting server on port :%d with tls option: %t", *port, *enableTLS)
-		err = grpcServer.Serve(listener)
-		if err != nil {
-			if errors.Is(err, grpc.ErrServerStopped) {
-				return nil
+	for i, listener := range listeners {
+		gr.Go(func() error {
+			log.Printf("Starting %s listener on %s %s with tls option: %t, protocol: %s", listener.Name, listener.Network, listener.Address, listener.TLS, cmp.Or(listener.Protocol, "grpc"))
+			var err error
+			switch {
+			case httpServers[i] == nil:
+				err = grpcServers[i].Serve(netListeners[i])
+			case listener.TLS:
+				err = httpServers[i].ServeTLS(netListeners[i], "", "")
+			default:
+				err = httpServers[i].Serve(netListeners[i])
 			}
+			if err != nil {
+				if errors.Is(err, grpc.ErrServerStopped) || errors.Is(err, http.ErrServerClosed) {
+					return nil
+				}
 
-			log.Printf("cannot start server on port :%d, err: %s", *port, err)
-			return err
-		}
+				log.Printf("cannot serve %s listener on %s, err: %s", listener.Name, listener.Address, err)
+				return err
+			}
 
-		return nil
+			return nil
+		})
+	}
+
+	for _, policyWatcher := range policyWatchers {
+		gr.Go(func() error {
+			return policyWatcher.Watch(ctx, time.Duration(cfg.PolicyReloadInterval))
+		})
+	}
+
+	gr.Go(func() error {
+		return auditLog.Watch(ctx, time.Duration(cfg.AuditVerifyInterval))
 	})
 
+	gr.Go(func() error {
+		return healthMonitor.Run(ctx, time.Duration(cfg.Health.Interval), time.Duration(cfg.Health.Timeout))
+	})
+
+	if certWatcher != nil {
+		gr.Go(func() error {
+			return certWatcher.Watch(ctx, time.Duration(cfg.TLS.ReloadInterval))
+		})
+	}
+
+	if cfg.FeaturesReloadInterval > 0 {
+		gr.Go(func() error {
+			return featureStore.Watch(ctx, time.Duration(cfg.FeaturesReloadInterval))
+		})
+	}
+
 	gr.Go(func() error {
 		<-ctx.Done()
-		// implement graceful shutdown
+		// implement graceful shutdown, failing health checks first so load balancers stop sending new calls
 		log.Println("shutting down gRPC server...")
-		grpcServer.GracefulStop()
+		healthMonitor.Shutdown()
+		time.Sleep(time.Duration(cfg.Health.ShutdownDrainDelay))
+		var wg sync.WaitGroup
+		for i, grpcServer := range grpcServers {
+			httpServer := httpServers[i]
+			if httpServer == nil {
+				wg.Go(grpcServer.GracefulStop)
+				continue
+			}
+
+			// gateway calls cannot be drained by the gRPC server, wait for their HTTP requests instead
+			wg.Go(func() {
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Keepalive.MaxConnectionAgeGrace))
+				defer cancel()
+				err := httpServer.Shutdown(shutdownCtx)
+				if err != nil {
+					log.Printf("cannot shut down %s listener gracefully: %v", listeners[i].Name, err)
+				}
+				grpcServer.Stop()
+				httpServer.Close()
+			})
+		}
+		wg.Wait()
 		return nil
 	})
 
@@ -0,0 +1,619 @@
+// Package config loads the configuration of cmd/server. Each setting takes its value from, in
+// increasing priority: the defaults, the YAML file, the SERVER_* environment variables and the flags.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variable of every flag, -features-file is set by SERVER_FEATURES_FILE.
+const EnvPrefix = "SERVER_"
+
+// Config is the configuration of cmd/server.
+type Config struct {
+	Port           int       `yaml:"port"`
+	PrometheusAddr string    `yaml:"prometheus_addr"`
+	GatewayAddr    string    `yaml:"gateway_addr"` // HTTP/JSON gateway of the default listener, disabled when empty
+	CORS           CORS      `yaml:"cors"`
+	TLS            TLS       `yaml:"tls"`
+	Keepalive      Keepalive `yaml:"keepalive"`
+	Token          Token     `yaml:"token"`
+
+	PolicyFile           string              `yaml:"policy_file"`
+	PolicyReloadInterval Duration            `yaml:"policy_reload_interval"`
+	Roles                map[string][]string `yaml:"roles,omitempty"` // role -> permissions, replaces the roles of the policy file when set
+	CertIdentitiesFile   string              `yaml:"cert_identities_file"`
+	AuditLogFile         string              `yaml:"audit_log_file"`
+	AuditVerifyInterval  Duration            `yaml:"audit_verify_interval"`
+	APIKeysFile          string              `yaml:"api_keys_file"`
+
+	Login        Login         `yaml:"login"`
+	Password     Password      `yaml:"password"`
+	SeedAccounts []SeedAccount `yaml:"seed_accounts"`
+	RateLimits   []RateLimit   `yaml:"rate_limits,omitempty"`
+	LoadShedding LoadShedding  `yaml:"load_shedding"`
+
+	FeaturesFile           string   `yaml:"features_file"`
+	FeaturesSeedFile       string   `yaml:"features_seed_file"`
+	FeaturesReloadInterval Duration `yaml:"features_reload_interval"`
+	RoutesFile             string   `yaml:"routes_file"`
+	RouteMaxPoints         int      `yaml:"route_max_points"`
+	Chat                   Chat     `yaml:"chat"`
+	Health                 Health   `yaml:"health"`
+
+	// Listeners replace the single listener of port and tls.enabled when set.
+	Listeners []Listener `yaml:"listeners,omitempty"`
+}
+
+// Listener is an address the services are served on, with its own credentials and access policy:
+//
+//	listeners:
+//	  - {name: public, network: tcp, address: "0.0.0.0:8443", tls: true}
+//	  - {name: sidecar, network: unix, address: /run/grpc/server.sock, cert_identities_file: uids.yaml}
+//	  - {name: admin, network: tcp, address: "127.0.0.1:9443", policy_file: admin_policy.yaml, services: [AuthService]}
+//	  - {name: web, network: tcp, address: "0.0.0.0:8081", tls: true, protocol: http}
+type Listener struct {
+	Name    string `yaml:"name"`
+	Network string `yaml:"network"`       // tcp or unix
+	Address string `yaml:"address"`       // host:port or socket path
+	TLS     bool   `yaml:"tls,omitempty"` // serve the tls key pair and require client certificates, tcp only
+	// Protocol is grpc, the default, or http to serve native gRPC, gRPC-Web, Connect and HTTP/JSON REST
+	// routes on one port, see package gateway. Without tls, an http listener serves HTTP/2 as h2c;
+	// with tls, it requests client certificates without requiring them.
+	Protocol string `yaml:"protocol,omitempty"`
+	// PolicyFile and CertIdentitiesFile default to the top level ones. Unix socket peers are
+	// identified by the uid entries of the cert identities.
+	PolicyFile         string   `yaml:"policy_file,omitempty"`
+	CertIdentitiesFile string   `yaml:"cert_identities_file,omitempty"`
+	Services           []string `yaml:"services,omitempty"` // service names served, all when empty; health and reflection are always served
+}
+
+// EffectiveListeners returns the listeners to serve, with their defaults applied.
+func (cfg *Config) EffectiveListeners() []Listener {
+	if len(cfg.Listeners) == 0 {
+		listeners := []Listener{{
+			Name:               "default",
+			Network:            "tcp",
+			Address:            fmt.Sprintf("0.0.0.0:%d", cfg.Port),
+			TLS:                cfg.TLS.Enabled,
+			PolicyFile:         cfg.PolicyFile,
+			CertIdentitiesFile: cfg.CertIdentitiesFile,
+		}}
+		if cfg.GatewayAddr != "" {
+			gateway := listeners[0]
+			gateway.Name, gateway.Address, gateway.Protocol = "gateway", cfg.GatewayAddr, "http"
+			listeners = append(listeners, gateway)
+		}
+		return listeners
+	}
+
+	listeners := slices.Clone(cfg.Listeners)
+	for i := range listeners {
+		if listeners[i].PolicyFile == "" {
+			listeners[i].PolicyFile = cfg.PolicyFile
+		}
+		if listeners[i].CertIdentitiesFile == "" {
+			listeners[i].CertIdentitiesFile = cfg.CertIdentitiesFile
+		}
+	}
+	return listeners
+}
+
+// CORS lets browser pages of other origins call the http listeners.
+type CORS struct {
+	AllowedOrigins   []string `yaml:"allowed_origins,omitempty"` // such as https://app.example.com, or * for any; none when empty
+	AllowedHeaders   []string `yaml:"allowed_headers,omitempty"` // request headers besides those of the protocols and credentials
+	ExposedHeaders   []string `yaml:"exposed_headers,omitempty"` // response headers scripts may read besides the gRPC status
+	AllowCredentials bool     `yaml:"allow_credentials"`
+	MaxAge           Duration `yaml:"max_age"` // how long browsers may cache a preflight answer
+}
+
+// TLS locates the server key pair and the CA verifying client certificates.
+type TLS struct {
+	Enabled        bool     `yaml:"enabled"` // TLS on the default listener, listeners set it per listener
+	CertFile       string   `yaml:"cert_file"`
+	KeyFile        string   `yaml:"key_file"`
+	ClientCAFile   string   `yaml:"client_ca_file"`
+	ReloadInterval Duration `yaml:"reload_interval"` // how often the files are checked for rotated certificates
+	ExpiryWarning  Duration `yaml:"expiry_warning"`  // certificates expiring sooner are logged
+}
+
+// Keepalive holds the keepalive enforcement policy and server parameters.
+type Keepalive struct {
+	MinTime               Duration `yaml:"min_time"` // minimum time between client pings
+	PermitWithoutStream   bool     `yaml:"permit_without_stream"`
+	MaxConnectionIdle     Duration `yaml:"max_connection_idle"`
+	MaxConnectionAge      Duration `yaml:"max_connection_age"`
+	MaxConnectionAgeGrace Duration `yaml:"max_connection_age_grace"`
+	Time                  Duration `yaml:"time"`    // idle time before the server pings the client
+	Timeout               Duration `yaml:"timeout"` // wait for the ping ack
+}
+
+// Token configures the access tokens issued by Login.
+type Token struct {
+	Duration  Duration `yaml:"duration"`
+	Issuer    string   `yaml:"issuer"`
+	Audience  string   `yaml:"audience"`
+	ClockSkew Duration `yaml:"clock_skew"`
+}
+
+// Login configures the lockout of failed logins.
+type Login struct {
+	MaxFailures int      `yaml:"max_failures"`
+	Window      Duration `yaml:"window"`
+	BaseLockout Duration `yaml:"base_lockout"`
+	MaxLockout  Duration `yaml:"max_lockout"`
+}
+
+// Password configures the password hashing and policy.
+type Password struct {
+	Hash          string `yaml:"hash"` // argon2id or bcrypt
+	BcryptCost    int    `yaml:"bcrypt_cost"`
+	Argon2Time    uint   `yaml:"argon2_time"`
+	Argon2Memory  uint   `yaml:"argon2_memory"` // KiB
+	Argon2Threads uint   `yaml:"argon2_threads"`
+	MinLength     int    `yaml:"min_length"`
+	MinClasses    int    `yaml:"min_classes"`
+}
+
+// SeedAccount is an account created at startup.
+type SeedAccount struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Role     string `yaml:"role"`
+	Tenant   string `yaml:"tenant"`
+}
+
+// RateLimit throttles the calls of each method matching Method, every matching limit applies:
+//
+//	rate_limits:
+//	  - {method: /LaptopService/SearchLaptop, per: principal, rate: 5, burst: 10, max_concurrent: 32}
+//	  - {method: /AuthService/*, per: peer, rate: 20, burst: 40}
+type RateLimit struct {
+	Method        string  `yaml:"method"`         // full method name or pattern, as in the policy file
+	Per           string  `yaml:"per"`            // principal, the peer IP for anonymous calls, or peer
+	Rate          float64 `yaml:"rate,omitempty"` // calls per second of each caller, 0 for no rate limit
+	Burst         int     `yaml:"burst,omitempty"`
+	MaxConcurrent int     `yaml:"max_concurrent,omitempty"` // calls in progress at once over all callers, 0 for no limit
+}
+
+// LoadShedding configures the adaptive limit of the unary calls in progress, which sheds the calls
+// of the lowest priorities first when their latency grows:
+//
+//	load_shedding:
+//	  enabled: true
+//	  initial_limit: 100
+//	  role_priorities: {admin: high, batch: low}
+type LoadShedding struct {
+	Enabled        bool              `yaml:"enabled"`
+	InitialLimit   int               `yaml:"initial_limit"`
+	MinLimit       int               `yaml:"min_limit"`
+	MaxLimit       int               `yaml:"max_limit"`
+	Tolerance      float64           `yaml:"tolerance"`                 // growth of the latency over its baseline tolerated before the limit shrinks
+	Smoothing      float64           `yaml:"smoothing"`                 // weight of each new estimate of the limit, between 0 and 1
+	RolePriorities map[string]string `yaml:"role_priorities,omitempty"` // role -> low, normal, high or critical; normal by default
+}
+
+// Chat configures the RouteChat broker.
+type Chat struct {
+	Buffer       int    `yaml:"buffer"`
+	Backpressure string `yaml:"backpressure"` // drop-oldest, drop-newest or disconnect
+	History      int    `yaml:"history"`
+	MaxLocations int    `yaml:"max_locations"`
+	HistoryFile  string `yaml:"history_file"`
+}
+
+// Health configures the store health checks and the shutdown.
+type Health struct {
+	Interval           Duration `yaml:"interval"`
+	Timeout            Duration `yaml:"timeout"`
+	ShutdownDrainDelay Duration `yaml:"shutdown_drain_delay"`
+}
+
+// Duration is a time.Duration written as "1m30s" in YAML.
+type Duration time.Duration
+
+func (d Duration) MarshalYAML() (any, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+	err := node.Decode(&value)
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() Config {
+	return Config{
+		Port:           8080,
+		PrometheusAddr: ":9464",
+		CORS:           CORS{MaxAge: Duration(10 * time.Minute)},
+		TLS: TLS{
+			CertFile:       "certs/server.crt",
+			KeyFile:        "certs/server.key",
+			ClientCAFile:   "certs/ca.crt",
+			ReloadInterval: Duration(30 * time.Second),
+			ExpiryWarning:  Duration(30 * 24 * time.Hour),
+		},
+		Keepalive: Keepalive{
+			MinTime:               Duration(5 * time.Second),
+			PermitWithoutStream:   true,
+			MaxConnectionIdle:     Duration(15 * time.Second),
+			MaxConnectionAge:      Duration(30 * time.Second),
+			MaxConnectionAgeGrace: Duration(5 * time.Second),
+			Time:                  Duration(5 * time.Second),
+			Timeout:               Duration(time.Second),
+		},
+		Token: Token{
+			Duration:  Duration(5 * time.Minute),
+			Issuer:    "grpc-server",
+			ClockSkew: Duration(30 * time.Second),
+		},
+		PolicyFile:           "policy.yaml",
+		PolicyReloadInterval: Duration(5 * time.Second),
+		AuditLogFile:         "audit.log",
+		AuditVerifyInterval:  Duration(time.Minute),
+		APIKeysFile:          "api_keys.jsonl",
+		Login: Login{
+			MaxFailures: 5,
+			Window:      Duration(15 * time.Minute),
+			BaseLockout: Duration(time.Minute),
+			MaxLockout:  Duration(time.Hour),
+		},
+		Password: Password{
+			Hash:          "argon2id",
+			BcryptCost:    10,
+			Argon2Time:    2,
+			Argon2Memory:  19 * 1024,
+			Argon2Threads: 1,
+			MinLength:     10,
+			MinClasses:    3,
+		},
+		SeedAccounts: []SeedAccount{
+			{Username: "admin_valid", Password: "Laptop#Store1", Role: "admin", Tenant: "default"},
+			{Username: "user", Password: "Laptop#Store1", Role: "user", Tenant: "default"},
+		},
+		RateLimits: []RateLimit{
+			// a flood of searches would hold the read lock of the laptop store
+			{Method: "/LaptopService/SearchLaptop", Per: "principal", Rate: 10, Burst: 20, MaxConcurrent: 64},
+		},
+		LoadShedding: LoadShedding{
+			Enabled:        true,
+			InitialLimit:   100,
+			MinLimit:       10,
+			MaxLimit:       1000,
+			Tolerance:      2,
+			Smoothing:      0.2,
+			RolePriorities: map[string]string{"admin": "high"},
+		},
+		FeaturesFile:     "features.json",
+		FeaturesSeedFile: "sample/route_guide.json",
+		RoutesFile:       "routes.jsonl",
+		RouteMaxPoints:   100000,
+		Chat: Chat{
+			Buffer:       64,
+			Backpressure: "drop-oldest",
+			History:      100,
+			MaxLocations: 10000,
+		},
+		Health: Health{
+			Interval: Duration(10 * time.Second),
+			Timeout:  Duration(2 * time.Second),
+		},
+	}
+}
+
+// bindFlags defines the flags setting cfg, their defaults are the current values of cfg.
+func bindFlags(fs *flag.FlagSet, cfg *Config) {
+	duration := func(d *Duration, name, usage string) {
+		fs.DurationVar((*time.Duration)(d), name, time.Duration(*d), usage)
+	}
+
+	fs.IntVar(&cfg.Port, "port", cfg.Port, "Port to run the server on")
+	fs.StringVar(&cfg.PrometheusAddr, "prometheus_endpoint", cfg.PrometheusAddr, "the Prometheus exporter endpoint for metrics")
+	fs.StringVar(&cfg.GatewayAddr, "gateway-addr", cfg.GatewayAddr, "Address of the HTTP/JSON REST gateway, e.g. :8081, disabled when empty")
+	fs.Var(stringList{&cfg.CORS.AllowedOrigins}, "cors-allowed-origins", "Comma-separated origins browsers may call the http listeners from, * for any")
+	fs.BoolVar(&cfg.LoadShedding.Enabled, "load-shedding", cfg.LoadShedding.Enabled, "Shed low priority calls when the latency of the unary calls grows")
+	fs.BoolVar(&cfg.TLS.Enabled, "tls", cfg.TLS.Enabled, "Enable TLS for the server")
+	fs.StringVar(&cfg.TLS.CertFile, "tls-cert", cfg.TLS.CertFile, "PEM certificate of the server")
+	fs.StringVar(&cfg.TLS.KeyFile, "tls-key", cfg.TLS.KeyFile, "PEM private key of the server")
+	fs.StringVar(&cfg.TLS.ClientCAFile, "tls-client-ca", cfg.TLS.ClientCAFile, "PEM CA certificates verifying client certificates")
+	duration(&cfg.TLS.ReloadInterval, "tls-reload-interval", "How often the TLS certificate, key and CA files are checked for rotation")
+	duration(&cfg.TLS.ExpiryWarning, "tls-expiry-warning", "Certificates expiring within this duration are logged as warnings")
+	duration(&cfg.Keepalive.MinTime, "keepalive-min-time", "Minimum time between client pings, faster clients are disconnected")
+	fs.BoolVar(&cfg.Keepalive.PermitWithoutStream, "keepalive-permit-without-stream", cfg.Keepalive.PermitWithoutStream, "Allow client pings when there is no active stream")
+	duration(&cfg.Keepalive.MaxConnectionIdle, "keepalive-max-idle", "Idle time after which a connection is sent a GOAWAY")
+	duration(&cfg.Keepalive.MaxConnectionAge, "keepalive-max-age", "Age after which a connection is sent a GOAWAY")
+	duration(&cfg.Keepalive.MaxConnectionAgeGrace, "keepalive-max-age-grace", "Time pending RPCs have to complete after MaxConnectionAge before the connection is closed")
+	duration(&cfg.Keepalive.Time, "keepalive-time", "Idle time after which the server pings the client")
+	duration(&cfg.Keepalive.Timeout, "keepalive-timeout", "Wait for the ping ack before the connection is considered dead")
+	duration(&cfg.Token.Duration, "token-duration", "Lifetime of access tokens issued by Login")
+	fs.StringVar(&cfg.Token.Issuer, "token-issuer", cfg.Token.Issuer, "Issuer (iss) claim set on and required from access tokens")
+	fs.StringVar(&cfg.Token.Audience, "token-audience", cfg.Token.Audience, "Audience (aud) claim set on and required from access tokens, empty to skip the check")
+	duration(&cfg.Token.ClockSkew, "token-clock-skew", "Allowed clock skew when checking token time claims")
+	fs.StringVar(&cfg.PolicyFile, "policy", cfg.PolicyFile, "RBAC policy file (YAML or JSON) mapping roles to permissions and permissions to methods")
+	duration(&cfg.PolicyReloadInterval, "policy-reload-interval", "How often the policy file is checked for changes")
+	fs.StringVar(&cfg.CertIdentitiesFile, "cert-identities", cfg.CertIdentitiesFile, "File mapping verified client certificates to principals, empty disables certificate authentication")
+	fs.StringVar(&cfg.AuditLogFile, "audit-log", cfg.AuditLogFile, "Append-only, hash-chained audit log file, empty keeps the audit trail in memory")
+	duration(&cfg.AuditVerifyInterval, "audit-verify-interval", "How often the audit log file is read back to verify its hash chain")
+	fs.StringVar(&cfg.APIKeysFile, "api-keys-file", cfg.APIKeysFile, "Append-only JSON lines file of the API keys, holding their hashes only, empty keeps keys in memory")
+	fs.IntVar(&cfg.Login.MaxFailures, "login-max-failures", cfg.Login.MaxFailures, "Failed logins per username or peer IP allowed inside the window before a lockout")
+	duration(&cfg.Login.Window, "login-window", "Sliding window failed logins are counted in")
+	duration(&cfg.Login.BaseLockout, "login-base-lockout", "Duration of the first lockout, doubled on each following lockout")
+	duration(&cfg.Login.MaxLockout, "login-max-lockout", "Upper bound of a lockout duration")
+	fs.StringVar(&cfg.FeaturesFile, "features-file", cfg.FeaturesFile, "RouteGuide features as a JSON array of E7 features or a GeoJSON FeatureCollection, rewritten when features change")
+	fs.StringVar(&cfg.FeaturesSeedFile, "features-seed-file", cfg.FeaturesSeedFile, "Features copied to the features file when it does not exist yet, read only")
+	duration(&cfg.FeaturesReloadInterval, "features-reload-interval", "How often the features file is checked for external changes, 0 disables hot reload")
+	fs.StringVar(&cfg.RoutesFile, "routes-file", cfg.RoutesFile, "Append-only JSON lines file of recorded routes, empty keeps routes in memory")
+	fs.IntVar(&cfg.RouteMaxPoints, "route-max-points", cfg.RouteMaxPoints, "Points a route recorded by RecordRoute may have")
+	fs.IntVar(&cfg.Chat.Buffer, "chat-buffer", cfg.Chat.Buffer, "RouteChat notes buffered per subscriber before the backpressure policy applies")
+	fs.StringVar(&cfg.Chat.Backpressure, "chat-backpressure", cfg.Chat.Backpressure, "What a full RouteChat subscriber buffer does with a new note: drop-oldest, drop-newest or disconnect")
+	fs.IntVar(&cfg.Chat.History, "chat-history", cfg.Chat.History, "RouteChat notes kept per location for new subscribers")
+	fs.IntVar(&cfg.Chat.MaxLocations, "chat-max-locations", cfg.Chat.MaxLocations, "RouteChat locations with a history, the least recently noted are forgotten first")
+	fs.StringVar(&cfg.Chat.HistoryFile, "chat-history-file", cfg.Chat.HistoryFile, "JSON lines file persisting the RouteChat history, empty keeps it in memory")
+	duration(&cfg.Health.Interval, "health-check-interval", "How often the stores backing each service are checked for the grpc.health.v1 service")
+	duration(&cfg.Health.Timeout, "health-check-timeout", "Time a store health check may take before it fails")
+	duration(&cfg.Health.ShutdownDrainDelay, "shutdown-drain-delay", "Time between reporting NOT_SERVING and stopping the server, for load balancers to notice")
+	fs.StringVar(&cfg.Password.Hash, "password-hash", cfg.Password.Hash, "Algorithm hashing new passwords, argon2id or bcrypt; older hashes are upgraded on login")
+	fs.IntVar(&cfg.Password.BcryptCost, "bcrypt-cost", cfg.Password.BcryptCost, "Cost of bcrypt password hashes")
+	fs.UintVar(&cfg.Password.Argon2Time, "argon2-time", cfg.Password.Argon2Time, "Passes of argon2id password hashes")
+	fs.UintVar(&cfg.Password.Argon2Memory, "argon2-memory", cfg.Password.Argon2Memory, "Memory in KiB of argon2id password hashes")
+	fs.UintVar(&cfg.Password.Argon2Threads, "argon2-threads", cfg.Password.Argon2Threads, "Threads of argon2id password hashes")
+	fs.IntVar(&cfg.Password.MinLength, "password-min-length", cfg.Password.MinLength, "Minimum length of registered passwords")
+	fs.IntVar(&cfg.Password.MinClasses, "password-min-classes", cfg.Password.MinClasses, "Character classes (lowercase, uppercase, digits, symbols) registered passwords must mix")
+}
+
+// stringList is the flag.Value of a comma-separated list.
+type stringList struct {
+	values *[]string
+}
+
+func (list stringList) String() string {
+	if list.values == nil {
+		return ""
+	}
+	return strings.Join(*list.values, ",")
+}
+
+func (list stringList) Set(value string) error {
+	*list.values = strings.Split(value, ",")
+	return nil
+}
+
+// EnvName returns the environment variable overriding the flag name.
+func EnvName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// Load parses the command line args of cmd/server into a validated configuration, and reports whether
+// -print-config asks to print it instead of serving. The file given by -config, or by SERVER_CONFIG,
+// is read first, then lookupEnv and the flags override its values.
+func Load(name string, args []string, lookupEnv func(string) (string, bool)) (*Config, bool, error) {
+	// parse once to find the file and the flags set, the values are applied after the file is read
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := fs.String("config", "", "YAML configuration file, settings are overridden by "+EnvPrefix+"* environment variables and flags")
+	printConfig := fs.Bool("print-config", false, "Print the effective configuration as YAML and exit")
+	scratch := Default()
+	bindFlags(fs, &scratch)
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, false, err
+	}
+	if fs.NArg() > 0 {
+		return nil, false, fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+
+	filename := *configFile
+	if value, ok := lookupEnv(EnvName("config")); ok && filename == "" {
+		filename = value
+	}
+
+	cfg := Default()
+	if filename != "" {
+		err = cfg.readFile(filename)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	overrides := flag.NewFlagSet(name, flag.ContinueOnError)
+	overrides.SetOutput(io.Discard)
+	bindFlags(overrides, &cfg)
+	var errs []error
+	overrides.VisitAll(func(f *flag.Flag) {
+		if value, ok := lookupEnv(EnvName(f.Name)); ok {
+			err := overrides.Set(f.Name, value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s: %w", EnvName(f.Name), err))
+			}
+		}
+	})
+	fs.Visit(func(f *flag.Flag) {
+		if overrides.Lookup(f.Name) != nil {
+			// the value was parsed once already, so it is valid
+			overrides.Set(f.Name, f.Value.String())
+		}
+	})
+	if len(errs) > 0 {
+		return nil, false, errors.Join(errs...)
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &cfg, *printConfig, nil
+}
+
+// readFile decodes the YAML file over the current values, rejecting unknown keys.
+func (cfg *Config) readFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("cannot parse config %s: %w", filename, err)
+	}
+	return nil
+}
+
+// Validate checks every setting and returns all the problems found.
+func (cfg *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	positive := func(name string, d Duration) {
+		check(d > 0, "%s: must be positive, got %s", name, time.Duration(d))
+	}
+
+	check(cfg.Port > 0 && cfg.Port <= 65535, "port: must be between 1 and 65535, got %d", cfg.Port)
+	check(cfg.PrometheusAddr != "", "prometheus_addr: must be set")
+	names := make(map[string]bool)
+	needTLS := false
+	for i, listener := range cfg.Listeners {
+		check(listener.Name != "" && !names[listener.Name], "listeners[%d]: name must be set and unique, got %q", i, listener.Name)
+		names[listener.Name] = true
+		check(listener.Network == "tcp" || listener.Network == "unix", "listeners[%d]: network must be tcp or unix, got %q", i, listener.Network)
+		check(listener.Address != "", "listeners[%d]: address must be set", i)
+		check(!listener.TLS || listener.Network == "tcp", "listeners[%d]: tls is only supported on tcp listeners", i)
+		check(listener.Protocol == "" || listener.Protocol == "grpc" || listener.Protocol == "http", "listeners[%d]: protocol must be grpc or http, got %q", i, listener.Protocol)
+	}
+	check(cfg.GatewayAddr == "" || len(cfg.Listeners) == 0, "gateway_addr: add a listener with protocol http instead when listeners are set")
+	for i, origin := range cfg.CORS.AllowedOrigins {
+		check(origin == "*" || strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://"), "cors.allowed_origins[%d]: must be * or an http(s) origin, got %q", i, origin)
+	}
+	check(!cfg.CORS.AllowCredentials || !slices.Contains(cfg.CORS.AllowedOrigins, "*"), "cors.allow_credentials: cannot be set for the * origin")
+	check(cfg.CORS.MaxAge >= 0, "cors.max_age: must not be negative")
+	for i, limit := range cfg.RateLimits {
+		_, err := path.Match(limit.Method, "")
+		check(strings.HasPrefix(limit.Method, "/") && err == nil, "rate_limits[%d]: method must be a full method name or pattern, got %q", i, limit.Method)
+		check(limit.Per == "principal" || limit.Per == "peer", "rate_limits[%d]: per must be principal or peer, got %q", i, limit.Per)
+		check(limit.Rate >= 0 && limit.MaxConcurrent >= 0, "rate_limits[%d]: rate and max_concurrent must not be negative", i)
+		check(limit.Rate > 0 || limit.MaxConcurrent > 0, "rate_limits[%d]: set rate or max_concurrent", i)
+		check(limit.Rate == 0 || limit.Burst >= 1, "rate_limits[%d]: burst must be at least 1 with a rate", i)
+	}
+	if shedding := cfg.LoadShedding; shedding.Enabled {
+		check(shedding.MinLimit >= 1 && shedding.MinLimit <= shedding.InitialLimit && shedding.InitialLimit <= shedding.MaxLimit,
+			"load_shedding: limits must satisfy 1 <= min_limit <= initial_limit <= max_limit, got %d, %d and %d", shedding.MinLimit, shedding.InitialLimit, shedding.MaxLimit)
+		check(shedding.Tolerance >= 1, "load_shedding.tolerance: must be at least 1, got %g", shedding.Tolerance)
+		check(shedding.Smoothing > 0 && shedding.Smoothing <= 1, "load_shedding.smoothing: must be over 0 and at most 1, got %g", shedding.Smoothing)
+		for role, priority := range shedding.RolePriorities {
+			check(priority == "low" || priority == "normal" || priority == "high" || priority == "critical",
+				"load_shedding.role_priorities.%s: must be low, normal, high or critical, got %q", role, priority)
+		}
+	}
+	for _, listener := range cfg.EffectiveListeners() {
+		needTLS = needTLS || listener.TLS
+	}
+	if needTLS {
+		for name, filename := range map[string]string{"cert_file": cfg.TLS.CertFile, "key_file": cfg.TLS.KeyFile, "client_ca_file": cfg.TLS.ClientCAFile} {
+			_, err := os.Stat(filename)
+			check(err == nil, "tls.%s: %v", name, err)
+		}
+		positive("tls.reload_interval", cfg.TLS.ReloadInterval)
+		check(cfg.TLS.ExpiryWarning >= 0, "tls.expiry_warning: must not be negative")
+	}
+
+	positive("keepalive.min_time", cfg.Keepalive.MinTime)
+	positive("keepalive.max_connection_idle", cfg.Keepalive.MaxConnectionIdle)
+	positive("keepalive.max_connection_age", cfg.Keepalive.MaxConnectionAge)
+	check(cfg.Keepalive.MaxConnectionAgeGrace >= 0, "keepalive.max_connection_age_grace: must not be negative")
+	positive("keepalive.time", cfg.Keepalive.Time)
+	positive("keepalive.timeout", cfg.Keepalive.Timeout)
+
+	positive("token.duration", cfg.Token.Duration)
+	check(cfg.Token.ClockSkew >= 0, "token.clock_skew: must not be negative")
+	check(cfg.PolicyFile != "", "policy_file: must be set")
+	positive("policy_reload_interval", cfg.PolicyReloadInterval)
+	positive("audit_verify_interval", cfg.AuditVerifyInterval)
+	for role, permissions := range cfg.Roles {
+		check(role != "", "roles: role names must not be empty")
+		check(len(permissions) > 0, "roles.%s: must grant at least one permission", role)
+	}
+
+	check(cfg.Login.MaxFailures > 0, "login.max_failures: must be positive, got %d", cfg.Login.MaxFailures)
+	positive("login.window", cfg.Login.Window)
+	positive("login.base_lockout", cfg.Login.BaseLockout)
+	check(cfg.Login.MaxLockout >= cfg.Login.BaseLockout, "login.max_lockout: must not be shorter than login.base_lockout")
+
+	check(cfg.Password.Hash == "argon2id" || cfg.Password.Hash == "bcrypt", "password.hash: must be argon2id or bcrypt, got %q", cfg.Password.Hash)
+	check(cfg.Password.MinLength > 0, "password.min_length: must be positive, got %d", cfg.Password.MinLength)
+	check(cfg.Password.MinClasses >= 0 && cfg.Password.MinClasses <= 4, "password.min_classes: must be between 0 and 4, got %d", cfg.Password.MinClasses)
+	usernames := make(map[string]bool)
+	for i, account := range cfg.SeedAccounts {
+		check(account.Username != "" && account.Password != "" && account.Role != "", "seed_accounts[%d]: username, password and role are required", i)
+		check(!usernames[account.Username], "seed_accounts[%d]: duplicate username %q", i, account.Username)
+		usernames[account.Username] = true
+		if cfg.Roles != nil {
+			_, ok := cfg.Roles[account.Role]
+			check(ok, "seed_accounts[%d]: role %q is not in roles", i, account.Role)
+		}
+	}
+
+	check(cfg.FeaturesReloadInterval >= 0, "features_reload_interval: must not be negative")
+	check(cfg.RouteMaxPoints > 0, "route_max_points: must be positive, got %d", cfg.RouteMaxPoints)
+	check(cfg.Chat.Buffer > 0, "chat.buffer: must be positive, got %d", cfg.Chat.Buffer)
+	check(cfg.Chat.History > 0, "chat.history: must be positive, got %d", cfg.Chat.History)
+	check(cfg.Chat.MaxLocations > 0, "chat.max_locations: must be positive, got %d", cfg.Chat.MaxLocations)
+	check(cfg.Chat.Backpressure == "drop-oldest" || cfg.Chat.Backpressure == "drop-newest" || cfg.Chat.Backpressure == "disconnect",
+		"chat.backpressure: must be drop-oldest, drop-newest or disconnect, got %q", cfg.Chat.Backpressure)
+	positive("health.interval", cfg.Health.Interval)
+	positive("health.timeout", cfg.Health.Timeout)
+	check(cfg.Health.ShutdownDrainDelay >= 0, "health.shutdown_drain_delay: must not be negative")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Redacted returns a copy of cfg safe to print, without the seed account passwords.
+func (cfg *Config) Redacted() *Config {
+	redacted := *cfg
+	redacted.SeedAccounts = make([]SeedAccount, len(cfg.SeedAccounts))
+	for i, account := range cfg.SeedAccounts {
+		account.Password = "REDACTED"
+		redacted.SeedAccounts[i] = account
+	}
+	return &redacted
+}
+
+// Print writes the configuration as YAML, the seed account passwords redacted.
+func (cfg *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	err := encoder.Encode(cfg.Redacted())
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,270 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-http-server/grpc/config"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	filename := filepath.Join(t.TempDir(), "server.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+	return filename
+}
+
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	filename := writeConfig(t, `
+port: 9090
+prometheus_addr: ":9000"
+keepalive:
+  max_connection_age: 2m
+token:
+  issuer: file-issuer
+roles:
+  admin: [route.admin]
+seed_accounts:
+  - {username: ops, password: "Ops#Password1", role: admin, tenant: acme}
+chat:
+  backpressure: disconnect
+`)
+
+	testCases := []struct {
+		name   string
+		args   []string
+		env    map[string]string
+		verify func(t *testing.T, cfg *config.Config)
+	}{
+		{
+			name: "defaults",
+			verify: func(t *testing.T, cfg *config.Config) {
+				require.Equal(t, config.Default(), *cfg)
+			},
+		},
+		{
+			name: "file",
+			args: []string{"-config", filename},
+			verify: func(t *testing.T, cfg *config.Config) {
+				require.Equal(t, 9090, cfg.Port)
+				require.Equal(t, ":9000", cfg.PrometheusAddr)
+				require.Equal(t, config.Duration(2*time.Minute), cfg.Keepalive.MaxConnectionAge)
+				require.Equal(t, config.Duration(15*time.Second), cfg.Keepalive.MaxConnectionIdle)
+				require.Equal(t, "file-issuer", cfg.Token.Issuer)
+				require.Equal(t, map[string][]string{"admin": {"route.admin"}}, cfg.Roles)
+				require.Equal(t, []config.SeedAccount{{Username: "ops", Password: "Ops#Password1", Role: "admin", Tenant: "acme"}}, cfg.SeedAccounts)
+				require.Equal(t, "disconnect", cfg.Chat.Backpressure)
+			},
+		},
+		{
+			name: "env overrides file",
+			env: map[string]string{
+				"SERVER_CONFIG":              filename,
+				"SERVER_PORT":                "7070",
+				"SERVER_KEEPALIVE_MAX_AGE":   "1m",
+				"SERVER_PROMETHEUS_ENDPOINT": ":9100",
+				"SERVER_LOAD_SHEDDING":       "false",
+			},
+			verify: func(t *testing.T, cfg *config.Config) {
+				require.Equal(t, 7070, cfg.Port)
+				require.Equal(t, ":9100", cfg.PrometheusAddr)
+				require.Equal(t, config.Duration(time.Minute), cfg.Keepalive.MaxConnectionAge)
+				require.Equal(t, "file-issuer", cfg.Token.Issuer)
+				require.False(t, cfg.LoadShedding.Enabled)
+			},
+		},
+		{
+			name: "flags override env",
+			args: []string{"-config", filename, "-port", "6060", "-token-issuer", "flag-issuer", "-cors-allowed-origins", "https://a.example.com,https://b.example.com"},
+			env:  map[string]string{"SERVER_PORT": "7070", "SERVER_TOKEN_ISSUER": "env-issuer", "SERVER_CORS_ALLOWED_ORIGINS": "*"},
+			verify: func(t *testing.T, cfg *config.Config) {
+				require.Equal(t, 6060, cfg.Port)
+				require.Equal(t, "flag-issuer", cfg.Token.Issuer)
+				require.Equal(t, ":9000", cfg.PrometheusAddr)
+				require.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.CORS.AllowedOrigins)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, printConfig, err := config.Load("server", tc.args, lookupEnv(tc.env))
+			require.NoError(t, err)
+			require.False(t, printConfig)
+			tc.verify(t, cfg)
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		content string
+		args    []string
+		env     map[string]string
+		errs    []string
+	}{
+		{
+			name:    "unknown key",
+			content: "prot: 8080\n",
+			errs:    []string{"field prot not found"},
+		},
+		{
+			name:    "bad duration",
+			content: "keepalive:\n  time: soon\n",
+			errs:    []string{"line 2", "soon"},
+		},
+		{
+			name:    "every problem reported",
+			content: "port: 70000\nroute_max_points: 0\nchat:\n  backpressure: block\nseed_accounts:\n  - {username: a, password: b, role: user}\n  - {username: a, password: b, role: user}\n",
+			errs:    []string{"port: must be between 1 and 65535", "route_max_points: must be positive", "chat.backpressure", "seed_accounts[1]: duplicate username \"a\""},
+		},
+		{
+			name:    "seed role missing from roles",
+			content: "roles:\n  admin: [route.admin]\n",
+			errs:    []string{"seed_accounts[1]: role \"user\" is not in roles"},
+		},
+		{
+			name:    "invalid listeners",
+			content: "listeners:\n  - {name: a, network: udp, address: x}\n  - {name: a, network: unix, address: s.sock, tls: true}\n  - {name: b, network: tcp, protocol: rest}\ngateway_addr: \":8081\"\n",
+			errs: []string{
+				"listeners[0]: network must be tcp or unix",
+				"listeners[1]: name must be set and unique",
+				"listeners[1]: tls is only supported on tcp listeners",
+				"listeners[2]: address must be set",
+				"listeners[2]: protocol must be grpc or http",
+				"gateway_addr: add a listener with protocol http instead",
+			},
+		},
+		{
+			name:    "invalid rate limits",
+			content: "rate_limits:\n  - {method: SearchLaptop, per: tenant, rate: 1}\n  - {method: /LaptopService/*, per: peer}\n",
+			errs: []string{
+				"rate_limits[0]: method must be a full method name or pattern",
+				"rate_limits[0]: per must be principal or peer",
+				"rate_limits[0]: burst must be at least 1 with a rate",
+				"rate_limits[1]: set rate or max_concurrent",
+			},
+		},
+		{
+			name:    "invalid load shedding",
+			content: "load_shedding:\n  min_limit: 200\n  tolerance: 0.5\n  smoothing: 0\n  role_priorities: {batch: lowest}\n",
+			errs: []string{
+				"load_shedding: limits must satisfy 1 <= min_limit <= initial_limit <= max_limit",
+				"load_shedding.tolerance: must be at least 1",
+				"load_shedding.smoothing: must be over 0 and at most 1",
+				"load_shedding.role_priorities.batch: must be low, normal, high or critical",
+			},
+		},
+		{
+			name:    "invalid cors",
+			content: "cors:\n  allowed_origins: [\"*\", app.example.com]\n  allow_credentials: true\n  max_age: -1s\n",
+			errs: []string{
+				"cors.allowed_origins[1]: must be * or an http(s) origin",
+				"cors.allow_credentials: cannot be set for the * origin",
+				"cors.max_age: must not be negative",
+			},
+		},
+		{
+			name: "missing TLS files",
+			args: []string{"-tls", "-tls-cert", "missing.crt"},
+			errs: []string{"tls.cert_file", "missing.crt"},
+		},
+		{
+			name: "bad env value",
+			env:  map[string]string{"SERVER_LOGIN_WINDOW": "forever"},
+			errs: []string{"SERVER_LOGIN_WINDOW"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			args := tc.args
+			if tc.content != "" {
+				args = append([]string{"-config", writeConfig(t, tc.content)}, args...)
+			}
+
+			_, _, err := config.Load("server", args, lookupEnv(tc.env))
+			require.Error(t, err)
+			for _, msg := range tc.errs {
+				require.ErrorContains(t, err, msg)
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	t.Parallel()
+
+	cfg, printConfig, err := config.Load("server", []string{"-print-config", "-keepalive-time", "90s"}, lookupEnv(nil))
+	require.NoError(t, err)
+	require.True(t, printConfig)
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Print(&out))
+	require.Contains(t, out.String(), "time: 1m30s")
+	require.Contains(t, out.String(), "password: REDACTED")
+	require.NotContains(t, out.String(), "Laptop#Store1")
+	require.Equal(t, "Laptop#Store1", cfg.SeedAccounts[0].Password)
+
+	// the printed configuration loads back to the same values
+	printed, _, err := config.Load("server", []string{"-config", writeConfig(t, out.String())}, lookupEnv(nil))
+	require.NoError(t, err)
+	require.Equal(t, cfg.Redacted(), printed)
+}
+
+func TestEffectiveListeners(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, err := config.Load("server", []string{"-port", "9090", "-cert-identities", "identities.yaml"}, lookupEnv(nil))
+	require.NoError(t, err)
+	require.Equal(t, []config.Listener{{
+		Name:               "default",
+		Network:            "tcp",
+		Address:            "0.0.0.0:9090",
+		PolicyFile:         "policy.yaml",
+		CertIdentitiesFile: "identities.yaml",
+	}}, cfg.EffectiveListeners())
+
+	cfg, _, err = config.Load("server", []string{"-tls", "-tls-cert", "config_test.go", "-tls-key", "config_test.go", "-tls-client-ca", "config_test.go", "-gateway-addr", ":8081"}, lookupEnv(nil))
+	require.NoError(t, err)
+	require.Equal(t, config.Listener{
+		Name:       "gateway",
+		Network:    "tcp",
+		Address:    ":8081",
+		TLS:        true,
+		Protocol:   "http",
+		PolicyFile: "policy.yaml",
+	}, cfg.EffectiveListeners()[1], "the gateway serves the services of the default listener")
+
+	filename := writeConfig(t, `
+listeners:
+  - {name: sidecar, network: unix, address: /run/server.sock, cert_identities_file: uids.yaml}
+  - {name: admin, network: tcp, address: "127.0.0.1:9443", policy_file: admin.yaml, services: [AuthService]}
+`)
+	cfg, _, err = config.Load("server", []string{"-config", filename}, lookupEnv(nil))
+	require.NoError(t, err)
+	require.Equal(t, []config.Listener{
+		{Name: "sidecar", Network: "unix", Address: "/run/server.sock", PolicyFile: "policy.yaml", CertIdentitiesFile: "uids.yaml"},
+		{Name: "admin", Network: "tcp", Address: "127.0.0.1:9443", PolicyFile: "admin.yaml", Services: []string{"AuthService"}},
+	}, cfg.EffectiveListeners())
+	require.Empty(t, cfg.Listeners[0].PolicyFile, "the defaults are not written back")
+}
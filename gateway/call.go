@@ -0,0 +1,286 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// prefixSize is the size of the header of a gRPC message: a compressed flag and a big endian length.
+const prefixSize = 5
+
+// callResult is the outcome of a gRPC call.
+type callResult struct {
+	status *status.Status
+	header http.Header // response headers and trailers of the gRPC server
+}
+
+// call sends requests to the gRPC method as if they came from r and hands each response to received
+// as it is decoded. headerSent, if set, is called once the gRPC server sent its response headers,
+// before the first response.
+func (gateway *Gateway) call(r *http.Request, method string, requests []proto.Message, newResponse func() proto.Message, received func(proto.Message) error, headerSent func(http.Header)) callResult {
+	var body bytes.Buffer
+	for _, request := range requests {
+		data, err := proto.Marshal(request)
+		if err != nil {
+			return callResult{status: status.Newf(codes.Internal, "cannot encode request: %v", err)}
+		}
+		writeFrame(&body, 0, data)
+	}
+
+	return gateway.forward(r, method, &body, func(data []byte) error {
+		response := newResponse()
+		err := proto.Unmarshal(data, response)
+		if err != nil {
+			return err
+		}
+		return received(response)
+	}, headerSent)
+}
+
+// forward sends body, length prefixed gRPC messages, to the gRPC method as if it came from r, with
+// the same peer address, TLS state and headers as metadata. Each response message is handed to
+// received as it completes; headerSent, if set, is called once before with the response headers.
+func (gateway *Gateway) forward(r *http.Request, method string, body io.Reader, received func([]byte) error, headerSent func(http.Header)) callResult {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	grpcRequest := r.Clone(ctx)
+	grpcRequest.Method = http.MethodPost
+	grpcRequest.URL = &url.URL{Path: method}
+	grpcRequest.RequestURI = method
+	grpcRequest.Proto, grpcRequest.ProtoMajor, grpcRequest.ProtoMinor = "HTTP/2.0", 2, 0
+	grpcRequest.Header = requestMetadata(r.Header)
+	grpcRequest.Body = io.NopCloser(body)
+	grpcRequest.ContentLength = -1
+
+	recorder := &responseRecorder{
+		header:     make(http.Header),
+		received:   received,
+		headerSent: headerSent,
+		cancel:     cancel,
+	}
+	gateway.grpcServer.ServeHTTP(recorder, grpcRequest)
+
+	result := callResult{status: recorder.status(), header: recorder.header}
+	if recorder.err != nil && result.status.Code() != codes.OK && r.Context().Err() == nil {
+		// the call was canceled because a response could not be handled
+		result.status = status.New(codes.Internal, recorder.err.Error())
+	}
+	return result
+}
+
+// writeFrame writes a length prefixed message with flags, as gRPC, gRPC-Web and Connect streams do.
+func writeFrame(w io.Writer, flags byte, data []byte) error {
+	var prefix [prefixSize]byte
+	prefix[0] = flags
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+	_, err := w.Write(append(prefix[:], data...))
+	return err
+}
+
+// readFrame reads a length prefixed message, io.EOF at the end of r.
+func readFrame(r io.Reader) (flags byte, data []byte, err error) {
+	var prefix [prefixSize]byte
+	_, err = io.ReadFull(r, prefix[:])
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, errors.New("truncated message prefix")
+		}
+		return 0, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxBodySize {
+		return 0, nil, fmt.Errorf("message of %d bytes exceeds the limit of %d bytes", size, maxBodySize)
+	}
+	data = make([]byte, size)
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return 0, nil, fmt.Errorf("truncated message: %w", err)
+	}
+	return prefix[0], data, nil
+}
+
+// requestMetadata returns the headers of a REST request as the headers of a gRPC request.
+// A bearer access token is sent as the raw token the gRPC clients send.
+func requestMetadata(header http.Header) http.Header {
+	metadata := make(http.Header, len(header)+2)
+	for key, values := range header {
+		switch http.CanonicalHeaderKey(key) {
+		case "Content-Type", "Content-Length", "Accept", "Accept-Encoding", "Connection", "Te":
+			continue
+		}
+		metadata[key] = values
+	}
+
+	if token, ok := strings.CutPrefix(header.Get("Authorization"), "Bearer "); ok {
+		metadata.Set("Authorization", token)
+	}
+	metadata.Set("Content-Type", "application/grpc")
+	metadata.Set("Te", "trailers")
+	return metadata
+}
+
+// responseMetadata returns the custom headers and trailers a gRPC server answered with, without
+// the headers of the protocol.
+func responseMetadata(header http.Header) (headers, trailers http.Header) {
+	headers, trailers = make(http.Header), make(http.Header)
+	for key, values := range header {
+		metadata := headers
+		if name, ok := strings.CutPrefix(key, http.TrailerPrefix); ok {
+			key, metadata = http.CanonicalHeaderKey(name), trailers
+		}
+		switch {
+		case key == "Content-Type", key == "Trailer", key == "Date", strings.HasPrefix(key, "Grpc-"):
+			continue
+		}
+		metadata[key] = append(metadata[key], values...)
+	}
+	return headers, trailers
+}
+
+// writeMetadata copies the custom headers and trailers of a gRPC response to the REST response,
+// prefixed with Grpc-Metadata-.
+func writeMetadata(dst, src http.Header) {
+	headers, trailers := responseMetadata(src)
+	for _, metadata := range []http.Header{headers, trailers} {
+		for key, values := range metadata {
+			for _, value := range values {
+				dst.Add("Grpc-Metadata-"+key, value)
+			}
+		}
+	}
+}
+
+// responseRecorder is the http.ResponseWriter the gRPC server answers a transcoded call with.
+// It splits the length prefixed messages written and hands them on as they complete.
+type responseRecorder struct {
+	header     http.Header
+	code       int
+	pending    []byte
+	headerSent func(http.Header)
+	sent       bool
+	received   func([]byte) error
+	cancel     context.CancelFunc
+	err        error // first error handling a response, the call is then canceled
+}
+
+func (recorder *responseRecorder) Header() http.Header {
+	return recorder.header
+}
+
+func (recorder *responseRecorder) WriteHeader(code int) {
+	if recorder.code != 0 {
+		return
+	}
+	recorder.code = code
+	if code == http.StatusOK {
+		recorder.sendHeader()
+	}
+}
+
+func (recorder *responseRecorder) Flush() {}
+
+func (recorder *responseRecorder) Write(p []byte) (int, error) {
+	if recorder.code == 0 {
+		recorder.WriteHeader(http.StatusOK)
+	}
+	if recorder.err != nil {
+		return 0, recorder.err
+	}
+	if recorder.code != http.StatusOK {
+		// an error the gRPC server could not send as a status, keep it for status
+		recorder.pending = append(recorder.pending, p...)
+		return len(p), nil
+	}
+
+	recorder.pending = append(recorder.pending, p...)
+	for len(recorder.pending) >= prefixSize {
+		size := int(binary.BigEndian.Uint32(recorder.pending[1:prefixSize]))
+		if len(recorder.pending) < prefixSize+size {
+			break
+		}
+		if recorder.pending[0] != 0 {
+			// compression is never accepted by the gateway requests
+			return 0, recorder.fail(errors.New("gRPC server sent a compressed response"))
+		}
+
+		data := recorder.pending[prefixSize : prefixSize+size]
+		recorder.pending = recorder.pending[prefixSize+size:]
+		err := recorder.received(data)
+		if err != nil {
+			return 0, recorder.fail(err)
+		}
+	}
+	return len(p), nil
+}
+
+func (recorder *responseRecorder) sendHeader() {
+	if !recorder.sent && recorder.headerSent != nil {
+		recorder.sent = true
+		recorder.headerSent(recorder.header)
+	}
+}
+
+func (recorder *responseRecorder) fail(err error) error {
+	recorder.err = err
+	recorder.cancel()
+	return err
+}
+
+// status returns the status of the call the gRPC server answered.
+func (recorder *responseRecorder) status() *status.Status {
+	if recorder.code != 0 && recorder.code != http.StatusOK {
+		return status.Newf(codes.Internal, "gRPC server answered %d %s", recorder.code, bytes.TrimSpace(recorder.pending))
+	}
+
+	value := recorder.header.Get("Grpc-Status")
+	if value == "" {
+		// the transport was closed, as a gRPC client would see the server stopping
+		return status.New(codes.Unavailable, "gRPC server closed the call without a status")
+	}
+	code, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return status.Newf(codes.Internal, "invalid gRPC status %q", value)
+	}
+	if details := recorder.header.Get("Grpc-Status-Details-Bin"); details != "" {
+		// the status with its details, as a gRPC client decodes it
+		if st, err := decodeStatusDetails(details); err == nil && st.GetCode() == int32(code) {
+			return status.FromProto(st)
+		}
+	}
+	message := recorder.header.Get("Grpc-Message")
+	if decoded, err := url.PathUnescape(message); err == nil {
+		message = decoded
+	}
+	return status.New(codes.Code(code), message)
+}
+
+// decodeStatusDetails decodes the base64 google.rpc.Status of a grpc-status-details-bin header,
+// padded or not.
+func decodeStatusDetails(value string) (*spb.Status, error) {
+	encoding := base64.RawStdEncoding
+	if len(value)%4 == 0 {
+		encoding = base64.StdEncoding
+	}
+	data, err := encoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	st := &spb.Status{}
+	return st, proto.Unmarshal(data, st)
+}
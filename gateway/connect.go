@@ -0,0 +1,336 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	connectCompressedFlag = 0x01 // a message of a Connect stream is compressed
+	connectEndStreamFlag  = 0x02 // the last message of a Connect stream, holding its status and trailers
+)
+
+// connectMarshaler encodes the JSON messages of Connect calls, with the canonical JSON field names
+// the Connect clients expect.
+var connectMarshaler = protojson.MarshalOptions{}
+
+// connectCodec encodes the messages of a Connect call: proto or json.
+type connectCodec string
+
+func (codec connectCodec) marshal(message proto.Message) ([]byte, error) {
+	if codec == "json" {
+		return connectMarshaler.Marshal(message)
+	}
+	return proto.Marshal(message)
+}
+
+func (codec connectCodec) unmarshal(data []byte, message proto.Message) error {
+	if codec == "json" {
+		return unmarshaler.Unmarshal(data, message)
+	}
+	return proto.Unmarshal(data, message)
+}
+
+// connectError is the JSON error of a Connect call.
+type connectError struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message,omitempty"`
+	Details []connectDetail `json:"details,omitempty"`
+}
+
+// connectDetail is an error detail of a Connect call, a google.protobuf.Any.
+type connectDetail struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// connectEndStream is the last message of a Connect stream.
+type connectEndStream struct {
+	Error    *connectError `json:"error,omitempty"`
+	Metadata http.Header   `json:"metadata,omitempty"`
+}
+
+func newConnectError(st *status.Status) *connectError {
+	connectErr := &connectError{Code: connectCode(st.Code()), Message: st.Message()}
+	for _, detail := range st.Proto().GetDetails() {
+		connectErr.Details = append(connectErr.Details, connectDetail{
+			Type:  strings.TrimPrefix(detail.GetTypeUrl(), "type.googleapis.com/"),
+			Value: base64.RawStdEncoding.EncodeToString(detail.GetValue()),
+		})
+	}
+	return connectErr
+}
+
+// connectCode returns the Connect name of a gRPC status code, invalid_argument for InvalidArgument.
+func connectCode(code codes.Code) string {
+	var name strings.Builder
+	for i, r := range code.String() {
+		if unicode.IsUpper(r) && i > 0 {
+			name.WriteByte('_')
+		}
+		name.WriteRune(unicode.ToLower(r))
+	}
+	return name.String()
+}
+
+// serveConnect serves a call of the Connect protocol: a unary call whose body is the request message,
+// or a stream of length prefixed messages ended by a message holding the status and trailers.
+func (gateway *Gateway) serveConnect(w http.ResponseWriter, r *http.Request, contentType string) {
+	codec, streaming := strings.CutPrefix(contentType, "application/connect+")
+	if !streaming {
+		codec = strings.TrimPrefix(contentType, "application/")
+	}
+	if codec != "proto" && codec != "json" {
+		http.Error(w, "unsupported Connect codec "+codec, http.StatusUnsupportedMediaType)
+		return
+	}
+	connect := &connectCall{gateway: gateway, codec: connectCodec(codec)}
+
+	r = r.Clone(r.Context())
+	err := connect.decodeHeaders(r, streaming)
+	if err == nil {
+		var ok bool
+		connect.desc, ok = lookupMethod(r.URL.Path)
+		if !ok {
+			err = status.Errorf(codes.Unimplemented, "unknown method %s", r.URL.Path)
+		}
+	}
+	if err == nil && !streaming && (connect.desc.IsStreamingClient() || connect.desc.IsStreamingServer()) {
+		err = status.Errorf(codes.Unimplemented, "streaming method %s must be called with content type application/connect+%s", r.URL.Path, codec)
+	}
+	if err != nil {
+		if streaming {
+			w.Header().Set("Content-Type", contentType)
+			connect.writeEndStream(w, status.Convert(err), nil)
+		} else {
+			writeConnectError(w, status.Convert(err))
+		}
+		return
+	}
+
+	if streaming {
+		connect.serveStream(w, r)
+	} else {
+		connect.serveUnary(w, r)
+	}
+}
+
+// connectCall is a call of the Connect protocol.
+type connectCall struct {
+	gateway *Gateway
+	codec   connectCodec
+	desc    protoreflect.MethodDescriptor
+}
+
+// decodeHeaders checks the Connect headers of r and replaces them with their gRPC ones.
+func (connect *connectCall) decodeHeaders(r *http.Request, streaming bool) error {
+	if version := r.Header.Get("Connect-Protocol-Version"); version != "" && version != "1" {
+		return status.Errorf(codes.InvalidArgument, "unsupported Connect protocol version %s", version)
+	}
+
+	encoding := r.Header.Get("Content-Encoding")
+	if streaming {
+		encoding = r.Header.Get("Connect-Content-Encoding")
+	}
+	if encoding != "" && encoding != "identity" {
+		return status.Errorf(codes.Unimplemented, "unsupported compression %s", encoding)
+	}
+
+	if timeout := r.Header.Get("Connect-Timeout-Ms"); timeout != "" {
+		ms, err := strconv.ParseUint(timeout, 10, 64)
+		if err != nil || len(timeout) > 10 {
+			return status.Errorf(codes.InvalidArgument, "invalid Connect-Timeout-Ms %q", timeout)
+		}
+		r.Header.Set("Grpc-Timeout", strconv.FormatUint(ms, 10)+"m")
+	}
+
+	for key := range r.Header {
+		if strings.HasPrefix(key, "Connect-") || key == "Content-Encoding" {
+			r.Header.Del(key)
+		}
+	}
+	return nil
+}
+
+// serveUnary serves a unary call: the request and response are the bodies, the response headers the
+// metadata and trailers are sent as headers prefixed with Trailer-.
+func (connect *connectCall) serveUnary(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeConnectError(w, status.Newf(codes.InvalidArgument, "cannot read request: %v", err))
+		return
+	}
+	request := newMessage(connect.desc.Input())
+	err = connect.codec.unmarshal(data, request)
+	if err != nil {
+		writeConnectError(w, status.Newf(codes.InvalidArgument, "invalid request: %v", err))
+		return
+	}
+
+	var response proto.Message
+	call := connect.gateway.call(r, r.URL.Path, []proto.Message{request}, func() proto.Message {
+		return newMessage(connect.desc.Output())
+	}, func(message proto.Message) error {
+		response = message
+		return nil
+	}, nil)
+	if call.status.Code() == codes.OK && response == nil {
+		call.status = status.New(codes.Internal, "gRPC server sent no response")
+	}
+
+	headers, trailers := responseMetadata(call.header)
+	for key, values := range headers {
+		w.Header()[key] = values
+	}
+	for key, values := range trailers {
+		w.Header()["Trailer-"+key] = values
+	}
+	if call.status.Code() != codes.OK {
+		writeConnectError(w, call.status)
+		return
+	}
+
+	data, err = connect.codec.marshal(response)
+	if err != nil {
+		writeConnectError(w, status.New(codes.Internal, err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/"+string(connect.codec))
+	w.Write(data)
+}
+
+// serveStream serves a streaming call, of any kind. The response is 200 OK even for failed calls,
+// their status is in the last message.
+func (connect *connectCall) serveStream(w http.ResponseWriter, r *http.Request) {
+	body := io.Reader(r.Body)
+	decodeErr := make(chan error, 1)
+	if connect.codec == "json" {
+		// the gRPC server reads the requests as they are transcoded, for bidirectional streams
+		reader, writer := io.Pipe()
+		defer reader.Close()
+		go func() {
+			err := connect.transcodeRequests(writer, r.Body)
+			decodeErr <- err
+			writer.CloseWithError(err)
+		}()
+		body = reader
+	}
+
+	controller := http.NewResponseController(w)
+	started := false
+	start := func(header http.Header) {
+		if !started {
+			started = true
+			headers, _ := responseMetadata(header)
+			for key, values := range headers {
+				w.Header()[key] = values
+			}
+			w.Header().Set("Content-Type", "application/connect+"+string(connect.codec))
+			w.WriteHeader(http.StatusOK)
+			controller.Flush()
+		}
+	}
+	call := connect.gateway.forward(r, r.URL.Path, body, func(data []byte) error {
+		if connect.codec == "json" {
+			response := newMessage(connect.desc.Output())
+			err := proto.Unmarshal(data, response)
+			if err != nil {
+				return err
+			}
+			data, err = connectMarshaler.Marshal(response)
+			if err != nil {
+				return err
+			}
+		}
+		err := writeFrame(w, 0, data)
+		if err != nil {
+			return err
+		}
+		return controller.Flush()
+	}, start)
+	start(call.header)
+
+	select {
+	case err := <-decodeErr:
+		if err != nil && call.status.Code() != codes.OK {
+			// the call failed reading a request the gateway could not transcode
+			call.status = status.Convert(err)
+		}
+	default:
+	}
+	_, trailers := responseMetadata(call.header)
+	connect.writeEndStream(w, call.status, trailers)
+}
+
+// transcodeRequests reads the JSON requests of a stream from body and writes them to w as
+// the protobuf ones of a gRPC stream.
+func (connect *connectCall) transcodeRequests(w io.Writer, body io.Reader) error {
+	for i := 1; ; i++ {
+		flags, data, err := readFrame(body)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "cannot read request %d: %v", i, err)
+		}
+		if flags&connectCompressedFlag != 0 {
+			return status.Errorf(codes.Internal, "request %d is compressed without a Connect-Content-Encoding", i)
+		}
+
+		request := newMessage(connect.desc.Input())
+		err = unmarshaler.Unmarshal(data, request)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid request %d: %v", i, err)
+		}
+		data, err = proto.Marshal(request)
+		if err != nil {
+			return status.Errorf(codes.Internal, "cannot encode request %d: %v", i, err)
+		}
+		err = writeFrame(w, 0, data)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeEndStream writes the last message of a stream.
+func (connect *connectCall) writeEndStream(w http.ResponseWriter, st *status.Status, trailers http.Header) {
+	end := connectEndStream{Metadata: trailers}
+	if st.Code() != codes.OK {
+		end.Error = newConnectError(st)
+	}
+	if len(end.Metadata) == 0 {
+		end.Metadata = nil
+	}
+	data, err := json.Marshal(end)
+	if err != nil {
+		data = []byte(`{"error":{"code":"internal"}}`)
+	}
+	writeFrame(w, connectEndStreamFlag, data)
+}
+
+// writeConnectError answers the error of a unary call as JSON with the HTTP status matching its code.
+func writeConnectError(w http.ResponseWriter, st *status.Status) {
+	data, err := json.Marshal(newConnectError(st))
+	if err != nil {
+		http.Error(w, st.Message(), HTTPStatus(st.Code()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	setRetryAfter(w.Header(), st)
+	w.WriteHeader(HTTPStatus(st.Code()))
+	w.Write(data)
+}
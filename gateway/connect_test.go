@@ -0,0 +1,169 @@
+package gateway_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/go-http-server/grpc/protoc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// connectCall sends a Connect request and returns its response.
+func connectCall(t *testing.T, url, contentType, method, token string, body []byte) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, url+method, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Connect-Protocol-Version", "1")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res, data
+}
+
+// envelopes frames the messages of a Connect stream request.
+func envelopes(messages ...string) []byte {
+	var body []byte
+	for _, message := range messages {
+		body = append(body, 0)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(message)))
+		body = append(body, message...)
+	}
+	return body
+}
+
+// readEnvelopes splits a Connect stream response into its messages and its end-stream message.
+func readEnvelopes(t *testing.T, data []byte) ([]string, map[string]any) {
+	t.Helper()
+
+	var messages []string
+	for len(data) > 0 {
+		require.GreaterOrEqual(t, len(data), 5)
+		flags, size := data[0], binary.BigEndian.Uint32(data[1:5])
+		message := data[5 : 5+size]
+		data = data[5+size:]
+		if flags&0x02 != 0 {
+			require.Empty(t, data, "messages after the end of the stream")
+			return messages, decode(t, string(message))
+		}
+		messages = append(messages, string(message))
+	}
+	require.Fail(t, "no end-stream message")
+	return nil, nil
+}
+
+func TestGatewayConnectUnary(t *testing.T) {
+	t.Parallel()
+
+	url := startTestGateway(t)
+
+	res, body := connectCall(t, url, "application/json", protoc.AuthService_Login_FullMethodName, "",
+		[]byte(`{"username": "admin_valid", "password": "Laptop#Store1"}`))
+	require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+	require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	token, ok := decode(t, string(body))["accessToken"].(string)
+	require.True(t, ok, string(body))
+
+	request, err := proto.Marshal(&protoc.Point{Latitude: 409146138, Longitude: -746188906})
+	require.NoError(t, err)
+	res, body = connectCall(t, url, "application/proto", protoc.RouteGuide_GetFeature_FullMethodName, token, request)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+	require.Equal(t, "application/proto", res.Header.Get("Content-Type"))
+	feature := &protoc.Feature{}
+	require.NoError(t, proto.Unmarshal(body, feature))
+	require.Equal(t, "Berkshire Valley Management Area Trail, Jefferson, NJ, USA", feature.GetName())
+
+	testCases := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		status int
+		code   string
+	}{
+		{name: "unauthenticated", method: protoc.RouteGuide_GetFeature_FullMethodName, body: `{"latitude": 409146138, "longitude": -746188906}`, status: http.StatusUnauthorized, code: "unauthenticated"},
+		{name: "invalid", method: protoc.RouteGuide_FindNearest_FullMethodName, token: token, body: `{"location": {"latitude": 409146138, "longitude": -746188906}, "k": 0}`, status: http.StatusBadRequest, code: "invalid_argument"},
+		{name: "not json", method: protoc.RouteGuide_GetFeature_FullMethodName, token: token, body: `latitude=1`, status: http.StatusBadRequest, code: "invalid_argument"},
+		{name: "streaming method", method: protoc.RouteGuide_ListFeatures_FullMethodName, token: token, body: `{}`, status: http.StatusNotImplemented, code: "unimplemented"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			res, body := connectCall(t, url, "application/json", tc.method, tc.token, []byte(tc.body))
+			require.Equal(t, tc.status, res.StatusCode, string(body))
+			require.Equal(t, tc.code, decode(t, string(body))["code"])
+		})
+	}
+}
+
+func TestGatewayConnectStream(t *testing.T) {
+	t.Parallel()
+
+	url := startTestGateway(t)
+	client := newTestClient(t, url)
+
+	rectangle := `{"lo": {"latitude": 400000000, "longitude": -750000000}, "hi": {"latitude": 420000000, "longitude": -730000000}}`
+	res, body := connectCall(t, url, "application/connect+json", protoc.RouteGuide_ListFeatures_FullMethodName, client.token, envelopes(rectangle))
+	require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+	require.Equal(t, "application/connect+json", res.Header.Get("Content-Type"))
+	messages, end := readEnvelopes(t, body)
+	require.Greater(t, len(messages), 1)
+	require.NotEmpty(t, decode(t, messages[0])["name"])
+	require.Nil(t, end["error"])
+
+	res, body = connectCall(t, url, "application/connect+json", protoc.RouteGuide_RecordRoute_FullMethodName, client.token, envelopes(
+		`{"latitude": 409146138, "longitude": -746188906, "recordedAt": "2026-01-02T15:04:05Z"}`,
+		`{"latitude": 409146138, "longitude": -746188906, "recordedAt": "2026-01-02T15:05:05Z"}`,
+	))
+	require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+	messages, end = readEnvelopes(t, body)
+	require.Len(t, messages, 1)
+	require.EqualValues(t, 2, decode(t, messages[0])["pointCount"])
+	require.Nil(t, end["error"])
+
+	point, err := proto.Marshal(&protoc.Point{Latitude: 409146138, Longitude: -746188906})
+	require.NoError(t, err)
+	res, body = connectCall(t, url, "application/connect+proto", protoc.RouteGuide_RecordRoute_FullMethodName, client.token, envelopes(string(point)))
+	require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+	messages, end = readEnvelopes(t, body)
+	require.Len(t, messages, 1)
+	require.Nil(t, end["error"])
+
+	// errors are reported by the end of the stream, the HTTP status is always 200 OK
+	testCases := []struct {
+		name   string
+		method string
+		token  string
+		body   []byte
+		code   string
+	}{
+		{name: "unauthenticated", method: protoc.RouteGuide_ListFeatures_FullMethodName, body: envelopes(rectangle), code: "unauthenticated"},
+		{name: "invalid json", method: protoc.RouteGuide_ListFeatures_FullMethodName, token: client.token, body: envelopes(`{"lo": 1}`), code: "invalid_argument"},
+		{name: "invalid second request", method: protoc.RouteGuide_RecordRoute_FullMethodName, token: client.token, body: envelopes(`{"latitude": 409146138, "longitude": -746188906}`, `{"latitude": 409146138`), code: "invalid_argument"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			res, body := connectCall(t, url, "application/connect+json", tc.method, tc.token, tc.body)
+			require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+			messages, end := readEnvelopes(t, body)
+			require.Empty(t, messages)
+			connectErr, ok := end["error"].(map[string]any)
+			require.True(t, ok, string(body))
+			require.Equal(t, tc.code, connectErr["code"])
+			require.NotEmpty(t, connectErr["message"])
+		})
+	}
+}
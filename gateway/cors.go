@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// corsHeaders are the request headers browsers may always send: those of the protocols the gateway
+// serves and the credentials of the callers.
+var corsHeaders = []string{
+	"Authorization",
+	"Content-Type",
+	"X-Api-Key",
+	"X-Grpc-Web",
+	"X-User-Agent",
+	"Grpc-Timeout",
+	"Connect-Protocol-Version",
+	"Connect-Timeout-Ms",
+}
+
+// corsExposedHeaders are the response headers scripts may always read, the status of gRPC-Web calls
+// answered without a body.
+var corsExposedHeaders = []string{"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"}
+
+// CORS lets browser pages of other origins call the gateway.
+type CORS struct {
+	AllowedOrigins   []string      // origins allowed, such as https://app.example.com, or * for any; none when empty
+	AllowedHeaders   []string      // request headers allowed besides those of the protocols, e.g. X-Simplify-Tolerance
+	ExposedHeaders   []string      // response headers scripts may read besides the gRPC status
+	AllowCredentials bool          // let browsers send cookies and TLS client certificates
+	MaxAge           time.Duration // how long browsers may cache a preflight answer, their default when 0
+}
+
+// allowOrigin tells whether cross-origin requests of origin are allowed.
+func (cors *CORS) allowOrigin(origin string) bool {
+	return slices.ContainsFunc(cors.AllowedOrigins, func(allowed string) bool {
+		return allowed == "*" || strings.EqualFold(allowed, origin)
+	})
+}
+
+// handle sets the CORS headers of the response to a request of an allowed origin. It answers
+// preflight requests itself and then returns true.
+func (cors *CORS) handle(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	if origin == "" {
+		return false
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	if !cors.allowOrigin(origin) {
+		if preflight {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+		}
+		return preflight
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if cors.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		header.Set("Access-Control-Expose-Headers", strings.Join(slices.Concat(corsExposedHeaders, cors.ExposedHeaders), ", "))
+		return false
+	}
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+	header.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+	header.Set("Access-Control-Allow-Headers", strings.Join(slices.Concat(corsHeaders, cors.AllowedHeaders), ", "))
+	if cors.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(cors.MaxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -0,0 +1,453 @@
+// Package gateway serves the gRPC services over HTTP for clients that cannot speak native gRPC, such
+// as web frontends and curl. One handler serves, told apart by the request content type:
+//
+//   - native gRPC, application/grpc, over HTTP/2;
+//   - gRPC-Web, application/grpc-web and its base64 variant application/grpc-web-text;
+//   - the Connect protocol, unary application/proto and application/json calls and
+//     application/connect+proto and application/connect+json streams;
+//   - HTTP/JSON REST routes, any other request.
+//
+// Each call is handled in process by the gRPC server, so every protocol goes through the same
+// interceptors as native gRPC: authentication, access policy, validation and audit.
+//
+// REST messages are encoded with protojson using the proto field names. Server streaming routes answer
+// newline-delimited JSON, one response per line, and client streaming routes read one request per
+// line of the body. An error after the first line is reported as a last {"error": status} line.
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// maxBodySize bounds the request bodies, a bit more than the largest image UploadImage accepts.
+const maxBodySize = 12 << 20
+
+var (
+	marshaler = protojson.MarshalOptions{
+		EmitDefaultValues: true,
+		UseProtoNames:     true,
+	}
+	unmarshaler = protojson.UnmarshalOptions{}
+)
+
+// Options configure a gateway.
+type Options struct {
+	CORS CORS // cross-origin requests of browsers, none are allowed by default
+}
+
+// Gateway is an http.Handler serving the calls of every protocol with a gRPC server.
+type Gateway struct {
+	grpcServer http.Handler
+	cors       CORS
+	mux        *http.ServeMux
+}
+
+// New returns a gateway calling the services of grpcServer, a *grpc.Server.
+// Routes of services grpcServer does not register fail with 501 Not Implemented.
+func New(grpcServer http.Handler, options Options) *Gateway {
+	gateway := &Gateway{grpcServer: grpcServer, cors: options.CORS, mux: http.NewServeMux()}
+	for _, route := range routes {
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(methodName(route.method))
+		if err != nil {
+			panic(fmt.Sprintf("gateway route %s: %v", route.pattern, err))
+		}
+		gateway.mux.Handle(route.pattern, &handler{gateway: gateway, route: route, desc: desc.(protoreflect.MethodDescriptor)})
+	}
+	return gateway
+}
+
+func (gateway *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if gateway.cors.handle(w, r) {
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case strings.HasPrefix(contentType, "application/grpc-web"):
+		gateway.serveGRPCWeb(w, r, contentType)
+	case strings.HasPrefix(contentType, "application/grpc"):
+		// the gRPC server answers calls that are not over HTTP/2 with 505 HTTP Version Not Supported
+		gateway.grpcServer.ServeHTTP(w, r)
+	case strings.HasPrefix(contentType, "application/connect+"):
+		gateway.serveConnect(w, r, contentType)
+	case r.Method == http.MethodPost && (contentType == "application/proto" || contentType == "application/json") && isMethod(r.URL.Path):
+		gateway.serveConnect(w, r, contentType)
+	default:
+		gateway.mux.ServeHTTP(w, r)
+	}
+}
+
+// isMethod tells whether path, such as /LaptopService/CreateLaptop, names a gRPC method.
+func isMethod(path string) bool {
+	_, ok := lookupMethod(path)
+	return ok
+}
+
+// lookupMethod returns the descriptor of the gRPC method path names.
+func lookupMethod(path string) (protoreflect.MethodDescriptor, bool) {
+	if strings.Count(path, "/") != 2 {
+		return nil, false
+	}
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(methodName(path))
+	if err != nil {
+		return nil, false
+	}
+	method, ok := desc.(protoreflect.MethodDescriptor)
+	return method, ok
+}
+
+// methodName returns the descriptor name of the full gRPC method name, LaptopService.CreateLaptop
+// for /LaptopService/CreateLaptop.
+func methodName(fullMethod string) protoreflect.FullName {
+	return protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(fullMethod, "/"), "/", "."))
+}
+
+// handler serves one route.
+type handler struct {
+	gateway *Gateway
+	route   route
+	desc    protoreflect.MethodDescriptor
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	err := h.checkMediaType(r)
+	if err != nil {
+		writeErrorStatus(w, status.Convert(err), http.StatusUnsupportedMediaType)
+		return
+	}
+	requests, err := h.decodeRequests(r)
+	if err != nil {
+		writeError(w, status.Convert(err))
+		return
+	}
+
+	newResponse := func() proto.Message {
+		return newMessage(h.desc.Output())
+	}
+	if !h.desc.IsStreamingServer() {
+		var response proto.Message
+		call := h.gateway.call(r, h.route.method, requests, newResponse, func(message proto.Message) error {
+			response = message
+			return nil
+		}, nil)
+		if call.status.Code() == codes.OK && response == nil {
+			call.status = status.New(codes.Internal, "gRPC server sent no response")
+		}
+		if call.status.Code() != codes.OK {
+			writeMetadata(w.Header(), call.header)
+			writeError(w, call.status)
+			return
+		}
+
+		data, err := marshaler.Marshal(response)
+		if err != nil {
+			writeError(w, status.New(codes.Internal, err.Error()))
+			return
+		}
+		writeMetadata(w.Header(), call.header)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	started := false
+	start := func(header http.Header) {
+		if !started {
+			started = true
+			writeMetadata(w.Header(), header)
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			controller.Flush()
+		}
+	}
+	call := h.gateway.call(r, h.route.method, requests, newResponse, func(message proto.Message) error {
+		data, err := marshaler.Marshal(message)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		if err != nil {
+			return err
+		}
+		return controller.Flush()
+	}, start)
+
+	switch {
+	case call.status.Code() == codes.OK:
+		start(call.header)
+	case started:
+		// the status cannot be told with the HTTP status code anymore
+		data, err := marshaler.Marshal(call.status.Proto())
+		if err == nil {
+			w.Write([]byte(`{"error":` + string(data) + "}\n"))
+		}
+	default:
+		writeMetadata(w.Header(), call.header)
+		writeError(w, call.status)
+	}
+}
+
+// checkMediaType makes sure a request body is not of a media type an HTML form may send, such as
+// text/plain, so browsers preflight cross-origin requests before sending the cookies or the client
+// certificate of their user. JSON bodies must be application/json.
+func (h *handler) checkMediaType(r *http.Request) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+
+	switch {
+	case h.route.decode != nil:
+		if mediaType == "" || mediaType == "text/plain" || mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data" {
+			return status.Errorf(codes.InvalidArgument, "unsupported request media type %q", mediaType)
+		}
+	case h.desc.IsStreamingClient() || h.route.body != "":
+		if mediaType != "application/json" {
+			return status.Errorf(codes.InvalidArgument, "request body must be application/json, got %q", mediaType)
+		}
+	}
+	return nil
+}
+
+// decodeRequests returns the requests the HTTP request carries: one, or for client streaming
+// methods one per line of the body. The path wildcards and query parameters set fields of each
+// request, over the body.
+func (h *handler) decodeRequests(r *http.Request) ([]proto.Message, error) {
+	if h.route.decode != nil {
+		return h.route.decode(r)
+	}
+
+	fields := r.URL.Query()
+	for wildcard, field := range h.route.params {
+		fields.Set(field, r.PathValue(wildcard))
+	}
+
+	var requests []proto.Message
+	switch {
+	case h.desc.IsStreamingClient():
+		decoder := json.NewDecoder(r.Body)
+		for {
+			var line json.RawMessage
+			err := decoder.Decode(&line)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "cannot read request %d: %v", len(requests)+1, err)
+			}
+
+			request := newMessage(h.desc.Input())
+			err = unmarshaler.Unmarshal(line, request)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid request %d: %v", len(requests)+1, err)
+			}
+			requests = append(requests, request)
+		}
+
+	case h.route.body != "":
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "cannot read request body: %v", err)
+		}
+
+		request := newMessage(h.desc.Input())
+		target := request
+		if h.route.body != "*" {
+			field := request.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(h.route.body))
+			target = request.ProtoReflect().Mutable(field).Message().Interface()
+		}
+		err = unmarshaler.Unmarshal(data, target)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request body: %v", err)
+		}
+		requests = append(requests, request)
+
+	default:
+		requests = append(requests, newMessage(h.desc.Input()))
+	}
+
+	for _, request := range requests {
+		err := decodeFields(request, fields)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+	}
+	return requests, nil
+}
+
+// decodeFields sets the fields of message named by the dotted field paths of values, such as
+// filter.min_memory.value=4. A value is parsed as protojson parses a JSON string, so enums take their
+// names and timestamps RFC 3339 dates; repeated fields take every value of their path.
+func decodeFields(message proto.Message, values url.Values) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	object := make(map[string]any)
+	for path, vals := range values {
+		parent, desc := object, message.ProtoReflect().Descriptor()
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			field := desc.Fields().ByName(protoreflect.Name(name))
+			if field == nil {
+				field = desc.Fields().ByJSONName(name)
+			}
+			if field == nil {
+				return fmt.Errorf("unknown field %q", path)
+			}
+			key := string(field.Name())
+
+			if i < len(names)-1 {
+				if field.Message() == nil || field.IsList() || field.IsMap() {
+					return fmt.Errorf("field %q is not a message", strings.Join(names[:i+1], "."))
+				}
+				child, ok := parent[key].(map[string]any)
+				if !ok {
+					child = make(map[string]any)
+					parent[key] = child
+				}
+				parent, desc = child, field.Message()
+				continue
+			}
+
+			value, err := fieldValue(field, vals)
+			if err != nil {
+				return fmt.Errorf("field %q: %w", path, err)
+			}
+			parent[key] = value
+		}
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	decoded := message.ProtoReflect().New().Interface()
+	err = unmarshaler.Unmarshal(data, decoded)
+	if err != nil {
+		return err
+	}
+	proto.Merge(message, decoded)
+	return nil
+}
+
+// fieldValue returns the JSON value of field holding values.
+func fieldValue(field protoreflect.FieldDescriptor, values []string) (any, error) {
+	parse := func(value string) (any, error) {
+		if field.Kind() == protoreflect.BoolKind {
+			return strconv.ParseBool(value)
+		}
+		// protojson reads numbers and well-known types from JSON strings too
+		return value, nil
+	}
+
+	switch {
+	case field.IsMap():
+		return nil, errors.New("map fields cannot be set from a URL")
+	case field.IsList():
+		list := make([]any, len(values))
+		for i, value := range values {
+			parsed, err := parse(value)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = parsed
+		}
+		return list, nil
+	case len(values) > 1:
+		return nil, errors.New("field is not repeated")
+	default:
+		return parse(values[0])
+	}
+}
+
+func newMessage(desc protoreflect.MessageDescriptor) proto.Message {
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(desc.FullName())
+	if err != nil {
+		panic(fmt.Sprintf("gateway: %v", err))
+	}
+	return messageType.New().Interface()
+}
+
+// writeError answers the gRPC status as JSON with the HTTP status matching its code.
+func writeError(w http.ResponseWriter, st *status.Status) {
+	writeErrorStatus(w, st, HTTPStatus(st.Code()))
+}
+
+// writeErrorStatus answers the gRPC status as JSON with the HTTP status code.
+func writeErrorStatus(w http.ResponseWriter, st *status.Status, code int) {
+	data, err := marshaler.Marshal(st.Proto())
+	if err != nil {
+		http.Error(w, st.Message(), code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if st.Code() == codes.Unauthenticated {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	setRetryAfter(w.Header(), st)
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+// setRetryAfter sets the Retry-After header of a throttled call from the google.rpc.RetryInfo
+// detail of its status.
+func setRetryAfter(header http.Header, st *status.Status) {
+	for _, detail := range st.Details() {
+		if retryInfo, ok := detail.(*errdetails.RetryInfo); ok {
+			seconds := math.Ceil(retryInfo.GetRetryDelay().AsDuration().Seconds())
+			header.Set("Retry-After", strconv.Itoa(int(seconds)))
+			return
+		}
+	}
+}
+
+// HTTPStatus returns the HTTP status code of a gRPC status code.
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // client closed request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,404 @@
+package gateway_test
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"buf.build/go/protovalidate"
+	"github.com/go-http-server/grpc/gateway"
+	"github.com/go-http-server/grpc/protoc"
+	"github.com/go-http-server/grpc/sample"
+	"github.com/go-http-server/grpc/serializer"
+	"github.com/go-http-server/grpc/service"
+	protovalidate_middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/protovalidate"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// startTestGateway serves the gateway of a test gRPC server and returns its URL.
+func startTestGateway(t *testing.T) string {
+	t.Helper()
+
+	server := httptest.NewServer(gateway.New(newTestGRPCServer(t), gateway.Options{}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// newTestGRPCServer returns a gRPC server authenticating its callers, with the admin_valid account.
+func newTestGRPCServer(t *testing.T) *grpc.Server {
+	t.Helper()
+
+	accountStore := service.NewInMemoryAccountStore()
+	hasher := &service.BcryptHasher{Cost: bcrypt.MinCost}
+	acc, err := service.NewAccount("admin_valid", "Laptop#Store1", "admin", hasher)
+	require.NoError(t, err)
+	require.NoError(t, accountStore.Save(acc))
+
+	policy := &service.Policy{
+		DefaultDeny: true,
+		Public:      []string{"/AuthService/Login"},
+		Permissions: map[string][]string{"all": {"/AuthService/*", "/LaptopService/*", "/RouteGuide/*"}},
+		Roles:       map[string][]string{"admin": {"all"}},
+	}
+	maker := service.NewPasetoMaker(paseto.NewV4AsymmetricSecretKey(), service.TokenConfig{})
+	limiter := service.NewLoginLimiter(service.LoginLimiterConfig{MaxFailures: 5, Window: time.Minute, BaseLockout: time.Minute, MaxLockout: time.Minute})
+	auditLog, err := service.OpenFileAuditLog("")
+	require.NoError(t, err)
+	authServer, err := service.NewAuthServer(accountStore, hasher, maker, time.Minute, limiter, service.NewInMemoryAPIKeyStore(), auditLog)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile("../sample/route_guide.json")
+	require.NoError(t, err)
+	filename := filepath.Join(t.TempDir(), "features.json")
+	require.NoError(t, os.WriteFile(filename, data, 0o600))
+	featureStore, err := service.OpenFileFeatureStore(filename)
+	require.NoError(t, err)
+	routeStore, err := service.OpenFileRouteStore("")
+	require.NoError(t, err)
+	chatBroker, err := service.OpenChatBroker(service.ChatBrokerOptions{})
+	require.NoError(t, err)
+
+	validator, err := protovalidate.New()
+	require.NoError(t, err)
+	interceptor := service.NewAuthInterceptor(maker, policy, nil, nil)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(protovalidate_middleware.UnaryServerInterceptor(validator), interceptor.Unary()),
+		grpc.ChainStreamInterceptor(protovalidate_middleware.StreamServerInterceptor(validator), interceptor.Stream()),
+	)
+	protoc.RegisterAuthServiceServer(grpcServer, authServer)
+	protoc.RegisterLaptopServiceServer(grpcServer, service.NewLaptopServer(service.NewInMemoryLaptopStore(), nil, service.NewInMemoryRatingStore()))
+	protoc.RegisterRouteGuideServer(grpcServer, service.NewRouteGuideServer(featureStore, routeStore, chatBroker, 1000))
+	return grpcServer
+}
+
+// testClient sends REST requests with the access token of admin_valid.
+type testClient struct {
+	t     *testing.T
+	url   string
+	token string
+}
+
+func newTestClient(t *testing.T, url string) *testClient {
+	client := &testClient{t: t, url: url}
+	res, body := client.do(http.MethodPost, "/v1/auth/login", `{"username": "admin_valid", "password": "Laptop#Store1"}`)
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &login))
+	require.NotEmpty(t, login.AccessToken)
+	client.token = login.AccessToken
+	return client
+}
+
+func (client *testClient) do(method, path, body string) (*http.Response, string) {
+	client.t.Helper()
+
+	req, err := http.NewRequestWithContext(client.t.Context(), method, client.url+path, strings.NewReader(body))
+	require.NoError(client.t, err)
+	if client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.token)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(client.t, err)
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	require.NoError(client.t, err)
+	return res, string(data)
+}
+
+func decode(t *testing.T, body string) map[string]any {
+	t.Helper()
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &decoded))
+	return decoded
+}
+
+// lines decodes the newline-delimited JSON of a streaming response.
+func lines(t *testing.T, body string) []map[string]any {
+	t.Helper()
+
+	var decoded []map[string]any
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		decoded = append(decoded, decode(t, scanner.Text()))
+	}
+	return decoded
+}
+
+func TestGatewayLaptops(t *testing.T) {
+	t.Parallel()
+
+	url := startTestGateway(t)
+	anonymous := &testClient{t: t, url: url}
+	client := newTestClient(t, url)
+
+	res, body := anonymous.do(http.MethodGet, "/v1/laptops/"+sample.NewLaptop().GetId(), "")
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	require.Equal(t, "Bearer", res.Header.Get("WWW-Authenticate"))
+	require.EqualValues(t, codes.Unauthenticated, decode(t, body)["code"])
+
+	res, body = anonymous.do(http.MethodPost, "/v1/auth/login", `{"username": "admin_valid", "password": "Wrong#Pass1"}`)
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode, body)
+
+	laptop := sample.NewLaptop()
+	laptop.PriceUsd = 1500
+	data, err := serializer.ProtobufToJSON(laptop)
+	require.NoError(t, err)
+	res, body = client.do(http.MethodPost, "/v1/laptops", string(data))
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	require.JSONEq(t, `{"id": "`+laptop.GetId()+`"}`, body)
+
+	res, body = client.do(http.MethodPost, "/v1/laptops", string(data))
+	require.Equal(t, http.StatusConflict, res.StatusCode, body)
+	res, body = client.do(http.MethodPost, "/v1/laptops", `{"price_usd": "cheap"}`)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode, body)
+
+	res, body = client.do(http.MethodGet, "/v1/laptops/"+laptop.GetId(), "")
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	found := decode(t, body)
+	require.Equal(t, laptop.GetId(), found["id"])
+	require.Equal(t, "admin_valid", found["owner"])
+	require.Equal(t, laptop.GetBrand(), found["brand"])
+
+	res, body = client.do(http.MethodGet, "/v1/laptops/"+sample.NewLaptop().GetId(), "")
+	require.Equal(t, http.StatusNotFound, res.StatusCode, body)
+
+	// the query parameters set the filter, server streaming answers a laptop per line
+	res, body = client.do(http.MethodGet, "/v1/laptops:search?filter.max_price_usd=2000&filter.min_cpu_cores=2&filter.min_cpu_ghz=1&filter.min_memory.value=1&filter.min_memory.unit=BYTE", "")
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	require.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+	results := lines(t, body)
+	require.Len(t, results, 1)
+	require.Equal(t, laptop.GetId(), results[0]["laptop"].(map[string]any)["id"])
+
+	res, body = client.do(http.MethodGet, "/v1/laptops:search?filter.max_price_usd=1000&filter.min_cpu_cores=2&filter.min_cpu_ghz=1&filter.min_memory.value=1&filter.min_memory.unit=BYTE", "")
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	require.Empty(t, body)
+
+	res, body = client.do(http.MethodGet, "/v1/laptops:search?filter.color=red", "")
+	require.Equal(t, http.StatusBadRequest, res.StatusCode, body)
+	require.Contains(t, body, `unknown field \"filter.color\"`)
+
+	// bidirectional streaming reads a rating per line and answers the running average per line
+	res, body = client.do(http.MethodPost, "/v1/laptops/"+laptop.GetId()+"/ratings", "{\"score\": 4}\n{\"score\": 5}\n")
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	results = lines(t, body)
+	require.Len(t, results, 2)
+	require.EqualValues(t, 4.5, results[1]["average_score"])
+	require.EqualValues(t, 2, results[1]["rated_count"])
+
+	res, body = client.do(http.MethodDelete, "/v1/laptops/"+laptop.GetId(), "")
+	require.Equal(t, http.StatusMethodNotAllowed, res.StatusCode, body)
+}
+
+func TestGatewayRouteGuide(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, startTestGateway(t))
+
+	res, body := client.do(http.MethodGet, "/v1/features/409146138/-746188906", "")
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	require.Equal(t, "Berkshire Valley Management Area Trail, Jefferson, NJ, USA", decode(t, body)["name"])
+
+	res, body = client.do(http.MethodGet, "/v1/features?lo.latitude=400000000&lo.longitude=-750000000&hi.latitude=420000000&hi.longitude=-730000000", "")
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	require.NotEmpty(t, lines(t, body))
+
+	res, body = client.do(http.MethodGet, "/v1/features:nearest?location.latitude=409146138&location.longitude=-746188906&k=2", "")
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	var nearest struct {
+		Features []struct {
+			Distance int `json:"distance"`
+		} `json:"features"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &nearest))
+	require.Len(t, nearest.Features, 2)
+	require.Zero(t, nearest.Features[0].Distance)
+
+	// the validation interceptor rejects the request as it does gRPC calls
+	res, body = client.do(http.MethodGet, "/v1/features:nearest?location.latitude=409146138&location.longitude=-746188906&k=0", "")
+	require.Equal(t, http.StatusBadRequest, res.StatusCode, body)
+	require.EqualValues(t, codes.InvalidArgument, decode(t, body)["code"])
+
+	// client streaming reads a point per line
+	points := `{"latitude": 409146138, "longitude": -746188906, "recorded_at": "2026-01-02T15:04:05Z"}
+{"latitude": 409146138, "longitude": -746188906, "recorded_at": "2026-01-02T15:05:05Z"}`
+	res, body = client.do(http.MethodPost, "/v1/routes", points)
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	var summary struct {
+		PointCount  int    `json:"point_count"`
+		ElapsedTime int    `json:"elapsed_time"`
+		RouteID     string `json:"route_id"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &summary))
+	require.Equal(t, 2, summary.PointCount)
+	require.Equal(t, 60, summary.ElapsedTime)
+
+	res, body = client.do(http.MethodGet, "/v1/routes/"+summary.RouteID, "")
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	require.Equal(t, "admin_valid", decode(t, body)["owner"])
+
+	res, body = client.do(http.MethodGet, "/v1/routes?from=2026-01-02T00:00:00Z", "")
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	require.Contains(t, body, summary.RouteID)
+	res, body = client.do(http.MethodGet, "/v1/routes?from=yesterday", "")
+	require.Equal(t, http.StatusBadRequest, res.StatusCode, body)
+
+	res, body = client.do(http.MethodPut, "/v1/features/409146138/-746188906", `{"name": "Renamed", "location": {"latitude": 409146138, "longitude": -746188906}}`)
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	require.Equal(t, "Renamed", decode(t, body)["name"])
+
+	res, body = client.do(http.MethodDelete, "/v1/features/409146138/-746188906", "")
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	res, body = client.do(http.MethodDelete, "/v1/features/409146138/-746188906", "")
+	require.Equal(t, http.StatusNotFound, res.StatusCode, body)
+}
+
+func TestGatewayNativeGRPC(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewUnstartedServer(gateway.New(newTestGRPCServer(t), gateway.Options{}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	conn, err := grpc.NewClient(server.Listener.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool})))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	authClient := protoc.NewAuthServiceClient(conn)
+	login, err := authClient.Login(t.Context(), &protoc.LoginRequest{Username: "admin_valid", Password: "Laptop#Store1"})
+	require.NoError(t, err)
+	require.NotEmpty(t, login.GetAccessToken())
+
+	_, err = protoc.NewRouteGuideClient(conn).GetFeature(t.Context(), &protoc.Point{Latitude: 409146138, Longitude: -746188906})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// REST routes are served on the same port
+	res, err := server.Client().Get(server.URL + "/v1/features/409146138/-746188906")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestGatewayCORS(t *testing.T) {
+	t.Parallel()
+
+	handler := gateway.New(http.NotFoundHandler(), gateway.Options{CORS: gateway.CORS{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedHeaders: []string{"X-Simplify-Tolerance"},
+		MaxAge:         10 * time.Minute,
+	}})
+	serve := func(method, origin string, header map[string]string) *http.Response {
+		req := httptest.NewRequest(method, "/v1/features", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Result()
+	}
+	preflight := map[string]string{"Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "content-type,x-grpc-web"}
+
+	res := serve(http.MethodOptions, "https://app.example.com", preflight)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+	require.Equal(t, "https://app.example.com", res.Header.Get("Access-Control-Allow-Origin"))
+	require.Contains(t, res.Header.Get("Access-Control-Allow-Headers"), "X-Grpc-Web")
+	require.Contains(t, res.Header.Get("Access-Control-Allow-Headers"), "X-Simplify-Tolerance")
+	require.Equal(t, "600", res.Header.Get("Access-Control-Max-Age"))
+
+	res = serve(http.MethodOptions, "https://evil.example.com", preflight)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+	require.Empty(t, res.Header.Get("Access-Control-Allow-Origin"))
+
+	res = serve(http.MethodGet, "https://app.example.com", nil)
+	require.Equal(t, "https://app.example.com", res.Header.Get("Access-Control-Allow-Origin"))
+	require.Contains(t, res.Header.Get("Access-Control-Expose-Headers"), "Grpc-Status")
+
+	res = serve(http.MethodGet, "", nil)
+	require.Empty(t, res.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestGatewayMediaType(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, startTestGateway(t))
+	laptop, err := serializer.ProtobufToJSON(sample.NewLaptop())
+	require.NoError(t, err)
+
+	// bodies an HTML form may send without a preflight are refused
+	testCases := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		code        int
+	}{
+		{name: "json", path: "/v1/laptops", contentType: "application/json; charset=utf-8", body: string(laptop), code: http.StatusOK},
+		{name: "text form", path: "/v1/laptops", contentType: "text/plain", body: string(laptop), code: http.StatusUnsupportedMediaType},
+		{name: "urlencoded form", path: "/v1/features:import", contentType: "application/x-www-form-urlencoded", body: `{"data": "{}"}`, code: http.StatusUnsupportedMediaType},
+		{name: "no content type", path: "/v1/routes", body: `{"latitude": 1, "longitude": 1}`, code: http.StatusUnsupportedMediaType},
+		{name: "multipart image", path: "/v1/laptops/id/images?image_type=.png", contentType: "multipart/form-data; boundary=x", body: "--x--", code: http.StatusUnsupportedMediaType},
+	}
+	for _, tc := range testCases {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, client.url+tc.path, strings.NewReader(tc.body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+client.token)
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, tc.code, res.StatusCode, tc.name)
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	t.Parallel()
+
+	for code, expected := range map[codes.Code]int{
+		codes.OK:                 http.StatusOK,
+		codes.InvalidArgument:    http.StatusBadRequest,
+		codes.Unauthenticated:    http.StatusUnauthorized,
+		codes.PermissionDenied:   http.StatusForbidden,
+		codes.NotFound:           http.StatusNotFound,
+		codes.AlreadyExists:      http.StatusConflict,
+		codes.ResourceExhausted:  http.StatusTooManyRequests,
+		codes.FailedPrecondition: http.StatusBadRequest,
+		codes.Unimplemented:      http.StatusNotImplemented,
+		codes.Unavailable:        http.StatusServiceUnavailable,
+		codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+		codes.DataLoss:           http.StatusInternalServerError,
+	} {
+		require.Equal(t, expected, gateway.HTTPStatus(code), code.String())
+	}
+}
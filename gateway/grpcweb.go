@@ -0,0 +1,100 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+)
+
+// grpcWebTrailerFlag flags the last message of a gRPC-Web response, which holds the trailers.
+const grpcWebTrailerFlag = 0x80
+
+// serveGRPCWeb serves a gRPC-Web call of a browser. Its messages are framed as gRPC ones, base64
+// encoded for the text content types, and the trailers are sent as a last message since browsers
+// cannot read HTTP trailers.
+func (gateway *Gateway) serveGRPCWeb(w http.ResponseWriter, r *http.Request, contentType string) {
+	text := strings.HasPrefix(contentType, "application/grpc-web-text")
+	codec := strings.TrimPrefix(contentType, "application/grpc-web")
+	codec = strings.TrimPrefix(codec, "-text")
+	if codec != "" && codec != "+proto" {
+		http.Error(w, fmt.Sprintf("unsupported gRPC-Web content type %s", contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "gRPC-Web calls must be POST requests", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body := io.Reader(r.Body)
+	if text {
+		body = base64.NewDecoder(base64.StdEncoding, r.Body)
+	}
+	controller := http.NewResponseController(w)
+	write := func(flags byte, data []byte) error {
+		var frame bytes.Buffer
+		writeFrame(&frame, flags, data)
+		data = frame.Bytes()
+		if text {
+			// each message is encoded on its own, padding included, as the gRPC-Web clients decode them
+			data = []byte(base64.StdEncoding.EncodeToString(data))
+		}
+		_, err := w.Write(data)
+		if err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+
+	started := false
+	start := func(header http.Header) {
+		if !started {
+			started = true
+			headers, _ := responseMetadata(header)
+			for key, values := range headers {
+				w.Header()[key] = values
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(http.StatusOK)
+			controller.Flush()
+		}
+	}
+	call := gateway.forward(r, r.URL.Path, body, func(data []byte) error {
+		return write(0, data)
+	}, start)
+	start(call.header)
+
+	_, trailers := responseMetadata(call.header)
+	var block bytes.Buffer
+	fmt.Fprintf(&block, "grpc-status: %d\r\n", call.status.Code())
+	if message := call.status.Message(); message != "" {
+		fmt.Fprintf(&block, "grpc-message: %s\r\n", encodeGRPCMessage(message))
+	}
+	if details := call.header.Get("Grpc-Status-Details-Bin"); details != "" && call.status.Code() != codes.OK {
+		fmt.Fprintf(&block, "grpc-status-details-bin: %s\r\n", details)
+	}
+	for key, values := range trailers {
+		for _, value := range values {
+			fmt.Fprintf(&block, "%s: %s\r\n", strings.ToLower(key), value)
+		}
+	}
+	write(grpcWebTrailerFlag, block.Bytes())
+}
+
+// encodeGRPCMessage percent-encodes a status message as the grpc-message header carries it.
+func encodeGRPCMessage(message string) string {
+	var encoded strings.Builder
+	for i := range len(message) {
+		c := message[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
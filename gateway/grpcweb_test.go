@@ -0,0 +1,120 @@
+package gateway_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-http-server/grpc/protoc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcWebCall sends a gRPC-Web call and returns its response messages and trailers.
+func grpcWebCall(t *testing.T, url, contentType, method, token string, request proto.Message) ([][]byte, map[string]string) {
+	t.Helper()
+
+	data, err := proto.Marshal(request)
+	require.NoError(t, err)
+	body := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(data)))
+	body = append(body, data...)
+	text := strings.HasPrefix(contentType, "application/grpc-web-text")
+	if text {
+		body = []byte(base64.StdEncoding.EncodeToString(body))
+	}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, url+method, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Grpc-Web", "1")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, contentType, res.Header.Get("Content-Type"))
+
+	data, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+	if text {
+		// each message is encoded on its own, the padding ends it
+		var decoded []byte
+		for len(data) > 0 {
+			size := len(data)
+			if end := bytes.IndexByte(data, '='); end >= 0 {
+				size = end + 1
+				for size < len(data) && data[size] == '=' {
+					size++
+				}
+			}
+			chunk, err := base64.StdEncoding.DecodeString(string(data[:size]))
+			require.NoError(t, err)
+			decoded = append(decoded, chunk...)
+			data = data[size:]
+		}
+		data = decoded
+	}
+
+	var messages [][]byte
+	trailers := make(map[string]string)
+	for len(data) > 0 {
+		require.GreaterOrEqual(t, len(data), 5)
+		flags, size := data[0], binary.BigEndian.Uint32(data[1:5])
+		message := data[5 : 5+size]
+		data = data[5+size:]
+		if flags&0x80 == 0 {
+			messages = append(messages, message)
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(message)), "\r\n") {
+			key, value, ok := strings.Cut(line, ": ")
+			require.True(t, ok, line)
+			trailers[key] = value
+		}
+		require.Empty(t, data, "messages after the trailers")
+	}
+	return messages, trailers
+}
+
+func TestGatewayGRPCWeb(t *testing.T) {
+	t.Parallel()
+
+	url := startTestGateway(t)
+	for _, contentType := range []string{"application/grpc-web+proto", "application/grpc-web-text"} {
+		t.Run(contentType, func(t *testing.T) {
+			t.Parallel()
+
+			messages, trailers := grpcWebCall(t, url, contentType, protoc.AuthService_Login_FullMethodName, "",
+				&protoc.LoginRequest{Username: "admin_valid", Password: "Laptop#Store1"})
+			require.Equal(t, "0", trailers["grpc-status"])
+			require.Len(t, messages, 1)
+			login := &protoc.LoginResponse{}
+			require.NoError(t, proto.Unmarshal(messages[0], login))
+			require.NotEmpty(t, login.GetAccessToken())
+
+			// the auth interceptor rejects the call as it does gRPC calls, the status is in the trailers
+			messages, trailers = grpcWebCall(t, url, contentType, protoc.RouteGuide_GetFeature_FullMethodName, "",
+				&protoc.Point{Latitude: 409146138, Longitude: -746188906})
+			require.Empty(t, messages)
+			require.Equal(t, "16", trailers["grpc-status"])
+			require.NotEmpty(t, trailers["grpc-message"])
+
+			messages, trailers = grpcWebCall(t, url, contentType, protoc.RouteGuide_ListFeatures_FullMethodName, login.GetAccessToken(),
+				&protoc.Rectangle{
+					Lo: &protoc.Point{Latitude: 400000000, Longitude: -750000000},
+					Hi: &protoc.Point{Latitude: 420000000, Longitude: -730000000},
+				})
+			require.Equal(t, "0", trailers["grpc-status"])
+			require.Greater(t, len(messages), 1)
+			feature := &protoc.Feature{}
+			require.NoError(t, proto.Unmarshal(messages[0], feature))
+			require.NotEmpty(t, feature.GetName())
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package gateway
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-http-server/grpc/protoc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// imageChunkSize is the size of the chunks an uploaded image is streamed to UploadImage in.
+const imageChunkSize = 64 << 10
+
+// route maps an HTTP request pattern onto a gRPC method.
+type route struct {
+	pattern string // http.ServeMux pattern
+	method  string // full gRPC method name
+	// body is the request field the JSON body is decoded into, "*" for the whole request, or empty
+	// when the request has no body. Client streaming methods always read a request per body line.
+	body   string
+	params map[string]string // path wildcard -> request field path
+	// decode replaces the decoding of the requests, for bodies that are not JSON
+	decode func(r *http.Request) ([]proto.Message, error)
+}
+
+// routes are the REST routes of the services. RouteChat is not served, as both of its sides
+// stream at the same time.
+var routes = []route{
+	{pattern: "POST /v1/auth/login", method: protoc.AuthService_Login_FullMethodName, body: "*"},
+	{pattern: "GET /v1/auth/lockouts", method: protoc.AuthService_ListLockouts_FullMethodName},
+	{pattern: "POST /v1/api-keys", method: protoc.AuthService_CreateApiKey_FullMethodName, body: "*"},
+	{pattern: "GET /v1/api-keys", method: protoc.AuthService_ListApiKeys_FullMethodName},
+	{pattern: "DELETE /v1/api-keys/{id}", method: protoc.AuthService_RevokeApiKey_FullMethodName, params: map[string]string{"id": "id"}},
+	{pattern: "GET /v1/audit-events", method: protoc.AuthService_ListAuditEvents_FullMethodName},
+
+	{pattern: "POST /v1/laptops", method: protoc.LaptopService_CreateLaptop_FullMethodName, body: "laptop"},
+	{pattern: "GET /v1/laptops/{id}", method: protoc.LaptopService_GetLaptop_FullMethodName, params: map[string]string{"id": "id"}},
+	{pattern: "GET /v1/laptops:search", method: protoc.LaptopService_SearchLaptop_FullMethodName},
+	{pattern: "POST /v1/laptops/{laptop_id}/images", method: protoc.LaptopService_UploadImage_FullMethodName, decode: decodeImage},
+	{pattern: "POST /v1/laptops/{laptop_id}/ratings", method: protoc.LaptopService_RateLaptop_FullMethodName, params: map[string]string{"laptop_id": "laptop_id"}},
+
+	{pattern: "GET /v1/features", method: protoc.RouteGuide_ListFeatures_FullMethodName},
+	{pattern: "POST /v1/features", method: protoc.RouteGuide_CreateFeature_FullMethodName, body: "*"},
+	{pattern: "GET /v1/features/{latitude}/{longitude}", method: protoc.RouteGuide_GetFeature_FullMethodName, params: map[string]string{"latitude": "latitude", "longitude": "longitude"}},
+	{pattern: "PUT /v1/features/{latitude}/{longitude}", method: protoc.RouteGuide_UpdateFeature_FullMethodName, body: "feature", params: map[string]string{"latitude": "location.latitude", "longitude": "location.longitude"}},
+	{pattern: "DELETE /v1/features/{latitude}/{longitude}", method: protoc.RouteGuide_DeleteFeature_FullMethodName, params: map[string]string{"latitude": "latitude", "longitude": "longitude"}},
+	{pattern: "GET /v1/features:nearest", method: protoc.RouteGuide_FindNearest_FullMethodName},
+	{pattern: "GET /v1/features:within", method: protoc.RouteGuide_ListFeaturesWithinRadius_FullMethodName},
+	{pattern: "GET /v1/features:export", method: protoc.RouteGuide_ExportFeatures_FullMethodName},
+	{pattern: "POST /v1/features:import", method: protoc.RouteGuide_ImportFeatures_FullMethodName, body: "*"},
+	{pattern: "POST /v1/routes", method: protoc.RouteGuide_RecordRoute_FullMethodName},
+	{pattern: "GET /v1/routes", method: protoc.RouteGuide_ListRoutes_FullMethodName},
+	{pattern: "GET /v1/routes/{id}", method: protoc.RouteGuide_GetRoute_FullMethodName, params: map[string]string{"id": "id"}},
+	{pattern: "GET /v1/routes/{id}/geojson", method: protoc.RouteGuide_ExportRoute_FullMethodName, params: map[string]string{"id": "id"}},
+	{pattern: "POST /v1/regions:watch", method: protoc.RouteGuide_WatchRegion_FullMethodName, body: "*"},
+}
+
+// decodeImage reads the raw image body of UploadImage, its type is the image_type query parameter, e.g. .jpg.
+func decodeImage(r *http.Request) ([]proto.Message, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot read image: %v", err)
+	}
+
+	requests := []proto.Message{&protoc.UploadImageRequest{
+		Data: &protoc.UploadImageRequest_Info{Info: &protoc.ImageInfo{
+			LaptopId:  r.PathValue("laptop_id"),
+			ImageType: r.URL.Query().Get("image_type"),
+		}},
+	}}
+	for len(data) > 0 {
+		chunk := data[:min(len(data), imageChunkSize)]
+		data = data[len(chunk):]
+		requests = append(requests, &protoc.UploadImageRequest{
+			Data: &protoc.UploadImageRequest_ChunkData{ChunkData: chunk},
+		})
+	}
+	return requests, nil
+}
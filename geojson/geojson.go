@@ -0,0 +1,192 @@
+// Package geojson converts RouteGuide features and routes from and to GeoJSON (RFC 7946).
+package geojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-http-server/grpc/protoc"
+)
+
+// coordFactor converts between degrees and the E7 representation of protoc.Point.
+const coordFactor = 1e7
+
+// FeatureCollection is a GeoJSON FeatureCollection object.
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+// Feature is a GeoJSON Feature object.
+type Feature struct {
+	Type       string         `json:"type"`
+	Geometry   *Geometry      `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// Geometry is a GeoJSON Point or LineString geometry. Positions are [longitude, latitude] in degrees.
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// ToDegrees converts an E7 point to a GeoJSON [longitude, latitude] position.
+func ToDegrees(point *protoc.Point) []float64 {
+	return []float64{float64(point.GetLongitude()) / coordFactor, float64(point.GetLatitude()) / coordFactor}
+}
+
+// FromDegrees converts a GeoJSON position to an E7 point, checking the latitude and longitude ranges.
+// An altitude is ignored.
+func FromDegrees(position []float64) (*protoc.Point, error) {
+	if len(position) < 2 {
+		return nil, fmt.Errorf("position has %d coordinates, want longitude and latitude", len(position))
+	}
+
+	longitude, latitude := position[0], position[1]
+	if math.IsNaN(latitude) || latitude < -90 || latitude > 90 {
+		return nil, fmt.Errorf("latitude %v out of range [-90, 90]", latitude)
+	}
+	if math.IsNaN(longitude) || longitude < -180 || longitude > 180 {
+		return nil, fmt.Errorf("longitude %v out of range [-180, 180]", longitude)
+	}
+
+	return &protoc.Point{
+		Latitude:  int32(math.Round(latitude * coordFactor)),
+		Longitude: int32(math.Round(longitude * coordFactor)),
+	}, nil
+}
+
+// IsFeatureCollection reports whether data is a GeoJSON FeatureCollection rather than the E7 JSON array of features.
+func IsFeatureCollection(data []byte) bool {
+	var object struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal(data, &object) == nil && object.Type == "FeatureCollection"
+}
+
+// MarshalFeatures encodes features as a FeatureCollection of Points named by a "name" property.
+func MarshalFeatures(features []*protoc.Feature) ([]byte, error) {
+	collection := &FeatureCollection{Type: "FeatureCollection", Features: make([]*Feature, 0, len(features))}
+	for _, feature := range features {
+		coordinates, err := json.Marshal(ToDegrees(feature.GetLocation()))
+		if err != nil {
+			return nil, err
+		}
+
+		collection.Features = append(collection.Features, &Feature{
+			Type:       "Feature",
+			Geometry:   &Geometry{Type: "Point", Coordinates: coordinates},
+			Properties: map[string]any{"name": feature.GetName()},
+		})
+	}
+
+	return json.MarshalIndent(collection, "", "  ")
+}
+
+// UnmarshalFeatures decodes a FeatureCollection of Points, naming features after their "name" property.
+func UnmarshalFeatures(data []byte) ([]*protoc.Feature, error) {
+	var collection FeatureCollection
+	err := json.Unmarshal(data, &collection)
+	if err != nil {
+		return nil, err
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("GeoJSON object of type %q, want FeatureCollection", collection.Type)
+	}
+
+	features := make([]*protoc.Feature, 0, len(collection.Features))
+	for i, item := range collection.Features {
+		feature, err := unmarshalFeature(item)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		features = append(features, feature)
+	}
+
+	return features, nil
+}
+
+func unmarshalFeature(item *Feature) (*protoc.Feature, error) {
+	if item == nil || item.Type != "Feature" {
+		return nil, errors.New("not a GeoJSON Feature")
+	}
+	if item.Geometry == nil || item.Geometry.Type != "Point" {
+		return nil, errors.New("geometry must be a Point")
+	}
+
+	var position []float64
+	err := json.Unmarshal(item.Geometry.Coordinates, &position)
+	if err != nil {
+		return nil, fmt.Errorf("malformed Point coordinates: %w", err)
+	}
+
+	location, err := FromDegrees(position)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := item.Properties["name"].(string)
+	return &protoc.Feature{Name: name, Location: location}, nil
+}
+
+// MarshalRoute encodes a route as a LineString Feature, with the summary fields as properties when summary is set.
+func MarshalRoute(points []*protoc.Point, summary *protoc.RouteSummary) ([]byte, error) {
+	return marshalLineString(points, summaryProperties(summary))
+}
+
+// MarshalStoredRoute encodes a recorded route as a LineString Feature, with its id, owner, tenant, times and summary
+// fields as properties.
+func MarshalStoredRoute(route *protoc.Route) ([]byte, error) {
+	properties := summaryProperties(route.GetSummary())
+	properties["id"] = route.GetId()
+	properties["owner"] = route.GetOwner()
+	properties["tenant"] = route.GetTenant()
+	if route.GetStartedAt() != nil {
+		properties["started_at"] = route.GetStartedAt().AsTime().Format(time.RFC3339Nano)
+	}
+	if route.GetEndedAt() != nil {
+		properties["ended_at"] = route.GetEndedAt().AsTime().Format(time.RFC3339Nano)
+	}
+
+	return marshalLineString(route.GetPoints(), properties)
+}
+
+// summaryProperties returns the properties of the summary fields, none when summary is nil.
+func summaryProperties(summary *protoc.RouteSummary) map[string]any {
+	properties := map[string]any{}
+	if summary != nil {
+		properties["point_count"] = summary.GetPointCount()
+		properties["feature_count"] = summary.GetFeatureCount()
+		properties["distance"] = summary.GetDistance()
+		properties["elapsed_time"] = summary.GetElapsedTime()
+		properties["average_speed"] = summary.GetAverageSpeed()
+		properties["max_speed"] = summary.GetMaxSpeed()
+		properties["stop_count"] = len(summary.GetStops())
+	}
+	return properties
+}
+
+// marshalLineString encodes points as a LineString Feature with properties.
+func marshalLineString(points []*protoc.Point, properties map[string]any) ([]byte, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("a LineString needs at least 2 points, route has %d", len(points))
+	}
+
+	positions := make([][]float64, len(points))
+	for i, point := range points {
+		positions[i] = ToDegrees(point)
+	}
+	coordinates, err := json.Marshal(positions)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(&Feature{
+		Type:       "Feature",
+		Geometry:   &Geometry{Type: "LineString", Coordinates: coordinates},
+		Properties: properties,
+	}, "", "  ")
+}
@@ -0,0 +1,97 @@
+package geojson_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-http-server/grpc/geojson"
+	"github.com/go-http-server/grpc/protoc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFeatures(t *testing.T) {
+	t.Parallel()
+
+	features := []*protoc.Feature{
+		{Name: "Patriots Path, Mendham, NJ 07945, USA", Location: &protoc.Point{Latitude: 407838351, Longitude: -746143763}},
+		{Name: "", Location: &protoc.Point{Latitude: -900000000, Longitude: 1800000000}},
+	}
+
+	data, err := geojson.MarshalFeatures(features)
+	require.NoError(t, err)
+	require.True(t, geojson.IsFeatureCollection(data))
+	require.Contains(t, string(data), "-74.6143763")
+	require.Contains(t, string(data), "40.7838351")
+
+	decoded, err := geojson.UnmarshalFeatures(data)
+	require.NoError(t, err)
+	require.Len(t, decoded, len(features))
+	for i := range features {
+		require.True(t, proto.Equal(features[i], decoded[i]), i)
+	}
+
+	require.False(t, geojson.IsFeatureCollection([]byte(`[{"name": "", "location": {"latitude": 1, "longitude": 2}}]`)))
+}
+
+func TestUnmarshalFeaturesInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		geometry string
+	}{
+		{name: "latitude out of range", geometry: `{"type": "Point", "coordinates": [0, 90.5]}`},
+		{name: "longitude out of range", geometry: `{"type": "Point", "coordinates": [-180.1, 0]}`},
+		{name: "missing latitude", geometry: `{"type": "Point", "coordinates": [10]}`},
+		{name: "not a point", geometry: `{"type": "LineString", "coordinates": [[0, 0], [1, 1]]}`},
+		{name: "no geometry", geometry: `null`},
+	}
+
+	for _, currCase := range testCases {
+		t.Run(currCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": ` + currCase.geometry + `, "properties": {}}]}`
+			_, err := geojson.UnmarshalFeatures([]byte(data))
+			require.Error(t, err)
+		})
+	}
+
+	_, err := geojson.UnmarshalFeatures([]byte(`{"type": "Feature"}`))
+	require.Error(t, err)
+}
+
+func TestMarshalRoute(t *testing.T) {
+	t.Parallel()
+
+	points := []*protoc.Point{
+		{Latitude: 407838351, Longitude: -746143763},
+		{Latitude: 408122808, Longitude: -743999179},
+	}
+	data, err := geojson.MarshalRoute(points, &protoc.RouteSummary{PointCount: 2, Distance: 18224})
+	require.NoError(t, err)
+
+	var feature struct {
+		Type     string
+		Geometry struct {
+			Type        string
+			Coordinates [][]float64
+		}
+		Properties map[string]float64
+	}
+	require.NoError(t, json.Unmarshal(data, &feature))
+	require.Equal(t, "Feature", feature.Type)
+	require.Equal(t, "LineString", feature.Geometry.Type)
+	require.Equal(t, [][]float64{{-74.6143763, 40.7838351}, {-74.3999179, 40.8122808}}, feature.Geometry.Coordinates)
+	require.EqualValues(t, 18224, feature.Properties["distance"])
+
+	_, err = geojson.MarshalRoute(points[:1], nil)
+	require.Error(t, err)
+
+	data, err = geojson.MarshalStoredRoute(&protoc.Route{Id: "r1", Owner: "admin_valid", Tenant: "acme", Points: points})
+	require.NoError(t, err)
+	var stored geojson.Feature
+	require.NoError(t, json.Unmarshal(data, &stored))
+	require.Equal(t, map[string]any{"id": "r1", "owner": "admin_valid", "tenant": "acme"}, stored.Properties)
+}
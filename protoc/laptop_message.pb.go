@@ -42,6 +42,8 @@ type Laptop struct {
 	PriceUsd      float64                `protobuf:"fixed64,12,opt,name=price_usd,json=priceUsd,proto3" json:"price_usd,omitempty"`         // Price of the laptop in USD
 	ReleaseYear   uint32                 `protobuf:"varint,13,opt,name=release_year,json=releaseYear,proto3" json:"release_year,omitempty"` // Release year of the laptop
 	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`        // Updation timestamp
+	Owner         string                 `protobuf:"bytes,15,opt,name=owner,proto3" json:"owner,omitempty"`                                 // Username of the seller managing the laptop, set by the server from the caller
+	Tenant        string                 `protobuf:"bytes,16,opt,name=tenant,proto3" json:"tenant,omitempty"`                               // Organisation the laptop belongs to, set by the server from the caller
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -185,6 +187,20 @@ func (x *Laptop) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Laptop) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Laptop) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
 type isLaptop_Weight interface {
 	isLaptop_Weight()
 }
@@ -205,7 +221,7 @@ var File_laptop_laptop_message_proto protoreflect.FileDescriptor
 
 const file_laptop_laptop_message_proto_rawDesc = "" +
 	"\n" +
-	"\x1blaptop/laptop_message.proto\x1a\x1blaptop/screen_message.proto\x1a\x1dlaptop/keyboard_message.proto\x1a\x1elaptop/processor_message.proto\x1a\x1blaptop/memory_message.proto\x1a\x1claptop/storage_message.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bbuf/validate/validate.proto\"\xb5\x04\n" +
+	"\x1blaptop/laptop_message.proto\x1a\x1blaptop/screen_message.proto\x1a\x1dlaptop/keyboard_message.proto\x1a\x1elaptop/processor_message.proto\x1a\x1blaptop/memory_message.proto\x1a\x1claptop/storage_message.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bbuf/validate/validate.proto\"\xe3\x04\n" +
 	"\x06Laptop\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12@\n" +
 	"\x05brand\x18\x02 \x01(\tB*\xbaH'\xc8\x01\x01r\"\x10\x01\x182R\x04DellR\x02HPR\x06LenovoR\x04AsusR\x04AcerR\x05brand\x12 \n" +
@@ -224,7 +240,9 @@ const file_laptop_laptop_message_proto_rawDesc = "" +
 	"\frelease_year\x18\r \x01(\rB\n" +
 	"\xbaH\a\xc8\x01\x01*\x02 \x00R\vreleaseYear\x129\n" +
 	"\n" +
-	"updated_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\b\n" +
+	"updated_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x14\n" +
+	"\x05owner\x18\x0f \x01(\tR\x05owner\x12\x16\n" +
+	"\x06tenant\x18\x10 \x01(\tR\x06tenantB\b\n" +
 	"\x06weightB\tZ\a/protocb\x06proto3"
 
 var (
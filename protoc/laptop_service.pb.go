@@ -7,6 +7,7 @@
 package protoc
 
 import (
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -109,6 +110,50 @@ func (x *CreateLaptopResponse) GetId() string {
 	return ""
 }
 
+type GetLaptopRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // Unique identifier of the laptop
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLaptopRequest) Reset() {
+	*x = GetLaptopRequest{}
+	mi := &file_laptop_laptop_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLaptopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLaptopRequest) ProtoMessage() {}
+
+func (x *GetLaptopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_laptop_laptop_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLaptopRequest.ProtoReflect.Descriptor instead.
+func (*GetLaptopRequest) Descriptor() ([]byte, []int) {
+	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetLaptopRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
 type SearchLaptopRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Filter        *Filter                `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"` // Filter criteria for searching laptops
@@ -118,7 +163,7 @@ type SearchLaptopRequest struct {
 
 func (x *SearchLaptopRequest) Reset() {
 	*x = SearchLaptopRequest{}
-	mi := &file_laptop_laptop_service_proto_msgTypes[2]
+	mi := &file_laptop_laptop_service_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -130,7 +175,7 @@ func (x *SearchLaptopRequest) String() string {
 func (*SearchLaptopRequest) ProtoMessage() {}
 
 func (x *SearchLaptopRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_laptop_laptop_service_proto_msgTypes[2]
+	mi := &file_laptop_laptop_service_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -143,7 +188,7 @@ func (x *SearchLaptopRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchLaptopRequest.ProtoReflect.Descriptor instead.
 func (*SearchLaptopRequest) Descriptor() ([]byte, []int) {
-	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{2}
+	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *SearchLaptopRequest) GetFilter() *Filter {
@@ -162,7 +207,7 @@ type SearchLaptopResponse struct {
 
 func (x *SearchLaptopResponse) Reset() {
 	*x = SearchLaptopResponse{}
-	mi := &file_laptop_laptop_service_proto_msgTypes[3]
+	mi := &file_laptop_laptop_service_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -174,7 +219,7 @@ func (x *SearchLaptopResponse) String() string {
 func (*SearchLaptopResponse) ProtoMessage() {}
 
 func (x *SearchLaptopResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_laptop_laptop_service_proto_msgTypes[3]
+	mi := &file_laptop_laptop_service_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -187,7 +232,7 @@ func (x *SearchLaptopResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchLaptopResponse.ProtoReflect.Descriptor instead.
 func (*SearchLaptopResponse) Descriptor() ([]byte, []int) {
-	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{3}
+	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *SearchLaptopResponse) GetLaptop() *Laptop {
@@ -210,7 +255,7 @@ type UploadImageRequest struct {
 
 func (x *UploadImageRequest) Reset() {
 	*x = UploadImageRequest{}
-	mi := &file_laptop_laptop_service_proto_msgTypes[4]
+	mi := &file_laptop_laptop_service_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -222,7 +267,7 @@ func (x *UploadImageRequest) String() string {
 func (*UploadImageRequest) ProtoMessage() {}
 
 func (x *UploadImageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_laptop_laptop_service_proto_msgTypes[4]
+	mi := &file_laptop_laptop_service_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -235,7 +280,7 @@ func (x *UploadImageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UploadImageRequest.ProtoReflect.Descriptor instead.
 func (*UploadImageRequest) Descriptor() ([]byte, []int) {
-	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{4}
+	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *UploadImageRequest) GetData() isUploadImageRequest_Data {
@@ -289,7 +334,7 @@ type ImageInfo struct {
 
 func (x *ImageInfo) Reset() {
 	*x = ImageInfo{}
-	mi := &file_laptop_laptop_service_proto_msgTypes[5]
+	mi := &file_laptop_laptop_service_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -301,7 +346,7 @@ func (x *ImageInfo) String() string {
 func (*ImageInfo) ProtoMessage() {}
 
 func (x *ImageInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_laptop_laptop_service_proto_msgTypes[5]
+	mi := &file_laptop_laptop_service_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -314,7 +359,7 @@ func (x *ImageInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ImageInfo.ProtoReflect.Descriptor instead.
 func (*ImageInfo) Descriptor() ([]byte, []int) {
-	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{5}
+	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ImageInfo) GetLaptopId() string {
@@ -341,7 +386,7 @@ type UploadImageResponse struct {
 
 func (x *UploadImageResponse) Reset() {
 	*x = UploadImageResponse{}
-	mi := &file_laptop_laptop_service_proto_msgTypes[6]
+	mi := &file_laptop_laptop_service_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -353,7 +398,7 @@ func (x *UploadImageResponse) String() string {
 func (*UploadImageResponse) ProtoMessage() {}
 
 func (x *UploadImageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_laptop_laptop_service_proto_msgTypes[6]
+	mi := &file_laptop_laptop_service_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -366,7 +411,7 @@ func (x *UploadImageResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UploadImageResponse.ProtoReflect.Descriptor instead.
 func (*UploadImageResponse) Descriptor() ([]byte, []int) {
-	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{6}
+	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *UploadImageResponse) GetId() string {
@@ -393,7 +438,7 @@ type RateLaptopRequest struct {
 
 func (x *RateLaptopRequest) Reset() {
 	*x = RateLaptopRequest{}
-	mi := &file_laptop_laptop_service_proto_msgTypes[7]
+	mi := &file_laptop_laptop_service_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -405,7 +450,7 @@ func (x *RateLaptopRequest) String() string {
 func (*RateLaptopRequest) ProtoMessage() {}
 
 func (x *RateLaptopRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_laptop_laptop_service_proto_msgTypes[7]
+	mi := &file_laptop_laptop_service_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -418,7 +463,7 @@ func (x *RateLaptopRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RateLaptopRequest.ProtoReflect.Descriptor instead.
 func (*RateLaptopRequest) Descriptor() ([]byte, []int) {
-	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{7}
+	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *RateLaptopRequest) GetLaptopId() string {
@@ -446,7 +491,7 @@ type RateLaptopResponse struct {
 
 func (x *RateLaptopResponse) Reset() {
 	*x = RateLaptopResponse{}
-	mi := &file_laptop_laptop_service_proto_msgTypes[8]
+	mi := &file_laptop_laptop_service_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -458,7 +503,7 @@ func (x *RateLaptopResponse) String() string {
 func (*RateLaptopResponse) ProtoMessage() {}
 
 func (x *RateLaptopResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_laptop_laptop_service_proto_msgTypes[8]
+	mi := &file_laptop_laptop_service_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -471,7 +516,7 @@ func (x *RateLaptopResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RateLaptopResponse.ProtoReflect.Descriptor instead.
 func (*RateLaptopResponse) Descriptor() ([]byte, []int) {
-	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{8}
+	return file_laptop_laptop_service_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *RateLaptopResponse) GetLaptopId() string {
@@ -499,11 +544,13 @@ var File_laptop_laptop_service_proto protoreflect.FileDescriptor
 
 const file_laptop_laptop_service_proto_rawDesc = "" +
 	"\n" +
-	"\x1blaptop/laptop_service.proto\x1a\x1blaptop/laptop_message.proto\x1a\x1blaptop/filter_message.proto\"6\n" +
+	"\x1blaptop/laptop_service.proto\x1a\x1bbuf/validate/validate.proto\x1a\x1blaptop/laptop_message.proto\x1a\x1blaptop/filter_message.proto\"6\n" +
 	"\x13CreateLaptopRequest\x12\x1f\n" +
 	"\x06laptop\x18\x01 \x01(\v2\a.LaptopR\x06laptop\"&\n" +
 	"\x14CreateLaptopResponse\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"6\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"*\n" +
+	"\x10GetLaptopRequest\x12\x16\n" +
+	"\x02id\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x02id\"6\n" +
 	"\x13SearchLaptopRequest\x12\x1f\n" +
 	"\x06filter\x18\x01 \x01(\v2\a.FilterR\x06filter\"7\n" +
 	"\x14SearchLaptopResponse\x12\x1f\n" +
@@ -528,9 +575,10 @@ const file_laptop_laptop_service_proto_rawDesc = "" +
 	"\tlaptop_id\x18\x01 \x01(\tR\blaptopId\x12\x1f\n" +
 	"\vrated_count\x18\x02 \x01(\rR\n" +
 	"ratedCount\x12#\n" +
-	"\raverage_score\x18\x03 \x01(\x01R\faverageScore2\x82\x02\n" +
+	"\raverage_score\x18\x03 \x01(\x01R\faverageScore2\xab\x02\n" +
 	"\rLaptopService\x12;\n" +
-	"\fCreateLaptop\x12\x14.CreateLaptopRequest\x1a\x15.CreateLaptopResponse\x12=\n" +
+	"\fCreateLaptop\x12\x14.CreateLaptopRequest\x1a\x15.CreateLaptopResponse\x12'\n" +
+	"\tGetLaptop\x12\x11.GetLaptopRequest\x1a\a.Laptop\x12=\n" +
 	"\fSearchLaptop\x12\x14.SearchLaptopRequest\x1a\x15.SearchLaptopResponse0\x01\x12:\n" +
 	"\vUploadImage\x12\x13.UploadImageRequest\x1a\x14.UploadImageResponse(\x01\x129\n" +
 	"\n" +
@@ -548,35 +596,38 @@ func file_laptop_laptop_service_proto_rawDescGZIP() []byte {
 	return file_laptop_laptop_service_proto_rawDescData
 }
 
-var file_laptop_laptop_service_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_laptop_laptop_service_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_laptop_laptop_service_proto_goTypes = []any{
 	(*CreateLaptopRequest)(nil),  // 0: CreateLaptopRequest
 	(*CreateLaptopResponse)(nil), // 1: CreateLaptopResponse
-	(*SearchLaptopRequest)(nil),  // 2: SearchLaptopRequest
-	(*SearchLaptopResponse)(nil), // 3: SearchLaptopResponse
-	(*UploadImageRequest)(nil),   // 4: UploadImageRequest
-	(*ImageInfo)(nil),            // 5: ImageInfo
-	(*UploadImageResponse)(nil),  // 6: UploadImageResponse
-	(*RateLaptopRequest)(nil),    // 7: RateLaptopRequest
-	(*RateLaptopResponse)(nil),   // 8: RateLaptopResponse
-	(*Laptop)(nil),               // 9: Laptop
-	(*Filter)(nil),               // 10: Filter
+	(*GetLaptopRequest)(nil),     // 2: GetLaptopRequest
+	(*SearchLaptopRequest)(nil),  // 3: SearchLaptopRequest
+	(*SearchLaptopResponse)(nil), // 4: SearchLaptopResponse
+	(*UploadImageRequest)(nil),   // 5: UploadImageRequest
+	(*ImageInfo)(nil),            // 6: ImageInfo
+	(*UploadImageResponse)(nil),  // 7: UploadImageResponse
+	(*RateLaptopRequest)(nil),    // 8: RateLaptopRequest
+	(*RateLaptopResponse)(nil),   // 9: RateLaptopResponse
+	(*Laptop)(nil),               // 10: Laptop
+	(*Filter)(nil),               // 11: Filter
 }
 var file_laptop_laptop_service_proto_depIdxs = []int32{
-	9,  // 0: CreateLaptopRequest.laptop:type_name -> Laptop
-	10, // 1: SearchLaptopRequest.filter:type_name -> Filter
-	9,  // 2: SearchLaptopResponse.laptop:type_name -> Laptop
-	5,  // 3: UploadImageRequest.info:type_name -> ImageInfo
+	10, // 0: CreateLaptopRequest.laptop:type_name -> Laptop
+	11, // 1: SearchLaptopRequest.filter:type_name -> Filter
+	10, // 2: SearchLaptopResponse.laptop:type_name -> Laptop
+	6,  // 3: UploadImageRequest.info:type_name -> ImageInfo
 	0,  // 4: LaptopService.CreateLaptop:input_type -> CreateLaptopRequest
-	2,  // 5: LaptopService.SearchLaptop:input_type -> SearchLaptopRequest
-	4,  // 6: LaptopService.UploadImage:input_type -> UploadImageRequest
-	7,  // 7: LaptopService.RateLaptop:input_type -> RateLaptopRequest
-	1,  // 8: LaptopService.CreateLaptop:output_type -> CreateLaptopResponse
-	3,  // 9: LaptopService.SearchLaptop:output_type -> SearchLaptopResponse
-	6,  // 10: LaptopService.UploadImage:output_type -> UploadImageResponse
-	8,  // 11: LaptopService.RateLaptop:output_type -> RateLaptopResponse
-	8,  // [8:12] is the sub-list for method output_type
-	4,  // [4:8] is the sub-list for method input_type
+	2,  // 5: LaptopService.GetLaptop:input_type -> GetLaptopRequest
+	3,  // 6: LaptopService.SearchLaptop:input_type -> SearchLaptopRequest
+	5,  // 7: LaptopService.UploadImage:input_type -> UploadImageRequest
+	8,  // 8: LaptopService.RateLaptop:input_type -> RateLaptopRequest
+	1,  // 9: LaptopService.CreateLaptop:output_type -> CreateLaptopResponse
+	10, // 10: LaptopService.GetLaptop:output_type -> Laptop
+	4,  // 11: LaptopService.SearchLaptop:output_type -> SearchLaptopResponse
+	7,  // 12: LaptopService.UploadImage:output_type -> UploadImageResponse
+	9,  // 13: LaptopService.RateLaptop:output_type -> RateLaptopResponse
+	9,  // [9:14] is the sub-list for method output_type
+	4,  // [4:9] is the sub-list for method input_type
 	4,  // [4:4] is the sub-list for extension type_name
 	4,  // [4:4] is the sub-list for extension extendee
 	0,  // [0:4] is the sub-list for field type_name
@@ -589,7 +640,7 @@ func file_laptop_laptop_service_proto_init() {
 	}
 	file_laptop_laptop_message_proto_init()
 	file_laptop_filter_message_proto_init()
-	file_laptop_laptop_service_proto_msgTypes[4].OneofWrappers = []any{
+	file_laptop_laptop_service_proto_msgTypes[5].OneofWrappers = []any{
 		(*UploadImageRequest_Info)(nil),
 		(*UploadImageRequest_ChunkData)(nil),
 	}
@@ -599,7 +650,7 @@ func file_laptop_laptop_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_laptop_laptop_service_proto_rawDesc), len(file_laptop_laptop_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
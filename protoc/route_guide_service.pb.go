@@ -7,8 +7,10 @@
 package protoc
 
 import (
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,14 +23,64 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type RegionEvent_Kind int32
+
+const (
+	RegionEvent_UNKNOWN RegionEvent_Kind = 0
+	RegionEvent_ENTER   RegionEvent_Kind = 1
+	RegionEvent_LEAVE   RegionEvent_Kind = 2
+)
+
+// Enum value maps for RegionEvent_Kind.
+var (
+	RegionEvent_Kind_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "ENTER",
+		2: "LEAVE",
+	}
+	RegionEvent_Kind_value = map[string]int32{
+		"UNKNOWN": 0,
+		"ENTER":   1,
+		"LEAVE":   2,
+	}
+)
+
+func (x RegionEvent_Kind) Enum() *RegionEvent_Kind {
+	p := new(RegionEvent_Kind)
+	*p = x
+	return p
+}
+
+func (x RegionEvent_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RegionEvent_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_route_guide_route_guide_service_proto_enumTypes[0].Descriptor()
+}
+
+func (RegionEvent_Kind) Type() protoreflect.EnumType {
+	return &file_route_guide_route_guide_service_proto_enumTypes[0]
+}
+
+func (x RegionEvent_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RegionEvent_Kind.Descriptor instead.
+func (RegionEvent_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{11, 0}
+}
+
 // Points are represented as latitude-longitude pairs in the E7 representation
 // (degrees multiplied by 10**7 and rounded to the nearest integer).
 // Latitudes should be in the range +/- 90 degrees and longitude should be in
 // the range +/- 180 degrees (inclusive).
 type Point struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Latitude      int32                  `protobuf:"varint,1,opt,name=latitude,proto3" json:"latitude,omitempty"`   // Latitude of the point
-	Longitude     int32                  `protobuf:"varint,2,opt,name=longitude,proto3" json:"longitude,omitempty"` // Longitude of the point
+	Latitude      int32                  `protobuf:"varint,1,opt,name=latitude,proto3" json:"latitude,omitempty"`                      // Latitude of the point
+	Longitude     int32                  `protobuf:"varint,2,opt,name=longitude,proto3" json:"longitude,omitempty"`                    // Longitude of the point
+	RecordedAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=recorded_at,json=recordedAt,proto3" json:"recorded_at,omitempty"` // When the point was reached, optional and only read by RecordRoute
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -77,6 +129,13 @@ func (x *Point) GetLongitude() int32 {
 	return 0
 }
 
+func (x *Point) GetRecordedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RecordedAt
+	}
+	return nil
+}
+
 // A feature names something at a given point.
 //
 // If a feature could not be named, the name is empty.
@@ -190,6 +249,53 @@ func (x *Rectangle) GetHi() *Point {
 	return nil
 }
 
+// A Polygon is a closed ring of vertices, the last vertex is joined to the
+// first. Edges are straight lines in latitude-longitude space, so a polygon
+// must not cross the antimeridian.
+type Polygon struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Vertices      []*Point               `protobuf:"bytes,1,rep,name=vertices,proto3" json:"vertices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Polygon) Reset() {
+	*x = Polygon{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Polygon) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Polygon) ProtoMessage() {}
+
+func (x *Polygon) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Polygon.ProtoReflect.Descriptor instead.
+func (*Polygon) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Polygon) GetVertices() []*Point {
+	if x != nil {
+		return x.Vertices
+	}
+	return nil
+}
+
 // A RouteSummary is received in response to a RecordRoute rpc.
 //
 // It contains the number of individual points received, the number of
@@ -203,15 +309,27 @@ type RouteSummary struct {
 	FeatureCount int32 `protobuf:"varint,2,opt,name=feature_count,json=featureCount,proto3" json:"feature_count,omitempty"`
 	// The distance covered in metres.
 	Distance int32 `protobuf:"varint,3,opt,name=distance,proto3" json:"distance,omitempty"`
-	// The duration of the traversal in seconds.
-	ElapsedTime   int32 `protobuf:"varint,4,opt,name=elapsed_time,json=elapsedTime,proto3" json:"elapsed_time,omitempty"`
+	// The duration of the traversal in seconds, between the first and last
+	// point timestamps when the client sets them, or else the stream duration.
+	ElapsedTime int32 `protobuf:"varint,4,opt,name=elapsed_time,json=elapsedTime,proto3" json:"elapsed_time,omitempty"`
+	// The id of the stored route, empty when no point was received.
+	RouteId string `protobuf:"bytes,5,opt,name=route_id,json=routeId,proto3" json:"route_id,omitempty"`
+	// The average and maximum speeds in metres per second, measured between
+	// consecutive points the client set timestamps on; 0 without timestamps.
+	AverageSpeed float64 `protobuf:"fixed64,6,opt,name=average_speed,json=averageSpeed,proto3" json:"average_speed,omitempty"`
+	MaxSpeed     float64 `protobuf:"fixed64,7,opt,name=max_speed,json=maxSpeed,proto3" json:"max_speed,omitempty"`
+	// The places the route stayed at, detected from the point timestamps.
+	Stops []*Stop `protobuf:"bytes,8,rep,name=stops,proto3" json:"stops,omitempty"`
+	// The route simplified with the Douglas-Peucker algorithm, when the client
+	// requests a tolerance in metres with the x-simplify-tolerance header.
+	Simplified    []*Point `protobuf:"bytes,9,rep,name=simplified,proto3" json:"simplified,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RouteSummary) Reset() {
 	*x = RouteSummary{}
-	mi := &file_route_guide_route_guide_service_proto_msgTypes[3]
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -223,7 +341,7 @@ func (x *RouteSummary) String() string {
 func (*RouteSummary) ProtoMessage() {}
 
 func (x *RouteSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_route_guide_route_guide_service_proto_msgTypes[3]
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -236,7 +354,7 @@ func (x *RouteSummary) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RouteSummary.ProtoReflect.Descriptor instead.
 func (*RouteSummary) Descriptor() ([]byte, []int) {
-	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{3}
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *RouteSummary) GetPointCount() int32 {
@@ -267,32 +385,69 @@ func (x *RouteSummary) GetElapsedTime() int32 {
 	return 0
 }
 
-// A RouteNote is a message sent while at a given point.
-type RouteNote struct {
+func (x *RouteSummary) GetRouteId() string {
+	if x != nil {
+		return x.RouteId
+	}
+	return ""
+}
+
+func (x *RouteSummary) GetAverageSpeed() float64 {
+	if x != nil {
+		return x.AverageSpeed
+	}
+	return 0
+}
+
+func (x *RouteSummary) GetMaxSpeed() float64 {
+	if x != nil {
+		return x.MaxSpeed
+	}
+	return 0
+}
+
+func (x *RouteSummary) GetStops() []*Stop {
+	if x != nil {
+		return x.Stops
+	}
+	return nil
+}
+
+func (x *RouteSummary) GetSimplified() []*Point {
+	if x != nil {
+		return x.Simplified
+	}
+	return nil
+}
+
+// A Stop is a place a route stayed within a small radius of for a while.
+type Stop struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// The location from which the message is sent.
-	Location *Point `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
-	// The message to be sent.
-	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// The first point of the stop.
+	Location  *Point                 `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	StartedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	EndedAt   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=ended_at,json=endedAt,proto3" json:"ended_at,omitempty"`
+	// The duration of the stop in seconds.
+	Duration      int32 `protobuf:"varint,4,opt,name=duration,proto3" json:"duration,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RouteNote) Reset() {
-	*x = RouteNote{}
-	mi := &file_route_guide_route_guide_service_proto_msgTypes[4]
+func (x *Stop) Reset() {
+	*x = Stop{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RouteNote) String() string {
+func (x *Stop) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RouteNote) ProtoMessage() {}
+func (*Stop) ProtoMessage() {}
 
-func (x *RouteNote) ProtoReflect() protoreflect.Message {
-	mi := &file_route_guide_route_guide_service_proto_msgTypes[4]
+func (x *Stop) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -303,116 +458,1199 @@ func (x *RouteNote) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RouteNote.ProtoReflect.Descriptor instead.
-func (*RouteNote) Descriptor() ([]byte, []int) {
-	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use Stop.ProtoReflect.Descriptor instead.
+func (*Stop) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *RouteNote) GetLocation() *Point {
+func (x *Stop) GetLocation() *Point {
 	if x != nil {
 		return x.Location
 	}
 	return nil
 }
 
-func (x *RouteNote) GetMessage() string {
+func (x *Stop) GetStartedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Message
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *Stop) GetEndedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndedAt
+	}
+	return nil
+}
+
+func (x *Stop) GetDuration() int32 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+// A Route is a route recorded by RecordRoute for its caller.
+type Route struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Owner  string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"` // Username of the caller who recorded the route
+	Tenant string                 `protobuf:"bytes,3,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	// The points received, with the timestamps the client set.
+	Points []*Point `protobuf:"bytes,4,rep,name=points,proto3" json:"points,omitempty"`
+	// The summary returned to the client.
+	Summary *RouteSummary `protobuf:"bytes,5,opt,name=summary,proto3" json:"summary,omitempty"`
+	// The known features passed, in route order.
+	Features []*Feature `protobuf:"bytes,6,rep,name=features,proto3" json:"features,omitempty"`
+	// When the traversal started and ended, from the point timestamps when the
+	// client sets them, or else when the stream started and ended.
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	EndedAt       *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=ended_at,json=endedAt,proto3" json:"ended_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Route) Reset() {
+	*x = Route{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Route) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Route) ProtoMessage() {}
+
+func (x *Route) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Route.ProtoReflect.Descriptor instead.
+func (*Route) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Route) GetId() string {
+	if x != nil {
+		return x.Id
 	}
 	return ""
 }
 
-var File_route_guide_route_guide_service_proto protoreflect.FileDescriptor
+func (x *Route) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
 
-const file_route_guide_route_guide_service_proto_rawDesc = "" +
-	"\n" +
-	"%route_guide/route_guide_service.proto\"A\n" +
-	"\x05Point\x12\x1a\n" +
-	"\blatitude\x18\x01 \x01(\x05R\blatitude\x12\x1c\n" +
-	"\tlongitude\x18\x02 \x01(\x05R\tlongitude\"A\n" +
-	"\aFeature\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\"\n" +
-	"\blocation\x18\x02 \x01(\v2\x06.PointR\blocation\";\n" +
-	"\tRectangle\x12\x16\n" +
-	"\x02lo\x18\x01 \x01(\v2\x06.PointR\x02lo\x12\x16\n" +
-	"\x02hi\x18\x02 \x01(\v2\x06.PointR\x02hi\"\x93\x01\n" +
-	"\fRouteSummary\x12\x1f\n" +
-	"\vpoint_count\x18\x01 \x01(\x05R\n" +
-	"pointCount\x12#\n" +
-	"\rfeature_count\x18\x02 \x01(\x05R\ffeatureCount\x12\x1a\n" +
-	"\bdistance\x18\x03 \x01(\x05R\bdistance\x12!\n" +
-	"\felapsed_time\x18\x04 \x01(\x05R\velapsedTime\"I\n" +
-	"\tRouteNote\x12\"\n" +
-	"\blocation\x18\x01 \x01(\v2\x06.PointR\blocation\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\xab\x01\n" +
-	"\n" +
-	"RouteGuide\x12\x1e\n" +
-	"\n" +
-	"GetFeature\x12\x06.Point\x1a\b.Feature\x12(\n" +
-	"\fListFeatures\x12\n" +
-	".Rectangle\x1a\b.Feature\"\x000\x01\x12(\n" +
-	"\vRecordRoute\x12\x06.Point\x1a\r.RouteSummary\"\x00(\x01\x12)\n" +
-	"\tRouteChat\x12\n" +
-	".RouteNote\x1a\n" +
-	".RouteNote\"\x00(\x010\x01B\tZ\a/protocb\x06proto3"
+func (x *Route) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
 
-var (
-	file_route_guide_route_guide_service_proto_rawDescOnce sync.Once
-	file_route_guide_route_guide_service_proto_rawDescData []byte
-)
+func (x *Route) GetPoints() []*Point {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
 
-func file_route_guide_route_guide_service_proto_rawDescGZIP() []byte {
-	file_route_guide_route_guide_service_proto_rawDescOnce.Do(func() {
-		file_route_guide_route_guide_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_route_guide_route_guide_service_proto_rawDesc), len(file_route_guide_route_guide_service_proto_rawDesc)))
-	})
-	return file_route_guide_route_guide_service_proto_rawDescData
+func (x *Route) GetSummary() *RouteSummary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
 }
 
-var file_route_guide_route_guide_service_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
-var file_route_guide_route_guide_service_proto_goTypes = []any{
-	(*Point)(nil),        // 0: Point
-	(*Feature)(nil),      // 1: Feature
-	(*Rectangle)(nil),    // 2: Rectangle
-	(*RouteSummary)(nil), // 3: RouteSummary
-	(*RouteNote)(nil),    // 4: RouteNote
+func (x *Route) GetFeatures() []*Feature {
+	if x != nil {
+		return x.Features
+	}
+	return nil
 }
-var file_route_guide_route_guide_service_proto_depIdxs = []int32{
-	0, // 0: Feature.location:type_name -> Point
-	0, // 1: Rectangle.lo:type_name -> Point
-	0, // 2: Rectangle.hi:type_name -> Point
-	0, // 3: RouteNote.location:type_name -> Point
-	0, // 4: RouteGuide.GetFeature:input_type -> Point
-	2, // 5: RouteGuide.ListFeatures:input_type -> Rectangle
-	0, // 6: RouteGuide.RecordRoute:input_type -> Point
-	4, // 7: RouteGuide.RouteChat:input_type -> RouteNote
-	1, // 8: RouteGuide.GetFeature:output_type -> Feature
-	1, // 9: RouteGuide.ListFeatures:output_type -> Feature
-	3, // 10: RouteGuide.RecordRoute:output_type -> RouteSummary
-	4, // 11: RouteGuide.RouteChat:output_type -> RouteNote
-	8, // [8:12] is the sub-list for method output_type
-	4, // [4:8] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+
+func (x *Route) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
 }
 
-func init() { file_route_guide_route_guide_service_proto_init() }
-func file_route_guide_route_guide_service_proto_init() {
-	if File_route_guide_route_guide_service_proto != nil {
-		return
+func (x *Route) GetEndedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndedAt
+	}
+	return nil
+}
+
+// A ListRoutesRequest filters the routes of the caller on their start time.
+type ListRoutesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"` // inclusive, unset for no lower bound
+	To            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`     // exclusive, unset for no upper bound
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoutesRequest) Reset() {
+	*x = ListRoutesRequest{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoutesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoutesRequest) ProtoMessage() {}
+
+func (x *ListRoutesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoutesRequest.ProtoReflect.Descriptor instead.
+func (*ListRoutesRequest) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListRoutesRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ListRoutesRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+// A ListRoutesResponse lists routes without their points, oldest first.
+type ListRoutesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Routes        []*Route               `protobuf:"bytes,1,rep,name=routes,proto3" json:"routes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoutesResponse) Reset() {
+	*x = ListRoutesResponse{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoutesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoutesResponse) ProtoMessage() {}
+
+func (x *ListRoutesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoutesResponse.ProtoReflect.Descriptor instead.
+func (*ListRoutesResponse) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListRoutesResponse) GetRoutes() []*Route {
+	if x != nil {
+		return x.Routes
+	}
+	return nil
+}
+
+type GetRouteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRouteRequest) Reset() {
+	*x = GetRouteRequest{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRouteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRouteRequest) ProtoMessage() {}
+
+func (x *GetRouteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRouteRequest.ProtoReflect.Descriptor instead.
+func (*GetRouteRequest) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetRouteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// A WatchRegionRequest selects the region whose boundary crossings are
+// streamed back, borders belong to the region.
+type WatchRegionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Region:
+	//
+	//	*WatchRegionRequest_Rectangle
+	//	*WatchRegionRequest_Polygon
+	Region        isWatchRegionRequest_Region `protobuf_oneof:"region"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRegionRequest) Reset() {
+	*x = WatchRegionRequest{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRegionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRegionRequest) ProtoMessage() {}
+
+func (x *WatchRegionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRegionRequest.ProtoReflect.Descriptor instead.
+func (*WatchRegionRequest) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *WatchRegionRequest) GetRegion() isWatchRegionRequest_Region {
+	if x != nil {
+		return x.Region
+	}
+	return nil
+}
+
+func (x *WatchRegionRequest) GetRectangle() *Rectangle {
+	if x != nil {
+		if x, ok := x.Region.(*WatchRegionRequest_Rectangle); ok {
+			return x.Rectangle
+		}
+	}
+	return nil
+}
+
+func (x *WatchRegionRequest) GetPolygon() *Polygon {
+	if x != nil {
+		if x, ok := x.Region.(*WatchRegionRequest_Polygon); ok {
+			return x.Polygon
+		}
+	}
+	return nil
+}
+
+type isWatchRegionRequest_Region interface {
+	isWatchRegionRequest_Region()
+}
+
+type WatchRegionRequest_Rectangle struct {
+	Rectangle *Rectangle `protobuf:"bytes,1,opt,name=rectangle,proto3,oneof"`
+}
+
+type WatchRegionRequest_Polygon struct {
+	Polygon *Polygon `protobuf:"bytes,2,opt,name=polygon,proto3,oneof"`
+}
+
+func (*WatchRegionRequest_Rectangle) isWatchRegionRequest_Region() {}
+
+func (*WatchRegionRequest_Polygon) isWatchRegionRequest_Region() {}
+
+// A RegionEvent reports a RecordRoute point entering or leaving a watched
+// region. The first point of a route seen inside the region enters it.
+type RegionEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          RegionEvent_Kind       `protobuf:"varint,1,opt,name=kind,proto3,enum=RegionEvent_Kind" json:"kind,omitempty"`
+	RouteId       string                 `protobuf:"bytes,2,opt,name=route_id,json=routeId,proto3" json:"route_id,omitempty"`          // The route being recorded, see RouteSummary.route_id
+	Owner         string                 `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`                             // Username of the caller recording the route
+	Point         *Point                 `protobuf:"bytes,4,opt,name=point,proto3" json:"point,omitempty"`                             // The first point inside, or outside, the region
+	ReceivedAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"` // When the server received the point
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegionEvent) Reset() {
+	*x = RegionEvent{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegionEvent) ProtoMessage() {}
+
+func (x *RegionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegionEvent.ProtoReflect.Descriptor instead.
+func (*RegionEvent) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RegionEvent) GetKind() RegionEvent_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return RegionEvent_UNKNOWN
+}
+
+func (x *RegionEvent) GetRouteId() string {
+	if x != nil {
+		return x.RouteId
+	}
+	return ""
+}
+
+func (x *RegionEvent) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *RegionEvent) GetPoint() *Point {
+	if x != nil {
+		return x.Point
+	}
+	return nil
+}
+
+func (x *RegionEvent) GetReceivedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return nil
+}
+
+// A RouteNote is a message sent while at a given point. Sending a note
+// subscribes the sender to the notes of its location, a note without message
+// only subscribes to the location, and a note with an area subscribes to
+// every location inside the rectangle.
+type RouteNote struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The location from which the message is sent.
+	Location *Point `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	// The message to be sent.
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// The rectangle to subscribe to, the location and message are then ignored.
+	Area *Rectangle `protobuf:"bytes,3,opt,name=area,proto3" json:"area,omitempty"`
+	// The time the server received the note, set on the notes it relays.
+	SentAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`
+	// The user who sent the note, set on the notes the server relays.
+	Sender        string `protobuf:"bytes,5,opt,name=sender,proto3" json:"sender,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RouteNote) Reset() {
+	*x = RouteNote{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteNote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteNote) ProtoMessage() {}
+
+func (x *RouteNote) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteNote.ProtoReflect.Descriptor instead.
+func (*RouteNote) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RouteNote) GetLocation() *Point {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *RouteNote) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RouteNote) GetArea() *Rectangle {
+	if x != nil {
+		return x.Area
+	}
+	return nil
+}
+
+func (x *RouteNote) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+func (x *RouteNote) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+// An UpdateFeatureRequest replaces the feature at a location.
+type UpdateFeatureRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The current location of the feature.
+	Location *Point `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	// The new feature, which may be located elsewhere.
+	Feature       *Feature `protobuf:"bytes,2,opt,name=feature,proto3" json:"feature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateFeatureRequest) Reset() {
+	*x = UpdateFeatureRequest{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateFeatureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateFeatureRequest) ProtoMessage() {}
+
+func (x *UpdateFeatureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateFeatureRequest.ProtoReflect.Descriptor instead.
+func (*UpdateFeatureRequest) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UpdateFeatureRequest) GetLocation() *Point {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *UpdateFeatureRequest) GetFeature() *Feature {
+	if x != nil {
+		return x.Feature
+	}
+	return nil
+}
+
+// A GeoJson carries a GeoJSON (RFC 7946) document.
+type GeoJson struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The GeoJSON text, coordinates in degrees.
+	Data          string `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeoJson) Reset() {
+	*x = GeoJson{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeoJson) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeoJson) ProtoMessage() {}
+
+func (x *GeoJson) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeoJson.ProtoReflect.Descriptor instead.
+func (*GeoJson) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GeoJson) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+// An ExportFeaturesRequest selects the features to export.
+type ExportFeaturesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The area to export, unset to export every feature.
+	Area          *Rectangle `protobuf:"bytes,1,opt,name=area,proto3" json:"area,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportFeaturesRequest) Reset() {
+	*x = ExportFeaturesRequest{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportFeaturesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportFeaturesRequest) ProtoMessage() {}
+
+func (x *ExportFeaturesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportFeaturesRequest.ProtoReflect.Descriptor instead.
+func (*ExportFeaturesRequest) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ExportFeaturesRequest) GetArea() *Rectangle {
+	if x != nil {
+		return x.Area
+	}
+	return nil
+}
+
+// An ImportFeaturesResponse counts the features an import created and replaced.
+type ImportFeaturesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Created       int32                  `protobuf:"varint,1,opt,name=created,proto3" json:"created,omitempty"`
+	Updated       int32                  `protobuf:"varint,2,opt,name=updated,proto3" json:"updated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportFeaturesResponse) Reset() {
+	*x = ImportFeaturesResponse{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportFeaturesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportFeaturesResponse) ProtoMessage() {}
+
+func (x *ImportFeaturesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportFeaturesResponse.ProtoReflect.Descriptor instead.
+func (*ImportFeaturesResponse) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ImportFeaturesResponse) GetCreated() int32 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *ImportFeaturesResponse) GetUpdated() int32 {
+	if x != nil {
+		return x.Updated
+	}
+	return 0
+}
+
+// A FindNearestRequest asks for the k features closest to a location.
+type FindNearestRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The location to measure distances from.
+	Location *Point `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	// The maximum number of features returned.
+	K             int32 `protobuf:"varint,2,opt,name=k,proto3" json:"k,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindNearestRequest) Reset() {
+	*x = FindNearestRequest{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindNearestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindNearestRequest) ProtoMessage() {}
+
+func (x *FindNearestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindNearestRequest.ProtoReflect.Descriptor instead.
+func (*FindNearestRequest) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *FindNearestRequest) GetLocation() *Point {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *FindNearestRequest) GetK() int32 {
+	if x != nil {
+		return x.K
+	}
+	return 0
+}
+
+// A RadiusRequest asks for the features within a distance of a location.
+type RadiusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The location to measure distances from.
+	Location *Point `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	// The radius in metres, borders included.
+	Radius        int32 `protobuf:"varint,2,opt,name=radius,proto3" json:"radius,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RadiusRequest) Reset() {
+	*x = RadiusRequest{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RadiusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RadiusRequest) ProtoMessage() {}
+
+func (x *RadiusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RadiusRequest.ProtoReflect.Descriptor instead.
+func (*RadiusRequest) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RadiusRequest) GetLocation() *Point {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *RadiusRequest) GetRadius() int32 {
+	if x != nil {
+		return x.Radius
+	}
+	return 0
+}
+
+// A FeatureDistance is a feature along with its distance to the requested location.
+type FeatureDistance struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The feature.
+	Feature *Feature `protobuf:"bytes,1,opt,name=feature,proto3" json:"feature,omitempty"`
+	// The haversine distance to the requested location in metres.
+	Distance      int32 `protobuf:"varint,2,opt,name=distance,proto3" json:"distance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FeatureDistance) Reset() {
+	*x = FeatureDistance{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FeatureDistance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FeatureDistance) ProtoMessage() {}
+
+func (x *FeatureDistance) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FeatureDistance.ProtoReflect.Descriptor instead.
+func (*FeatureDistance) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *FeatureDistance) GetFeature() *Feature {
+	if x != nil {
+		return x.Feature
+	}
+	return nil
+}
+
+func (x *FeatureDistance) GetDistance() int32 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+// A FindNearestResponse lists the nearest features, closest first.
+type FindNearestResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Features      []*FeatureDistance     `protobuf:"bytes,1,rep,name=features,proto3" json:"features,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindNearestResponse) Reset() {
+	*x = FindNearestResponse{}
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindNearestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindNearestResponse) ProtoMessage() {}
+
+func (x *FindNearestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_route_guide_route_guide_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindNearestResponse.ProtoReflect.Descriptor instead.
+func (*FindNearestResponse) Descriptor() ([]byte, []int) {
+	return file_route_guide_route_guide_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *FindNearestResponse) GetFeatures() []*FeatureDistance {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+var File_route_guide_route_guide_service_proto protoreflect.FileDescriptor
+
+const file_route_guide_route_guide_service_proto_rawDesc = "" +
+	"\n" +
+	"%route_guide/route_guide_service.proto\x1a\x1bbuf/validate/validate.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"~\n" +
+	"\x05Point\x12\x1a\n" +
+	"\blatitude\x18\x01 \x01(\x05R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x02 \x01(\x05R\tlongitude\x12;\n" +
+	"\vrecorded_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"recordedAt\"A\n" +
+	"\aFeature\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\"\n" +
+	"\blocation\x18\x02 \x01(\v2\x06.PointR\blocation\";\n" +
+	"\tRectangle\x12\x16\n" +
+	"\x02lo\x18\x01 \x01(\v2\x06.PointR\x02lo\x12\x16\n" +
+	"\x02hi\x18\x02 \x01(\v2\x06.PointR\x02hi\":\n" +
+	"\aPolygon\x12/\n" +
+	"\bvertices\x18\x01 \x03(\v2\x06.PointB\v\xbaH\b\x92\x01\x05\b\x03\x10\xe8\aR\bvertices\"\xb5\x02\n" +
+	"\fRouteSummary\x12\x1f\n" +
+	"\vpoint_count\x18\x01 \x01(\x05R\n" +
+	"pointCount\x12#\n" +
+	"\rfeature_count\x18\x02 \x01(\x05R\ffeatureCount\x12\x1a\n" +
+	"\bdistance\x18\x03 \x01(\x05R\bdistance\x12!\n" +
+	"\felapsed_time\x18\x04 \x01(\x05R\velapsedTime\x12\x19\n" +
+	"\broute_id\x18\x05 \x01(\tR\arouteId\x12#\n" +
+	"\raverage_speed\x18\x06 \x01(\x01R\faverageSpeed\x12\x1b\n" +
+	"\tmax_speed\x18\a \x01(\x01R\bmaxSpeed\x12\x1b\n" +
+	"\x05stops\x18\b \x03(\v2\x05.StopR\x05stops\x12&\n" +
+	"\n" +
+	"simplified\x18\t \x03(\v2\x06.PointR\n" +
+	"simplified\"\xb8\x01\n" +
+	"\x04Stop\x12\"\n" +
+	"\blocation\x18\x01 \x01(\v2\x06.PointR\blocation\x129\n" +
+	"\n" +
+	"started_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x125\n" +
+	"\bended_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\aendedAt\x12\x1a\n" +
+	"\bduration\x18\x04 \x01(\x05R\bduration\"\xa6\x02\n" +
+	"\x05Route\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x16\n" +
+	"\x06tenant\x18\x03 \x01(\tR\x06tenant\x12\x1e\n" +
+	"\x06points\x18\x04 \x03(\v2\x06.PointR\x06points\x12'\n" +
+	"\asummary\x18\x05 \x01(\v2\r.RouteSummaryR\asummary\x12$\n" +
+	"\bfeatures\x18\x06 \x03(\v2\b.FeatureR\bfeatures\x129\n" +
+	"\n" +
+	"started_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x125\n" +
+	"\bended_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\aendedAt\"o\n" +
+	"\x11ListRoutesRequest\x12.\n" +
+	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"4\n" +
+	"\x12ListRoutesResponse\x12\x1e\n" +
+	"\x06routes\x18\x01 \x03(\v2\x06.RouteR\x06routes\")\n" +
+	"\x0fGetRouteRequest\x12\x16\n" +
+	"\x02id\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x02id\"w\n" +
+	"\x12WatchRegionRequest\x12*\n" +
+	"\trectangle\x18\x01 \x01(\v2\n" +
+	".RectangleH\x00R\trectangle\x12$\n" +
+	"\apolygon\x18\x02 \x01(\v2\b.PolygonH\x00R\apolygonB\x0f\n" +
+	"\x06region\x12\x05\xbaH\x02\b\x01\"\xeb\x01\n" +
+	"\vRegionEvent\x12%\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x11.RegionEvent.KindR\x04kind\x12\x19\n" +
+	"\broute_id\x18\x02 \x01(\tR\arouteId\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\x12\x1c\n" +
+	"\x05point\x18\x04 \x01(\v2\x06.PointR\x05point\x12;\n" +
+	"\vreceived_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"receivedAt\")\n" +
+	"\x04Kind\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\t\n" +
+	"\x05ENTER\x10\x01\x12\t\n" +
+	"\x05LEAVE\x10\x02\"\xb6\x01\n" +
+	"\tRouteNote\x12\"\n" +
+	"\blocation\x18\x01 \x01(\v2\x06.PointR\blocation\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1e\n" +
+	"\x04area\x18\x03 \x01(\v2\n" +
+	".RectangleR\x04area\x123\n" +
+	"\asent_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06sentAt\x12\x16\n" +
+	"\x06sender\x18\x05 \x01(\tR\x06sender\"n\n" +
+	"\x14UpdateFeatureRequest\x12*\n" +
+	"\blocation\x18\x01 \x01(\v2\x06.PointB\x06\xbaH\x03\xc8\x01\x01R\blocation\x12*\n" +
+	"\afeature\x18\x02 \x01(\v2\b.FeatureB\x06\xbaH\x03\xc8\x01\x01R\afeature\"%\n" +
+	"\aGeoJson\x12\x1a\n" +
+	"\x04data\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x04data\"7\n" +
+	"\x15ExportFeaturesRequest\x12\x1e\n" +
+	"\x04area\x18\x01 \x01(\v2\n" +
+	".RectangleR\x04area\"L\n" +
+	"\x16ImportFeaturesResponse\x12\x18\n" +
+	"\acreated\x18\x01 \x01(\x05R\acreated\x12\x18\n" +
+	"\aupdated\x18\x02 \x01(\x05R\aupdated\"Z\n" +
+	"\x12FindNearestRequest\x12*\n" +
+	"\blocation\x18\x01 \x01(\v2\x06.PointB\x06\xbaH\x03\xc8\x01\x01R\blocation\x12\x18\n" +
+	"\x01k\x18\x02 \x01(\x05B\n" +
+	"\xbaH\a\x1a\x05\x18\xe8\a \x00R\x01k\"\\\n" +
+	"\rRadiusRequest\x12*\n" +
+	"\blocation\x18\x01 \x01(\v2\x06.PointB\x06\xbaH\x03\xc8\x01\x01R\blocation\x12\x1f\n" +
+	"\x06radius\x18\x02 \x01(\x05B\a\xbaH\x04\x1a\x02 \x00R\x06radius\"Q\n" +
+	"\x0fFeatureDistance\x12\"\n" +
+	"\afeature\x18\x01 \x01(\v2\b.FeatureR\afeature\x12\x1a\n" +
+	"\bdistance\x18\x02 \x01(\x05R\bdistance\"C\n" +
+	"\x13FindNearestResponse\x12,\n" +
+	"\bfeatures\x18\x01 \x03(\v2\x10.FeatureDistanceR\bfeatures2\xda\x05\n" +
+	"\n" +
+	"RouteGuide\x12\x1e\n" +
+	"\n" +
+	"GetFeature\x12\x06.Point\x1a\b.Feature\x12(\n" +
+	"\fListFeatures\x12\n" +
+	".Rectangle\x1a\b.Feature\"\x000\x01\x12:\n" +
+	"\vFindNearest\x12\x13.FindNearestRequest\x1a\x14.FindNearestResponse\"\x00\x12@\n" +
+	"\x18ListFeaturesWithinRadius\x12\x0e.RadiusRequest\x1a\x10.FeatureDistance\"\x000\x01\x12(\n" +
+	"\vRecordRoute\x12\x06.Point\x1a\r.RouteSummary\"\x00(\x01\x127\n" +
+	"\n" +
+	"ListRoutes\x12\x12.ListRoutesRequest\x1a\x13.ListRoutesResponse\"\x00\x12&\n" +
+	"\bGetRoute\x12\x10.GetRouteRequest\x1a\x06.Route\"\x00\x12+\n" +
+	"\vExportRoute\x12\x10.GetRouteRequest\x1a\b.GeoJson\"\x00\x12)\n" +
+	"\tRouteChat\x12\n" +
+	".RouteNote\x1a\n" +
+	".RouteNote\"\x00(\x010\x01\x124\n" +
+	"\vWatchRegion\x12\x13.WatchRegionRequest\x1a\f.RegionEvent\"\x000\x01\x12%\n" +
+	"\rCreateFeature\x12\b.Feature\x1a\b.Feature\"\x00\x122\n" +
+	"\rUpdateFeature\x12\x15.UpdateFeatureRequest\x1a\b.Feature\"\x00\x12#\n" +
+	"\rDeleteFeature\x12\x06.Point\x1a\b.Feature\"\x00\x124\n" +
+	"\x0eExportFeatures\x12\x16.ExportFeaturesRequest\x1a\b.GeoJson\"\x00\x125\n" +
+	"\x0eImportFeatures\x12\b.GeoJson\x1a\x17.ImportFeaturesResponse\"\x00B\tZ\a/protocb\x06proto3"
+
+var (
+	file_route_guide_route_guide_service_proto_rawDescOnce sync.Once
+	file_route_guide_route_guide_service_proto_rawDescData []byte
+)
+
+func file_route_guide_route_guide_service_proto_rawDescGZIP() []byte {
+	file_route_guide_route_guide_service_proto_rawDescOnce.Do(func() {
+		file_route_guide_route_guide_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_route_guide_route_guide_service_proto_rawDesc), len(file_route_guide_route_guide_service_proto_rawDesc)))
+	})
+	return file_route_guide_route_guide_service_proto_rawDescData
+}
+
+var file_route_guide_route_guide_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_route_guide_route_guide_service_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_route_guide_route_guide_service_proto_goTypes = []any{
+	(RegionEvent_Kind)(0),          // 0: RegionEvent.Kind
+	(*Point)(nil),                  // 1: Point
+	(*Feature)(nil),                // 2: Feature
+	(*Rectangle)(nil),              // 3: Rectangle
+	(*Polygon)(nil),                // 4: Polygon
+	(*RouteSummary)(nil),           // 5: RouteSummary
+	(*Stop)(nil),                   // 6: Stop
+	(*Route)(nil),                  // 7: Route
+	(*ListRoutesRequest)(nil),      // 8: ListRoutesRequest
+	(*ListRoutesResponse)(nil),     // 9: ListRoutesResponse
+	(*GetRouteRequest)(nil),        // 10: GetRouteRequest
+	(*WatchRegionRequest)(nil),     // 11: WatchRegionRequest
+	(*RegionEvent)(nil),            // 12: RegionEvent
+	(*RouteNote)(nil),              // 13: RouteNote
+	(*UpdateFeatureRequest)(nil),   // 14: UpdateFeatureRequest
+	(*GeoJson)(nil),                // 15: GeoJson
+	(*ExportFeaturesRequest)(nil),  // 16: ExportFeaturesRequest
+	(*ImportFeaturesResponse)(nil), // 17: ImportFeaturesResponse
+	(*FindNearestRequest)(nil),     // 18: FindNearestRequest
+	(*RadiusRequest)(nil),          // 19: RadiusRequest
+	(*FeatureDistance)(nil),        // 20: FeatureDistance
+	(*FindNearestResponse)(nil),    // 21: FindNearestResponse
+	(*timestamppb.Timestamp)(nil),  // 22: google.protobuf.Timestamp
+}
+var file_route_guide_route_guide_service_proto_depIdxs = []int32{
+	22, // 0: Point.recorded_at:type_name -> google.protobuf.Timestamp
+	1,  // 1: Feature.location:type_name -> Point
+	1,  // 2: Rectangle.lo:type_name -> Point
+	1,  // 3: Rectangle.hi:type_name -> Point
+	1,  // 4: Polygon.vertices:type_name -> Point
+	6,  // 5: RouteSummary.stops:type_name -> Stop
+	1,  // 6: RouteSummary.simplified:type_name -> Point
+	1,  // 7: Stop.location:type_name -> Point
+	22, // 8: Stop.started_at:type_name -> google.protobuf.Timestamp
+	22, // 9: Stop.ended_at:type_name -> google.protobuf.Timestamp
+	1,  // 10: Route.points:type_name -> Point
+	5,  // 11: Route.summary:type_name -> RouteSummary
+	2,  // 12: Route.features:type_name -> Feature
+	22, // 13: Route.started_at:type_name -> google.protobuf.Timestamp
+	22, // 14: Route.ended_at:type_name -> google.protobuf.Timestamp
+	22, // 15: ListRoutesRequest.from:type_name -> google.protobuf.Timestamp
+	22, // 16: ListRoutesRequest.to:type_name -> google.protobuf.Timestamp
+	7,  // 17: ListRoutesResponse.routes:type_name -> Route
+	3,  // 18: WatchRegionRequest.rectangle:type_name -> Rectangle
+	4,  // 19: WatchRegionRequest.polygon:type_name -> Polygon
+	0,  // 20: RegionEvent.kind:type_name -> RegionEvent.Kind
+	1,  // 21: RegionEvent.point:type_name -> Point
+	22, // 22: RegionEvent.received_at:type_name -> google.protobuf.Timestamp
+	1,  // 23: RouteNote.location:type_name -> Point
+	3,  // 24: RouteNote.area:type_name -> Rectangle
+	22, // 25: RouteNote.sent_at:type_name -> google.protobuf.Timestamp
+	1,  // 26: UpdateFeatureRequest.location:type_name -> Point
+	2,  // 27: UpdateFeatureRequest.feature:type_name -> Feature
+	3,  // 28: ExportFeaturesRequest.area:type_name -> Rectangle
+	1,  // 29: FindNearestRequest.location:type_name -> Point
+	1,  // 30: RadiusRequest.location:type_name -> Point
+	2,  // 31: FeatureDistance.feature:type_name -> Feature
+	20, // 32: FindNearestResponse.features:type_name -> FeatureDistance
+	1,  // 33: RouteGuide.GetFeature:input_type -> Point
+	3,  // 34: RouteGuide.ListFeatures:input_type -> Rectangle
+	18, // 35: RouteGuide.FindNearest:input_type -> FindNearestRequest
+	19, // 36: RouteGuide.ListFeaturesWithinRadius:input_type -> RadiusRequest
+	1,  // 37: RouteGuide.RecordRoute:input_type -> Point
+	8,  // 38: RouteGuide.ListRoutes:input_type -> ListRoutesRequest
+	10, // 39: RouteGuide.GetRoute:input_type -> GetRouteRequest
+	10, // 40: RouteGuide.ExportRoute:input_type -> GetRouteRequest
+	13, // 41: RouteGuide.RouteChat:input_type -> RouteNote
+	11, // 42: RouteGuide.WatchRegion:input_type -> WatchRegionRequest
+	2,  // 43: RouteGuide.CreateFeature:input_type -> Feature
+	14, // 44: RouteGuide.UpdateFeature:input_type -> UpdateFeatureRequest
+	1,  // 45: RouteGuide.DeleteFeature:input_type -> Point
+	16, // 46: RouteGuide.ExportFeatures:input_type -> ExportFeaturesRequest
+	15, // 47: RouteGuide.ImportFeatures:input_type -> GeoJson
+	2,  // 48: RouteGuide.GetFeature:output_type -> Feature
+	2,  // 49: RouteGuide.ListFeatures:output_type -> Feature
+	21, // 50: RouteGuide.FindNearest:output_type -> FindNearestResponse
+	20, // 51: RouteGuide.ListFeaturesWithinRadius:output_type -> FeatureDistance
+	5,  // 52: RouteGuide.RecordRoute:output_type -> RouteSummary
+	9,  // 53: RouteGuide.ListRoutes:output_type -> ListRoutesResponse
+	7,  // 54: RouteGuide.GetRoute:output_type -> Route
+	15, // 55: RouteGuide.ExportRoute:output_type -> GeoJson
+	13, // 56: RouteGuide.RouteChat:output_type -> RouteNote
+	12, // 57: RouteGuide.WatchRegion:output_type -> RegionEvent
+	2,  // 58: RouteGuide.CreateFeature:output_type -> Feature
+	2,  // 59: RouteGuide.UpdateFeature:output_type -> Feature
+	2,  // 60: RouteGuide.DeleteFeature:output_type -> Feature
+	15, // 61: RouteGuide.ExportFeatures:output_type -> GeoJson
+	17, // 62: RouteGuide.ImportFeatures:output_type -> ImportFeaturesResponse
+	48, // [48:63] is the sub-list for method output_type
+	33, // [33:48] is the sub-list for method input_type
+	33, // [33:33] is the sub-list for extension type_name
+	33, // [33:33] is the sub-list for extension extendee
+	0,  // [0:33] is the sub-list for field type_name
+}
+
+func init() { file_route_guide_route_guide_service_proto_init() }
+func file_route_guide_route_guide_service_proto_init() {
+	if File_route_guide_route_guide_service_proto != nil {
+		return
+	}
+	file_route_guide_route_guide_service_proto_msgTypes[10].OneofWrappers = []any{
+		(*WatchRegionRequest_Rectangle)(nil),
+		(*WatchRegionRequest_Polygon)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_route_guide_route_guide_service_proto_rawDesc), len(file_route_guide_route_guide_service_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   5,
+			NumEnums:      1,
+			NumMessages:   21,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_route_guide_route_guide_service_proto_goTypes,
 		DependencyIndexes: file_route_guide_route_guide_service_proto_depIdxs,
+		EnumInfos:         file_route_guide_route_guide_service_proto_enumTypes,
 		MessageInfos:      file_route_guide_route_guide_service_proto_msgTypes,
 	}.Build()
 	File_route_guide_route_guide_service_proto = out.File
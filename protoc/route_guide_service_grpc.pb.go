@@ -19,10 +19,21 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	RouteGuide_GetFeature_FullMethodName   = "/RouteGuide/GetFeature"
-	RouteGuide_ListFeatures_FullMethodName = "/RouteGuide/ListFeatures"
-	RouteGuide_RecordRoute_FullMethodName  = "/RouteGuide/RecordRoute"
-	RouteGuide_RouteChat_FullMethodName    = "/RouteGuide/RouteChat"
+	RouteGuide_GetFeature_FullMethodName               = "/RouteGuide/GetFeature"
+	RouteGuide_ListFeatures_FullMethodName             = "/RouteGuide/ListFeatures"
+	RouteGuide_FindNearest_FullMethodName              = "/RouteGuide/FindNearest"
+	RouteGuide_ListFeaturesWithinRadius_FullMethodName = "/RouteGuide/ListFeaturesWithinRadius"
+	RouteGuide_RecordRoute_FullMethodName              = "/RouteGuide/RecordRoute"
+	RouteGuide_ListRoutes_FullMethodName               = "/RouteGuide/ListRoutes"
+	RouteGuide_GetRoute_FullMethodName                 = "/RouteGuide/GetRoute"
+	RouteGuide_ExportRoute_FullMethodName              = "/RouteGuide/ExportRoute"
+	RouteGuide_RouteChat_FullMethodName                = "/RouteGuide/RouteChat"
+	RouteGuide_WatchRegion_FullMethodName              = "/RouteGuide/WatchRegion"
+	RouteGuide_CreateFeature_FullMethodName            = "/RouteGuide/CreateFeature"
+	RouteGuide_UpdateFeature_FullMethodName            = "/RouteGuide/UpdateFeature"
+	RouteGuide_DeleteFeature_FullMethodName            = "/RouteGuide/DeleteFeature"
+	RouteGuide_ExportFeatures_FullMethodName           = "/RouteGuide/ExportFeatures"
+	RouteGuide_ImportFeatures_FullMethodName           = "/RouteGuide/ImportFeatures"
 )
 
 // RouteGuideClient is the client API for RouteGuide service.
@@ -43,14 +54,47 @@ type RouteGuideClient interface {
 	// repeated field), as the rectangle may cover a large area and contain a
 	// huge number of features.
 	ListFeatures(ctx context.Context, in *Rectangle, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Feature], error)
+	// Obtains the k Features closest to the given location, ordered by
+	// distance. Features at the same distance keep their catalogue order.
+	FindNearest(ctx context.Context, in *FindNearestRequest, opts ...grpc.CallOption) (*FindNearestResponse, error)
+	// A server-to-client streaming RPC.
+	// Obtains the Features within the given radius of a location, streamed
+	// closest first.
+	ListFeaturesWithinRadius(ctx context.Context, in *RadiusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FeatureDistance], error)
 	// A client-to-server streaming RPC.
 	// Accepts a stream of Points on a route being traversed, returning a
-	// RouteSummary when traversal is completed.
+	// RouteSummary when traversal is completed. The route is stored for the
+	// caller.
 	RecordRoute(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Point, RouteSummary], error)
+	// Lists the routes recorded by the caller, points omitted.
+	ListRoutes(ctx context.Context, in *ListRoutesRequest, opts ...grpc.CallOption) (*ListRoutesResponse, error)
+	// Returns a route recorded by the caller with its points.
+	GetRoute(ctx context.Context, in *GetRouteRequest, opts ...grpc.CallOption) (*Route, error)
+	// Exports a route recorded by the caller as a GeoJSON LineString Feature,
+	// with the route id, owner, tenant, times and summary as properties.
+	ExportRoute(ctx context.Context, in *GetRouteRequest, opts ...grpc.CallOption) (*GeoJson, error)
 	// A Bidirectional streaming RPC.
 	// Accepts a stream of RouteNotes sent while a route is being traversed,
 	// while receiving other RouteNotes (e.g. from other users).
 	RouteChat(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[RouteNote, RouteNote], error)
+	// A server-to-client streaming RPC.
+	// Streams the RecordRoute points of the caller's tenant entering or leaving
+	// a region until the client cancels.
+	WatchRegion(ctx context.Context, in *WatchRegionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RegionEvent], error)
+	// Adds a feature to the catalogue, admin only. Fails with ALREADY_EXISTS
+	// when a feature exists at its location.
+	CreateFeature(ctx context.Context, in *Feature, opts ...grpc.CallOption) (*Feature, error)
+	// Replaces the feature at a location, admin only.
+	UpdateFeature(ctx context.Context, in *UpdateFeatureRequest, opts ...grpc.CallOption) (*Feature, error)
+	// Removes the feature at the given point and returns it, admin only.
+	DeleteFeature(ctx context.Context, in *Point, opts ...grpc.CallOption) (*Feature, error)
+	// Exports features as a GeoJSON FeatureCollection of Points named by a
+	// "name" property.
+	ExportFeatures(ctx context.Context, in *ExportFeaturesRequest, opts ...grpc.CallOption) (*GeoJson, error)
+	// Imports a GeoJSON FeatureCollection of Points, admin only. A feature
+	// replaces the one at its location, and nothing is imported when any
+	// feature is invalid.
+	ImportFeatures(ctx context.Context, in *GeoJson, opts ...grpc.CallOption) (*ImportFeaturesResponse, error)
 }
 
 type routeGuideClient struct {
@@ -90,9 +134,38 @@ func (c *routeGuideClient) ListFeatures(ctx context.Context, in *Rectangle, opts
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_ListFeaturesClient = grpc.ServerStreamingClient[Feature]
 
+func (c *routeGuideClient) FindNearest(ctx context.Context, in *FindNearestRequest, opts ...grpc.CallOption) (*FindNearestResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindNearestResponse)
+	err := c.cc.Invoke(ctx, RouteGuide_FindNearest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routeGuideClient) ListFeaturesWithinRadius(ctx context.Context, in *RadiusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FeatureDistance], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[1], RouteGuide_ListFeaturesWithinRadius_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RadiusRequest, FeatureDistance]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_ListFeaturesWithinRadiusClient = grpc.ServerStreamingClient[FeatureDistance]
+
 func (c *routeGuideClient) RecordRoute(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Point, RouteSummary], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[1], RouteGuide_RecordRoute_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[2], RouteGuide_RecordRoute_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -103,9 +176,39 @@ func (c *routeGuideClient) RecordRoute(ctx context.Context, opts ...grpc.CallOpt
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_RecordRouteClient = grpc.ClientStreamingClient[Point, RouteSummary]
 
+func (c *routeGuideClient) ListRoutes(ctx context.Context, in *ListRoutesRequest, opts ...grpc.CallOption) (*ListRoutesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRoutesResponse)
+	err := c.cc.Invoke(ctx, RouteGuide_ListRoutes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routeGuideClient) GetRoute(ctx context.Context, in *GetRouteRequest, opts ...grpc.CallOption) (*Route, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Route)
+	err := c.cc.Invoke(ctx, RouteGuide_GetRoute_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routeGuideClient) ExportRoute(ctx context.Context, in *GetRouteRequest, opts ...grpc.CallOption) (*GeoJson, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GeoJson)
+	err := c.cc.Invoke(ctx, RouteGuide_ExportRoute_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *routeGuideClient) RouteChat(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[RouteNote, RouteNote], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[2], RouteGuide_RouteChat_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[3], RouteGuide_RouteChat_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +219,75 @@ func (c *routeGuideClient) RouteChat(ctx context.Context, opts ...grpc.CallOptio
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_RouteChatClient = grpc.BidiStreamingClient[RouteNote, RouteNote]
 
+func (c *routeGuideClient) WatchRegion(ctx context.Context, in *WatchRegionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RegionEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[4], RouteGuide_WatchRegion_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRegionRequest, RegionEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_WatchRegionClient = grpc.ServerStreamingClient[RegionEvent]
+
+func (c *routeGuideClient) CreateFeature(ctx context.Context, in *Feature, opts ...grpc.CallOption) (*Feature, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Feature)
+	err := c.cc.Invoke(ctx, RouteGuide_CreateFeature_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routeGuideClient) UpdateFeature(ctx context.Context, in *UpdateFeatureRequest, opts ...grpc.CallOption) (*Feature, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Feature)
+	err := c.cc.Invoke(ctx, RouteGuide_UpdateFeature_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routeGuideClient) DeleteFeature(ctx context.Context, in *Point, opts ...grpc.CallOption) (*Feature, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Feature)
+	err := c.cc.Invoke(ctx, RouteGuide_DeleteFeature_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routeGuideClient) ExportFeatures(ctx context.Context, in *ExportFeaturesRequest, opts ...grpc.CallOption) (*GeoJson, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GeoJson)
+	err := c.cc.Invoke(ctx, RouteGuide_ExportFeatures_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routeGuideClient) ImportFeatures(ctx context.Context, in *GeoJson, opts ...grpc.CallOption) (*ImportFeaturesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportFeaturesResponse)
+	err := c.cc.Invoke(ctx, RouteGuide_ImportFeatures_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RouteGuideServer is the server API for RouteGuide service.
 // All implementations must embed UnimplementedRouteGuideServer
 // for forward compatibility.
@@ -134,14 +306,47 @@ type RouteGuideServer interface {
 	// repeated field), as the rectangle may cover a large area and contain a
 	// huge number of features.
 	ListFeatures(*Rectangle, grpc.ServerStreamingServer[Feature]) error
+	// Obtains the k Features closest to the given location, ordered by
+	// distance. Features at the same distance keep their catalogue order.
+	FindNearest(context.Context, *FindNearestRequest) (*FindNearestResponse, error)
+	// A server-to-client streaming RPC.
+	// Obtains the Features within the given radius of a location, streamed
+	// closest first.
+	ListFeaturesWithinRadius(*RadiusRequest, grpc.ServerStreamingServer[FeatureDistance]) error
 	// A client-to-server streaming RPC.
 	// Accepts a stream of Points on a route being traversed, returning a
-	// RouteSummary when traversal is completed.
+	// RouteSummary when traversal is completed. The route is stored for the
+	// caller.
 	RecordRoute(grpc.ClientStreamingServer[Point, RouteSummary]) error
+	// Lists the routes recorded by the caller, points omitted.
+	ListRoutes(context.Context, *ListRoutesRequest) (*ListRoutesResponse, error)
+	// Returns a route recorded by the caller with its points.
+	GetRoute(context.Context, *GetRouteRequest) (*Route, error)
+	// Exports a route recorded by the caller as a GeoJSON LineString Feature,
+	// with the route id, owner, tenant, times and summary as properties.
+	ExportRoute(context.Context, *GetRouteRequest) (*GeoJson, error)
 	// A Bidirectional streaming RPC.
 	// Accepts a stream of RouteNotes sent while a route is being traversed,
 	// while receiving other RouteNotes (e.g. from other users).
 	RouteChat(grpc.BidiStreamingServer[RouteNote, RouteNote]) error
+	// A server-to-client streaming RPC.
+	// Streams the RecordRoute points of the caller's tenant entering or leaving
+	// a region until the client cancels.
+	WatchRegion(*WatchRegionRequest, grpc.ServerStreamingServer[RegionEvent]) error
+	// Adds a feature to the catalogue, admin only. Fails with ALREADY_EXISTS
+	// when a feature exists at its location.
+	CreateFeature(context.Context, *Feature) (*Feature, error)
+	// Replaces the feature at a location, admin only.
+	UpdateFeature(context.Context, *UpdateFeatureRequest) (*Feature, error)
+	// Removes the feature at the given point and returns it, admin only.
+	DeleteFeature(context.Context, *Point) (*Feature, error)
+	// Exports features as a GeoJSON FeatureCollection of Points named by a
+	// "name" property.
+	ExportFeatures(context.Context, *ExportFeaturesRequest) (*GeoJson, error)
+	// Imports a GeoJSON FeatureCollection of Points, admin only. A feature
+	// replaces the one at its location, and nothing is imported when any
+	// feature is invalid.
+	ImportFeatures(context.Context, *GeoJson) (*ImportFeaturesResponse, error)
 	mustEmbedUnimplementedRouteGuideServer()
 }
 
@@ -158,12 +363,45 @@ func (UnimplementedRouteGuideServer) GetFeature(context.Context, *Point) (*Featu
 func (UnimplementedRouteGuideServer) ListFeatures(*Rectangle, grpc.ServerStreamingServer[Feature]) error {
 	return status.Error(codes.Unimplemented, "method ListFeatures not implemented")
 }
+func (UnimplementedRouteGuideServer) FindNearest(context.Context, *FindNearestRequest) (*FindNearestResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method FindNearest not implemented")
+}
+func (UnimplementedRouteGuideServer) ListFeaturesWithinRadius(*RadiusRequest, grpc.ServerStreamingServer[FeatureDistance]) error {
+	return status.Error(codes.Unimplemented, "method ListFeaturesWithinRadius not implemented")
+}
 func (UnimplementedRouteGuideServer) RecordRoute(grpc.ClientStreamingServer[Point, RouteSummary]) error {
 	return status.Error(codes.Unimplemented, "method RecordRoute not implemented")
 }
+func (UnimplementedRouteGuideServer) ListRoutes(context.Context, *ListRoutesRequest) (*ListRoutesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListRoutes not implemented")
+}
+func (UnimplementedRouteGuideServer) GetRoute(context.Context, *GetRouteRequest) (*Route, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetRoute not implemented")
+}
+func (UnimplementedRouteGuideServer) ExportRoute(context.Context, *GetRouteRequest) (*GeoJson, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExportRoute not implemented")
+}
 func (UnimplementedRouteGuideServer) RouteChat(grpc.BidiStreamingServer[RouteNote, RouteNote]) error {
 	return status.Error(codes.Unimplemented, "method RouteChat not implemented")
 }
+func (UnimplementedRouteGuideServer) WatchRegion(*WatchRegionRequest, grpc.ServerStreamingServer[RegionEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchRegion not implemented")
+}
+func (UnimplementedRouteGuideServer) CreateFeature(context.Context, *Feature) (*Feature, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateFeature not implemented")
+}
+func (UnimplementedRouteGuideServer) UpdateFeature(context.Context, *UpdateFeatureRequest) (*Feature, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateFeature not implemented")
+}
+func (UnimplementedRouteGuideServer) DeleteFeature(context.Context, *Point) (*Feature, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteFeature not implemented")
+}
+func (UnimplementedRouteGuideServer) ExportFeatures(context.Context, *ExportFeaturesRequest) (*GeoJson, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExportFeatures not implemented")
+}
+func (UnimplementedRouteGuideServer) ImportFeatures(context.Context, *GeoJson) (*ImportFeaturesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ImportFeatures not implemented")
+}
 func (UnimplementedRouteGuideServer) mustEmbedUnimplementedRouteGuideServer() {}
 func (UnimplementedRouteGuideServer) testEmbeddedByValue()                    {}
 
@@ -214,6 +452,35 @@ func _RouteGuide_ListFeatures_Handler(srv interface{}, stream grpc.ServerStream)
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_ListFeaturesServer = grpc.ServerStreamingServer[Feature]
 
+func _RouteGuide_FindNearest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindNearestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).FindNearest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_FindNearest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).FindNearest(ctx, req.(*FindNearestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RouteGuide_ListFeaturesWithinRadius_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RadiusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RouteGuideServer).ListFeaturesWithinRadius(m, &grpc.GenericServerStream[RadiusRequest, FeatureDistance]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_ListFeaturesWithinRadiusServer = grpc.ServerStreamingServer[FeatureDistance]
+
 func _RouteGuide_RecordRoute_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(RouteGuideServer).RecordRoute(&grpc.GenericServerStream[Point, RouteSummary]{ServerStream: stream})
 }
@@ -221,6 +488,60 @@ func _RouteGuide_RecordRoute_Handler(srv interface{}, stream grpc.ServerStream)
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_RecordRouteServer = grpc.ClientStreamingServer[Point, RouteSummary]
 
+func _RouteGuide_ListRoutes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRoutesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).ListRoutes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_ListRoutes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).ListRoutes(ctx, req.(*ListRoutesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RouteGuide_GetRoute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRouteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).GetRoute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_GetRoute_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).GetRoute(ctx, req.(*GetRouteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RouteGuide_ExportRoute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRouteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).ExportRoute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_ExportRoute_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).ExportRoute(ctx, req.(*GetRouteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _RouteGuide_RouteChat_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(RouteGuideServer).RouteChat(&grpc.GenericServerStream[RouteNote, RouteNote]{ServerStream: stream})
 }
@@ -228,6 +549,107 @@ func _RouteGuide_RouteChat_Handler(srv interface{}, stream grpc.ServerStream) er
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_RouteChatServer = grpc.BidiStreamingServer[RouteNote, RouteNote]
 
+func _RouteGuide_WatchRegion_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRegionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RouteGuideServer).WatchRegion(m, &grpc.GenericServerStream[WatchRegionRequest, RegionEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_WatchRegionServer = grpc.ServerStreamingServer[RegionEvent]
+
+func _RouteGuide_CreateFeature_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Feature)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).CreateFeature(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_CreateFeature_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).CreateFeature(ctx, req.(*Feature))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RouteGuide_UpdateFeature_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateFeatureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).UpdateFeature(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_UpdateFeature_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).UpdateFeature(ctx, req.(*UpdateFeatureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RouteGuide_DeleteFeature_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Point)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).DeleteFeature(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_DeleteFeature_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).DeleteFeature(ctx, req.(*Point))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RouteGuide_ExportFeatures_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportFeaturesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).ExportFeatures(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_ExportFeatures_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).ExportFeatures(ctx, req.(*ExportFeaturesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RouteGuide_ImportFeatures_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GeoJson)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).ImportFeatures(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_ImportFeatures_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).ImportFeatures(ctx, req.(*GeoJson))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RouteGuide_ServiceDesc is the grpc.ServiceDesc for RouteGuide service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -239,6 +661,42 @@ var RouteGuide_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetFeature",
 			Handler:    _RouteGuide_GetFeature_Handler,
 		},
+		{
+			MethodName: "FindNearest",
+			Handler:    _RouteGuide_FindNearest_Handler,
+		},
+		{
+			MethodName: "ListRoutes",
+			Handler:    _RouteGuide_ListRoutes_Handler,
+		},
+		{
+			MethodName: "GetRoute",
+			Handler:    _RouteGuide_GetRoute_Handler,
+		},
+		{
+			MethodName: "ExportRoute",
+			Handler:    _RouteGuide_ExportRoute_Handler,
+		},
+		{
+			MethodName: "CreateFeature",
+			Handler:    _RouteGuide_CreateFeature_Handler,
+		},
+		{
+			MethodName: "UpdateFeature",
+			Handler:    _RouteGuide_UpdateFeature_Handler,
+		},
+		{
+			MethodName: "DeleteFeature",
+			Handler:    _RouteGuide_DeleteFeature_Handler,
+		},
+		{
+			MethodName: "ExportFeatures",
+			Handler:    _RouteGuide_ExportFeatures_Handler,
+		},
+		{
+			MethodName: "ImportFeatures",
+			Handler:    _RouteGuide_ImportFeatures_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -246,6 +704,11 @@ var RouteGuide_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _RouteGuide_ListFeatures_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "ListFeaturesWithinRadius",
+			Handler:       _RouteGuide_ListFeaturesWithinRadius_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "RecordRoute",
 			Handler:       _RouteGuide_RecordRoute_Handler,
@@ -257,6 +720,11 @@ var RouteGuide_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "WatchRegion",
+			Handler:       _RouteGuide_WatchRegion_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "route_guide/route_guide_service.proto",
 }
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"gopkg.in/yaml.v3"
+)
+
+// Credential kinds a method accepts to authenticate its caller.
+const (
+	CredentialToken = "token" // PASETO access token in the authorization metadata
+	CredentialCert  = "cert"  // verified mTLS client certificate, or Unix socket peer UID, mapped to a principal
+	CredentialAny   = "any"   // either of them
+)
+
+// AccessPolicy decides who may call a gRPC method.
+type AccessPolicy interface {
+	// Access reports whether method is public, and otherwise the roles allowed to call it.
+	Access(method string) (public bool, roles []string)
+
+	// Credentials returns the credential kind method accepts.
+	Credentials(method string) string
+
+	// Audited reports whether every call of method is recorded in the audit log, not only denied ones.
+	Audited(method string) bool
+
+	// RolePermissions returns the permissions granted to role, ok is false for an unknown role.
+	RolePermissions(role string) (permissions []string, ok bool)
+}
+
+// CredentialRule sets the credential kind accepted by the methods it matches.
+type CredentialRule struct {
+	Methods []string `yaml:"methods"`
+	Accept  string   `yaml:"accept"`
+}
+
+// Policy is a role based access control policy: roles are granted permissions, and permissions
+// cover full method names. Method patterns use path.Match syntax, e.g. "/LaptopService/*".
+type Policy struct {
+	// DefaultDeny denies methods no public pattern or permission covers, otherwise they are public.
+	DefaultDeny bool                `yaml:"default_deny"`
+	Public      []string            `yaml:"public"`      // method patterns callable without a token
+	Permissions map[string][]string `yaml:"permissions"` // permission -> method patterns
+	Roles       map[string][]string `yaml:"roles"`       // role -> permissions
+	// CredentialRules are checked in order, the first rule matching a method wins, token is the default.
+	CredentialRules []CredentialRule `yaml:"credentials"`
+	Audit           []string         `yaml:"audit"` // method patterns whose every call is audited
+}
+
+// LoadPolicy reads a YAML or JSON policy file.
+func LoadPolicy(filename string) (*Policy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// reject unknown keys, so a typo fails instead of silently dropping a rule
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	policy := &Policy{}
+	err = decoder.Decode(policy)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse policy %s: %w", filename, err)
+	}
+
+	return policy, nil
+}
+
+// Access implements AccessPolicy.
+func (policy *Policy) Access(method string) (bool, []string) {
+	if matchAny(policy.Public, method) {
+		return true, nil
+	}
+
+	granting := make(map[string]bool)
+	for permission, patterns := range policy.Permissions {
+		if matchAny(patterns, method) {
+			granting[permission] = true
+		}
+	}
+	if len(granting) == 0 {
+		return !policy.DefaultDeny, nil
+	}
+
+	// a permission no role holds still covers the method, so it is denied rather than public
+	var roles []string
+	for role, permissions := range policy.Roles {
+		if slices.ContainsFunc(permissions, func(permission string) bool { return granting[permission] }) {
+			roles = append(roles, role)
+		}
+	}
+
+	sort.Strings(roles)
+	return false, roles
+}
+
+// Credentials implements AccessPolicy.
+func (policy *Policy) Credentials(method string) string {
+	for _, rule := range policy.CredentialRules {
+		if matchAny(rule.Methods, method) {
+			return rule.Accept
+		}
+	}
+
+	return CredentialToken
+}
+
+// Audited implements AccessPolicy.
+func (policy *Policy) Audited(method string) bool {
+	return matchAny(policy.Audit, method)
+}
+
+// RolePermissions implements AccessPolicy.
+func (policy *Policy) RolePermissions(role string) ([]string, bool) {
+	permissions, ok := policy.Roles[role]
+	return permissions, ok
+}
+
+// Validate checks the policy is well formed and that every method pattern matches one of methods.
+func (policy *Policy) Validate(methods []string) error {
+	check := func(owner string, patterns []string) error {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s: malformed method pattern %q: %w", owner, pattern, err)
+			}
+			if !slices.ContainsFunc(methods, func(method string) bool { return matchPattern(pattern, method) }) {
+				return fmt.Errorf("%s: method pattern %q matches no registered method", owner, pattern)
+			}
+		}
+		return nil
+	}
+
+	err := check("public", policy.Public)
+	if err != nil {
+		return err
+	}
+	err = check("audit", policy.Audit)
+	if err != nil {
+		return err
+	}
+	for permission, patterns := range policy.Permissions {
+		err := check("permission "+permission, patterns)
+		if err != nil {
+			return err
+		}
+	}
+	for role, permissions := range policy.Roles {
+		for _, permission := range permissions {
+			if _, ok := policy.Permissions[permission]; !ok {
+				return fmt.Errorf("role %s: unknown permission %q", role, permission)
+			}
+		}
+	}
+	for i, rule := range policy.CredentialRules {
+		if !slices.Contains([]string{CredentialToken, CredentialCert, CredentialAny}, rule.Accept) {
+			return fmt.Errorf("credentials rule %d: unknown credential kind %q", i, rule.Accept)
+		}
+		err := check(fmt.Sprintf("credentials rule %d", i), rule.Methods)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func matchAny(patterns []string, method string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool { return matchPattern(pattern, method) })
+}
+
+func matchPattern(pattern, method string) bool {
+	ok, err := path.Match(pattern, method)
+	return err == nil && ok
+}
+
+// ServiceMethods lists the full method names of the services registered on server,
+// resolved through their reflection descriptors.
+func ServiceMethods(server *grpc.Server) ([]string, error) {
+	var methods []string
+	for name := range server.GetServiceInfo() {
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("cannot find descriptor of service %s: %w", name, err)
+		}
+
+		service, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("descriptor %s is not a service", name)
+		}
+
+		for i := range service.Methods().Len() {
+			methods = append(methods, fmt.Sprintf("/%s/%s", service.FullName(), service.Methods().Get(i).Name()))
+		}
+	}
+
+	sort.Strings(methods)
+	return methods, nil
+}
+
+// PolicyWatcher serves the policy of a file and hot reloads it when the file changes.
+// A policy that fails to load or validate is logged and the previous one is kept.
+type PolicyWatcher struct {
+	filename string
+	methods  []string
+	roles    map[string][]string // replaces the roles of the file when set
+	policy   atomic.Pointer[Policy]
+	modTime  time.Time
+	size     int64
+}
+
+// NewPolicyWatcher loads the policy file, call Validate once the services are registered.
+func NewPolicyWatcher(filename string) (*PolicyWatcher, error) {
+	watcher := &PolicyWatcher{filename: filename}
+
+	err := watcher.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return watcher, nil
+}
+
+// Validate checks the current policy against methods, which later reloads are validated against as well.
+func (watcher *PolicyWatcher) Validate(methods []string) error {
+	watcher.methods = methods
+
+	err := watcher.policy.Load().Validate(methods)
+	if err != nil {
+		return fmt.Errorf("invalid policy %s: %w", watcher.filename, err)
+	}
+
+	return nil
+}
+
+// SetRoles replaces the roles of the policy file, now and on every reload, with roles mapping
+// each role to its permissions. Call it before Validate and Watch.
+func (watcher *PolicyWatcher) SetRoles(roles map[string][]string) {
+	watcher.roles = roles
+
+	policy := *watcher.policy.Load()
+	policy.Roles = roles
+	watcher.policy.Store(&policy)
+}
+
+// Access implements AccessPolicy with the current policy.
+func (watcher *PolicyWatcher) Access(method string) (bool, []string) {
+	return watcher.policy.Load().Access(method)
+}
+
+// Credentials implements AccessPolicy with the current policy.
+func (watcher *PolicyWatcher) Credentials(method string) string {
+	return watcher.policy.Load().Credentials(method)
+}
+
+// Audited implements AccessPolicy with the current policy.
+func (watcher *PolicyWatcher) Audited(method string) bool {
+	return watcher.policy.Load().Audited(method)
+}
+
+// RolePermissions implements AccessPolicy with the current policy.
+func (watcher *PolicyWatcher) RolePermissions(role string) ([]string, bool) {
+	return watcher.policy.Load().RolePermissions(role)
+}
+
+// Watch polls the policy file every interval until ctx is done.
+func (watcher *PolicyWatcher) Watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(watcher.filename)
+		if err != nil {
+			log.Printf("cannot stat policy %s: %s", watcher.filename, err)
+			continue
+		}
+		if info.ModTime().Equal(watcher.modTime) && info.Size() == watcher.size {
+			continue
+		}
+
+		err = watcher.reload()
+		if err != nil {
+			log.Printf("keep previous policy, cannot reload %s: %s", watcher.filename, err)
+			continue
+		}
+		log.Printf("policy %s reloaded", watcher.filename)
+	}
+}
+
+func (watcher *PolicyWatcher) reload() error {
+	info, err := os.Stat(watcher.filename)
+	if err != nil {
+		return err
+	}
+	// remember the file version even when it is invalid, so a broken file is reported once
+	watcher.modTime, watcher.size = info.ModTime(), info.Size()
+
+	policy, err := LoadPolicy(watcher.filename)
+	if err != nil {
+		return err
+	}
+	if watcher.roles != nil {
+		policy.Roles = watcher.roles
+	}
+
+	if watcher.methods != nil {
+		err = policy.Validate(watcher.methods)
+		if err != nil {
+			return fmt.Errorf("invalid policy %s: %w", watcher.filename, err)
+		}
+	}
+
+	watcher.policy.Store(policy)
+	return nil
+}
@@ -0,0 +1,115 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyAccess(t *testing.T) {
+	t.Parallel()
+
+	policy := &service.Policy{
+		DefaultDeny: true,
+		Public:      []string{"/AuthService/Login"},
+		Permissions: map[string][]string{
+			"laptop.read":  {"/LaptopService/SearchLaptop"},
+			"laptop.admin": {"/LaptopService/*"},
+			"route.chat":   {"/RouteGuide/RouteChat"},
+		},
+		Roles: map[string][]string{
+			"admin": {"laptop.admin"},
+			"user":  {"laptop.read"},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		method      string
+		defaultDeny bool
+		public      bool
+		roles       []string
+	}{
+		{name: "public", method: "/AuthService/Login", defaultDeny: true, public: true},
+		{name: "exact and wildcard", method: "/LaptopService/SearchLaptop", defaultDeny: true, roles: []string{"admin", "user"}},
+		{name: "wildcard", method: "/LaptopService/CreateLaptop", defaultDeny: true, roles: []string{"admin"}},
+		{name: "permission held by no role", method: "/RouteGuide/RouteChat", defaultDeny: false},
+		{name: "uncovered with default deny", method: "/RouteGuide/GetFeature", defaultDeny: true},
+		{name: "uncovered without default deny", method: "/RouteGuide/GetFeature", defaultDeny: false, public: true},
+	}
+
+	for _, currCase := range testCases {
+		t.Run(currCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := *policy
+			p.DefaultDeny = currCase.defaultDeny
+			public, roles := p.Access(currCase.method)
+			require.Equal(t, currCase.public, public)
+			require.Equal(t, currCase.roles, roles)
+		})
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	t.Parallel()
+
+	methods := []string{"/AuthService/Login", "/LaptopService/CreateLaptop", "/LaptopService/SearchLaptop"}
+	dir := t.TempDir()
+
+	testCases := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{
+			name:    "valid",
+			content: "default_deny: true\npublic: [/AuthService/Login]\npermissions:\n  laptop: [/LaptopService/*]\nroles:\n  admin: [laptop]\ncredentials:\n  - methods: [/LaptopService/*]\n    accept: any\n",
+			valid:   true,
+		},
+		{
+			name:    "json",
+			content: `{"public": ["/AuthService/Login"], "permissions": {"laptop": ["/LaptopService/SearchLaptop"]}}`,
+			valid:   true,
+		},
+		{
+			name:    "method typo",
+			content: "permissions:\n  laptop: [/LaptopService/CreateLaptopp]\n",
+		},
+		{
+			name:    "unknown credential kind",
+			content: "credentials:\n  - methods: [/LaptopService/*]\n    accept: password\n",
+		},
+		{
+			name:    "unknown permission",
+			content: "roles:\n  admin: [laptop]\n",
+		},
+		{
+			name:    "malformed pattern",
+			content: "public: ['/AuthService/[']\n",
+		},
+	}
+
+	for i, currCase := range testCases {
+		filename := filepath.Join(dir, currCase.name+".yaml")
+		require.NoError(t, os.WriteFile(filename, []byte(currCase.content), 0o600), i)
+
+		policy, err := service.LoadPolicy(filename)
+		require.NoError(t, err, currCase.name)
+
+		err = policy.Validate(methods)
+		if currCase.valid {
+			require.NoError(t, err, currCase.name)
+		} else {
+			require.Error(t, err, currCase.name)
+		}
+	}
+
+	filename := filepath.Join(dir, "unknown-key.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte("defualt_deny: true\n"), 0o600))
+	_, err := service.LoadPolicy(filename)
+	require.Error(t, err)
+}
@@ -1,31 +1,28 @@
 package service
 
-import (
-	"golang.org/x/crypto/bcrypt"
-)
-
 // Account represents a user account in the system.
 type Account struct {
 	Username, HashedPassword, Role string
+	Tenant                         string // organisation the account acts for
 }
 
-// NewAccount creates a new account with the given username, password, and role.
-func NewAccount(username, password, role string) (*Account, error) {
-	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// NewAccount creates a new account with the given username, password, and role, hashing the password with hasher.
+func NewAccount(username, password, role string, hasher PasswordHasher) (*Account, error) {
+	hashed, err := hasher.Hash(password)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Account{
 		Username:       username,
-		HashedPassword: string(hashed),
+		HashedPassword: hashed,
 		Role:           role,
 	}, nil
 }
 
-// IsCorrectPassword checks if the provided password matches the account's hashed password.
+// IsCorrectPassword checks if the provided password matches the account's hashed password, whatever algorithm hashed it.
 func (acc *Account) IsCorrectPassword(password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(acc.HashedPassword), []byte(password))
+	return verifyPassword(acc.HashedPassword, password)
 }
 
 // Clone creates a copy of the account.
@@ -34,5 +31,6 @@ func (acc *Account) Clone() *Account {
 		Username:       acc.Username,
 		HashedPassword: acc.HashedPassword,
 		Role:           acc.Role,
+		Tenant:         acc.Tenant,
 	}
 }
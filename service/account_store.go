@@ -11,6 +11,9 @@ type AccountStore interface {
 
 	// Find retrieves an account by its username.
 	Find(username string) (*Account, error)
+
+	// Update replaces an existing account.
+	Update(account *Account) error
 }
 
 // InMemoryAccountStore is an in-memory implementation of the AccountStore interface.
@@ -49,3 +52,15 @@ func (acc *InMemoryAccountStore) Find(username string) (*Account, error) {
 
 	return nil, fmt.Errorf("account with username %s not found", username)
 }
+
+func (acc *InMemoryAccountStore) Update(account *Account) error {
+	acc.mutex.Lock()
+	defer acc.mutex.Unlock()
+
+	if acc.accounts[account.Username] == nil {
+		return fmt.Errorf("account with username %s not found", account.Username)
+	}
+
+	acc.accounts[account.Username] = account.Clone()
+	return nil
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKey is a long-lived credential of a machine client. Only the SHA-256 of the secret is kept.
+type APIKey struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Tenant    string    `json:"tenant"`
+	Methods   []string  `json:"methods,omitempty"` // full method patterns the key is limited to
+	CreatedBy string    `json:"created_by"`
+	HashedKey []byte    `json:"hashed_key"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"` // zero when the key never expires
+	RevokedAt time.Time `json:"revoked_at,omitzero"` // zero while the key is not revoked
+}
+
+// NewAPIKey creates an API key acting as role for the creator, and returns it with its secret "<id>.<secret>" form.
+// A zero ttl creates a key that never expires.
+func NewAPIKey(name, role string, methods []string, creator *Payload, ttl time.Duration) (*APIKey, string, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, "", err
+	}
+
+	raw := id.String() + "." + rand.Text()
+	hashed := sha256.Sum256([]byte(raw))
+
+	now := time.Now()
+	key := &APIKey{
+		ID:        id.String(),
+		Name:      name,
+		Role:      role,
+		Tenant:    creator.Tenant,
+		Methods:   methods,
+		CreatedBy: creator.Username,
+		HashedKey: hashed[:],
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		key.ExpiresAt = now.Add(ttl)
+	}
+
+	return key, raw, nil
+}
+
+// IsCorrectKey checks raw is the secret of the key.
+func (key *APIKey) IsCorrectKey(raw string) bool {
+	hashed := sha256.Sum256([]byte(raw))
+	return subtle.ConstantTimeCompare(hashed[:], key.HashedKey) == 1
+}
+
+// IsValid checks the key is neither revoked nor expired at now.
+func (key *APIKey) IsValid(now time.Time) error {
+	if !key.RevokedAt.IsZero() {
+		return errors.New("api key revoked")
+	}
+	if !key.ExpiresAt.IsZero() && now.After(key.ExpiresAt) {
+		return errors.New("api key expired")
+	}
+	return nil
+}
+
+// Allows reports whether the key may call method.
+func (key *APIKey) Allows(method string) bool {
+	return matchAny(key.Methods, method)
+}
+
+// Clone creates a copy of the API key.
+func (key *APIKey) Clone() *APIKey {
+	other := *key
+	other.Methods = append([]string(nil), key.Methods...)
+	other.HashedKey = append([]byte(nil), key.HashedKey...)
+	return &other
+}
+
+// apiKeyID returns the id part of a raw "<id>.<secret>" key.
+func apiKeyID(raw string) (string, error) {
+	id, _, ok := strings.Cut(raw, ".")
+	if !ok || id == "" {
+		return "", errors.New("malformed api key")
+	}
+	return id, nil
+}
+
+// APIKeyStore defines the interface for storing API keys.
+type APIKeyStore interface {
+	// Save persists a new API key.
+	Save(key *APIKey) error
+
+	// Find retrieves an API key by its ID.
+	Find(id string) (*APIKey, error)
+
+	// List returns every API key ordered by creation time, revoked ones included.
+	List() ([]*APIKey, error)
+
+	// Revoke marks an API key as revoked at the given time and returns it.
+	Revoke(id string, at time.Time) (*APIKey, error)
+}
+
+// InMemoryAPIKeyStore is an in-memory implementation of the APIKeyStore interface.
+type InMemoryAPIKeyStore struct {
+	mutex sync.RWMutex
+	keys  map[string]*APIKey
+}
+
+func NewInMemoryAPIKeyStore() APIKeyStore {
+	return &InMemoryAPIKeyStore{
+		keys: make(map[string]*APIKey),
+	}
+}
+
+func (store *InMemoryAPIKeyStore) Save(key *APIKey) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.keys[key.ID] != nil {
+		return fmt.Errorf("api key with id %s already exists", key.ID)
+	}
+
+	store.keys[key.ID] = key.Clone()
+	return nil
+}
+
+func (store *InMemoryAPIKeyStore) Find(id string) (*APIKey, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	key, ok := store.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	return key.Clone(), nil
+}
+
+func (store *InMemoryAPIKeyStore) List() ([]*APIKey, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	keys := make([]*APIKey, 0, len(store.keys))
+	for _, key := range store.keys {
+		keys = append(keys, key.Clone())
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+
+	return keys, nil
+}
+
+func (store *InMemoryAPIKeyStore) Revoke(id string, at time.Time) (*APIKey, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	key, ok := store.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	if key.RevokedAt.IsZero() {
+		key.RevokedAt = at
+	}
+
+	return key.Clone(), nil
+}
+
+// FileAPIKeyStore is an APIKeyStore kept in memory and persisted as JSON lines to an append-only
+// file. A key is written when it is created and again when it is revoked, the last line of a key
+// wins on load. Only the hashes of the secrets are written.
+type FileAPIKeyStore struct {
+	mutex sync.RWMutex
+	file  *os.File
+	keys  map[string]*APIKey
+}
+
+// OpenFileAPIKeyStore loads the API keys of filename, creating it when missing.
+// An empty filename keeps the keys in memory only.
+func OpenFileAPIKeyStore(filename string) (*FileAPIKeyStore, error) {
+	store := &FileAPIKeyStore{keys: make(map[string]*APIKey)}
+	if filename == "" {
+		return store, nil
+	}
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		key := &APIKey{}
+		err := json.Unmarshal(scanner.Bytes(), key)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("api keys %s: cannot parse line %d: %w", filename, line, err)
+		}
+		store.keys[key.ID] = key
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	store.file = file
+	return store, nil
+}
+
+func (store *FileAPIKeyStore) Save(key *APIKey) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.keys[key.ID] != nil {
+		return fmt.Errorf("api key with id %s already exists", key.ID)
+	}
+
+	err := store.write(key)
+	if err != nil {
+		return err
+	}
+
+	store.keys[key.ID] = key.Clone()
+	return nil
+}
+
+func (store *FileAPIKeyStore) Find(id string) (*APIKey, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	key, ok := store.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	return key.Clone(), nil
+}
+
+func (store *FileAPIKeyStore) List() ([]*APIKey, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	keys := make([]*APIKey, 0, len(store.keys))
+	for _, key := range store.keys {
+		keys = append(keys, key.Clone())
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+
+	return keys, nil
+}
+
+func (store *FileAPIKeyStore) Revoke(id string, at time.Time) (*APIKey, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	key, ok := store.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	if key.RevokedAt.IsZero() {
+		revoked := key.Clone()
+		revoked.RevokedAt = at
+		err := store.write(revoked)
+		if err != nil {
+			return nil, err
+		}
+		store.keys[id] = revoked
+		key = revoked
+	}
+
+	return key.Clone(), nil
+}
+
+// write appends key to the file, if any.
+func (store *FileAPIKeyStore) write(key *APIKey) error {
+	if store.file == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.file.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("cannot write api key: %w", err)
+	}
+	return nil
+}
+
+// CheckHealth makes sure the API keys file is still open and on disk.
+func (store *FileAPIKeyStore) CheckHealth(ctx context.Context) error {
+	if store.file == nil {
+		return nil
+	}
+
+	_, err := os.Stat(store.file.Name())
+	return err
+}
+
+// Close closes the underlying file.
+func (store *FileAPIKeyStore) Close() error {
+	if store.file == nil {
+		return nil
+	}
+	return errors.Join(store.file.Sync(), store.file.Close())
+}
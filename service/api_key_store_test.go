@@ -0,0 +1,59 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileAPIKeyStore(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "api_keys.jsonl")
+	store, err := service.OpenFileAPIKeyStore(filename)
+	require.NoError(t, err)
+
+	creator := &service.Payload{Username: "admin_valid", Role: "admin", Tenant: "acme"}
+	batch, batchSecret, err := service.NewAPIKey("batch", "user", []string{"/LaptopService/*"}, creator, 0)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(batch))
+	report, _, err := service.NewAPIKey("report", "user", nil, creator, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(report))
+	require.Error(t, store.Save(batch), "duplicate id")
+
+	revokedAt := time.Now().Truncate(time.Second)
+	_, err = store.Revoke(report.ID, revokedAt)
+	require.NoError(t, err)
+	revoked, err := store.Revoke(report.ID, revokedAt.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, revokedAt.Equal(revoked.RevokedAt), "revoking twice keeps the first time")
+	require.NoError(t, store.Close())
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), batchSecret)
+	require.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 3)
+
+	// the keys and their revocation survive a restart
+	store, err = service.OpenFileAPIKeyStore(filename)
+	require.NoError(t, err)
+	defer store.Close()
+
+	keys, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Equal(t, []string{"/LaptopService/*"}, keys[0].Methods)
+	require.True(t, keys[0].IsCorrectKey(batchSecret))
+	require.NoError(t, keys[0].IsValid(time.Now()))
+	require.Error(t, keys[1].IsValid(time.Now()))
+	require.Equal(t, "acme", keys[1].Tenant)
+
+	_, err = store.Find("missing")
+	require.ErrorIs(t, err, service.ErrAPIKeyNotFound)
+}
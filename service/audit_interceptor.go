@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/go-http-server/grpc/protoc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// auditRecord collects what the inner interceptors learn about a call, e.g. the authenticated caller.
+type auditRecord struct {
+	payload *Payload
+}
+
+type auditRecordContextKey struct{}
+
+// setAuditPayload records the authenticated caller for the audit event of the call, if it is audited.
+func setAuditPayload(ctx context.Context, payload *Payload) {
+	if record, ok := ctx.Value(auditRecordContextKey{}).(*auditRecord); ok {
+		record.payload = payload
+	}
+}
+
+// AuditInterceptor records denied calls and calls of audited methods in an AuditLog.
+// It must run before the AuthInterceptor to see its denials.
+type AuditInterceptor struct {
+	auditLog AuditLog
+	policy   AccessPolicy
+}
+
+// NewAuditInterceptor creates a new AuditInterceptor with the given audit log and access policy.
+func NewAuditInterceptor(auditLog AuditLog, policy AccessPolicy) *AuditInterceptor {
+	return &AuditInterceptor{auditLog: auditLog, policy: policy}
+}
+
+// Unary returns a unary server interceptor that audits the call once it completes.
+func (interceptor *AuditInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		record := &auditRecord{}
+		res, err := handler(context.WithValue(ctx, auditRecordContextKey{}, record), req)
+
+		interceptor.audit(ctx, info.FullMethod, record, req, res, err)
+		return res, err
+	}
+}
+
+// Stream returns a stream server interceptor that audits the call once it completes.
+func (interceptor *AuditInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		record := &auditRecord{}
+		ctx := context.WithValue(ss.Context(), auditRecordContextKey{}, record)
+		err := handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+
+		interceptor.audit(ss.Context(), info.FullMethod, record, nil, nil, err)
+		return err
+	}
+}
+
+func (interceptor *AuditInterceptor) audit(ctx context.Context, method string, record *auditRecord, req, res any, err error) {
+	code := status.Code(err)
+	denied := code == codes.Unauthenticated || code == codes.PermissionDenied
+	if !denied && !interceptor.policy.Audited(method) {
+		return
+	}
+
+	event := AuditEvent{
+		Time:       time.Now().UTC(),
+		Peer:       peerAddress(ctx),
+		Method:     method,
+		ResourceID: resourceID(res, req),
+		Outcome:    code.String(),
+	}
+	if record.payload != nil {
+		event.Principal, event.Role, event.Tenant = record.payload.Username, record.payload.Role, record.payload.Tenant
+	} else if login, ok := req.(interface{ GetUsername() string }); ok {
+		// unauthenticated calls such as Login name their principal in the request
+		event.Principal = login.GetUsername()
+	}
+
+	err = interceptor.auditLog.Append(event)
+	if err != nil {
+		log.Printf("cannot append audit event for %s: %s", method, err)
+	}
+}
+
+// resourceID returns the id of the resource a call acted on, looked up in the given messages in order.
+func resourceID(messages ...any) string {
+	for _, message := range messages {
+		id := ""
+		switch m := message.(type) {
+		case interface{ GetLaptopId() string }:
+			id = m.GetLaptopId()
+		case interface{ GetId() string }:
+			id = m.GetId()
+		case interface{ GetApiKey() *protoc.ApiKey }:
+			id = m.GetApiKey().GetId()
+		}
+
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AuditEvent is one entry of the audit trail. Each event hashes the previous one, so editing,
+// dropping or reordering events breaks the chain.
+type AuditEvent struct {
+	Sequence   uint64    `json:"seq"`
+	Time       time.Time `json:"time"`
+	Principal  string    `json:"principal"`
+	Role       string    `json:"role,omitempty"`
+	Tenant     string    `json:"tenant,omitempty"`
+	Peer       string    `json:"peer"`
+	Method     string    `json:"method"`
+	ResourceID string    `json:"resource_id,omitempty"`
+	Outcome    string    `json:"outcome"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+}
+
+// computeHash returns the hex SHA-256 of the event with an empty Hash field.
+func (event AuditEvent) computeHash() (string, error) {
+	event.Hash = ""
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditFilter selects the audit events of a tenant, its other zero fields match everything.
+type AuditFilter struct {
+	Tenant    string    // the events of no tenant, such as logins of unknown usernames, match an empty tenant only
+	From      time.Time // inclusive
+	To        time.Time // exclusive
+	Principal string
+	Limit     int // most recent events kept
+}
+
+func (filter AuditFilter) match(event *AuditEvent) bool {
+	if event.Tenant != filter.Tenant {
+		return false
+	}
+	if !filter.From.IsZero() && event.Time.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !event.Time.Before(filter.To) {
+		return false
+	}
+	return filter.Principal == "" || filter.Principal == event.Principal
+}
+
+// auditEventsRetained is the number of most recent events a FileAuditLog keeps at least in memory to
+// list them, up to twice as many, the older ones are read back from the file.
+const auditEventsRetained = 10000
+
+// AuditLog defines the interface of an append-only audit trail.
+type AuditLog interface {
+	// Append chains the event after the last one and persists it.
+	Append(event AuditEvent) error
+
+	// List returns the recent events matching filter, oldest first.
+	List(filter AuditFilter) ([]AuditEvent, error)
+
+	// Verify checks the hash chain of every persisted event, and that it ends with the last event
+	// appended.
+	Verify() error
+
+	// Verified returns the result of the last verification, without verifying again.
+	Verified() error
+}
+
+// FileAuditLog is an AuditLog persisted as JSON lines to an append-only file. Only the most recent
+// events are kept in memory, the file is verified by reading it back, anchored on the last event
+// appended, so edits of the file while the server runs are detected as well.
+type FileAuditLog struct {
+	mutex     sync.RWMutex
+	file      *os.File
+	events    []*AuditEvent // the most recent ones, the last one is the head of the chain
+	verifyErr error         // result of the last verification
+}
+
+// OpenFileAuditLog loads and verifies the audit trail of filename, creating it when missing. A broken
+// chain does not keep the trail from growing, it is reported by Verified until the file is repaired.
+// An empty filename keeps the trail in memory only.
+func OpenFileAuditLog(filename string) (*FileAuditLog, error) {
+	auditLog := &FileAuditLog{}
+	if filename == "" {
+		return auditLog, nil
+	}
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain auditChain
+	err = chain.read(file, func(event *AuditEvent) bool {
+		auditLog.retain(event)
+		return true
+	})
+	if err != nil {
+		// the chain cannot be continued after an unreadable event
+		file.Close()
+		return nil, fmt.Errorf("audit log %s: %w", filename, err)
+	}
+	if chain.err != nil {
+		auditLog.verifyErr = fmt.Errorf("audit log %s: %w", filename, chain.err)
+	}
+
+	auditLog.file = file
+	return auditLog, nil
+}
+
+func (auditLog *FileAuditLog) Append(event AuditEvent) error {
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+
+	event.Sequence = 1
+	event.PrevHash = ""
+	if n := len(auditLog.events); n > 0 {
+		event.Sequence = auditLog.events[n-1].Sequence + 1
+		event.PrevHash = auditLog.events[n-1].Hash
+	}
+
+	hash, err := event.computeHash()
+	if err != nil {
+		return err
+	}
+	event.Hash = hash
+
+	if auditLog.file != nil {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		_, err = auditLog.file.Write(append(data, '\n'))
+		if err != nil {
+			return fmt.Errorf("cannot write audit event: %w", err)
+		}
+	}
+
+	auditLog.retain(&event)
+	return nil
+}
+
+// retain keeps event in memory, dropping the oldest events once twice auditEventsRetained are kept.
+func (auditLog *FileAuditLog) retain(event *AuditEvent) {
+	if len(auditLog.events) >= 2*auditEventsRetained {
+		auditLog.events = slices.Clone(auditLog.events[len(auditLog.events)-auditEventsRetained:])
+	}
+	auditLog.events = append(auditLog.events, event)
+}
+
+// List reads the events older than the ones kept in memory back from the file, when they may match
+// filter and the recent ones are not enough.
+func (auditLog *FileAuditLog) List(filter AuditFilter) ([]AuditEvent, error) {
+	auditLog.mutex.RLock()
+	// the events are never modified, and the retained ones only replaced by a copy
+	retained := auditLog.events
+	auditLog.mutex.RUnlock()
+
+	var events []AuditEvent
+	for _, event := range retained {
+		if filter.match(event) {
+			events = append(events, *event)
+		}
+	}
+
+	// the events are appended in time order, none older than the first one retained is after filter.From
+	if auditLog.file != nil && len(retained) > 0 && retained[0].Sequence > 1 &&
+		(filter.Limit == 0 || len(events) < filter.Limit) && !filter.From.After(retained[0].Time) {
+		older, err := auditLog.listFile(filter, retained[0].Sequence)
+		if err != nil {
+			return nil, err
+		}
+		events = append(older, events...)
+	}
+
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[len(events)-filter.Limit:]
+	}
+
+	return events, nil
+}
+
+// listFile reads the file back up to the sequence before, and returns the events matching filter.
+func (auditLog *FileAuditLog) listFile(filter AuditFilter, before uint64) ([]AuditEvent, error) {
+	file, err := os.Open(auditLog.file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("cannot read audit log: %w", err)
+	}
+	defer file.Close()
+
+	var events []AuditEvent
+	var chain auditChain
+	err = chain.read(file, func(event *AuditEvent) bool {
+		if event.Sequence >= before {
+			return false
+		}
+		if filter.match(event) {
+			events = append(events, *event)
+		}
+		// only the most recent events are returned, up to twice as many are kept meanwhile
+		if filter.Limit > 0 && len(events) >= 2*filter.Limit {
+			events = slices.Clone(events[len(events)-filter.Limit:])
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Verify checks the events kept in memory and the file up to the head of the chain when Verify is
+// called, without holding back the events appended meanwhile.
+func (auditLog *FileAuditLog) Verify() error {
+	auditLog.mutex.RLock()
+	// the events are never modified, and the retained ones only replaced by a copy
+	events := auditLog.events
+	auditLog.mutex.RUnlock()
+
+	err := verifyEvents(events)
+	if auditLog.file != nil && len(events) > 0 {
+		err = errors.Join(err, auditLog.verifyFile(events[len(events)-1]))
+	}
+
+	auditLog.mutex.Lock()
+	auditLog.verifyErr = err
+	auditLog.mutex.Unlock()
+	return err
+}
+
+func (auditLog *FileAuditLog) Verified() error {
+	auditLog.mutex.RLock()
+	defer auditLog.mutex.RUnlock()
+
+	return auditLog.verifyErr
+}
+
+// verifyEvents checks the chain of the events kept in memory.
+func verifyEvents(events []*AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	chain := auditChain{last: &AuditEvent{Sequence: events[0].Sequence - 1, Hash: events[0].PrevHash}}
+	for _, event := range events {
+		chain.add(event)
+	}
+	return chain.err
+}
+
+// verifyFile reads the file back up to the sequence of head, the events appended since are not read,
+// and checks the chain ends with head.
+func (auditLog *FileAuditLog) verifyFile(head *AuditEvent) error {
+	file, err := os.Open(auditLog.file.Name())
+	if err != nil {
+		return fmt.Errorf("cannot read audit log: %w", err)
+	}
+	defer file.Close()
+
+	var chain auditChain
+	err = chain.read(file, func(event *AuditEvent) bool {
+		return event.Sequence < head.Sequence
+	})
+	if err != nil {
+		return err
+	}
+	if chain.err != nil {
+		return chain.err
+	}
+	if chain.last == nil || chain.last.Sequence != head.Sequence || chain.last.Hash != head.Hash {
+		return errors.New("audit log does not end with the last event appended, it was truncated or rewritten")
+	}
+	return nil
+}
+
+// Watch verifies the audit trail every interval until ctx is done, logging a broken chain.
+func (auditLog *FileAuditLog) Watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			err := auditLog.Verify()
+			if err != nil {
+				log.Printf("audit log verification failed: %s", err)
+			}
+		}
+	}
+}
+
+// auditChain checks events follow each other in a hash chain.
+type auditChain struct {
+	last *AuditEvent // nil before the first event
+	err  error       // first break of the chain
+}
+
+// read adds the events of r to the chain, passing each one to f, until f returns false. It fails on an
+// event it cannot decode, the breaks of the chain are recorded in chain.err.
+func (chain *auditChain) read(r io.Reader, f func(event *AuditEvent) bool) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		event := &AuditEvent{}
+		err := json.Unmarshal(scanner.Bytes(), event)
+		if err != nil {
+			return fmt.Errorf("cannot parse event %d: %w", line, err)
+		}
+
+		chain.add(event)
+		if !f(event) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// add makes event the last one of the chain, recording the first event not following its predecessor.
+func (chain *auditChain) add(event *AuditEvent) {
+	var sequence uint64 = 1
+	prevHash := ""
+	if chain.last != nil {
+		sequence, prevHash = chain.last.Sequence+1, chain.last.Hash
+	}
+	chain.last = event
+	if chain.err != nil {
+		return
+	}
+
+	switch hash, err := event.computeHash(); {
+	case event.Sequence != sequence:
+		chain.err = fmt.Errorf("audit event %d has sequence %d", sequence, event.Sequence)
+	case event.PrevHash != prevHash:
+		chain.err = fmt.Errorf("audit event %d is not chained to its predecessor", event.Sequence)
+	case err != nil:
+		chain.err = err
+	case hash != event.Hash:
+		chain.err = fmt.Errorf("audit event %d was tampered with", event.Sequence)
+	}
+}
+
+// Close closes the underlying file.
+func (auditLog *FileAuditLog) Close() error {
+	if auditLog.file == nil {
+		return nil
+	}
+	return errors.Join(auditLog.file.Sync(), auditLog.file.Close())
+}
@@ -0,0 +1,143 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileAuditLogChain(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := service.OpenFileAuditLog(filename)
+	require.NoError(t, err)
+
+	start := time.Now().UTC()
+	for _, principal := range []string{"admin_valid", "user", "admin_valid"} {
+		err := auditLog.Append(service.AuditEvent{Time: time.Now().UTC(), Principal: principal, Tenant: "acme", Method: "/LaptopService/CreateLaptop", Outcome: "OK"})
+		require.NoError(t, err)
+	}
+	require.NoError(t, auditLog.Close())
+
+	// the chain survives a restart and keeps growing from the last event
+	auditLog, err = service.OpenFileAuditLog(filename)
+	require.NoError(t, err)
+	require.NoError(t, auditLog.Append(service.AuditEvent{Time: time.Now().UTC(), Principal: "user", Tenant: "globex", Outcome: "PermissionDenied"}))
+
+	events, err := auditLog.List(service.AuditFilter{Tenant: "acme", From: start, Principal: "admin_valid"})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.EqualValues(t, 3, events[1].Sequence)
+
+	events, err = auditLog.List(service.AuditFilter{Tenant: "globex", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.EqualValues(t, 4, events[0].Sequence)
+
+	// the events of a tenant are only listed to it
+	events, err = auditLog.List(service.AuditFilter{Tenant: "acme", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.EqualValues(t, 3, events[0].Sequence)
+	events, err = auditLog.List(service.AuditFilter{})
+	require.NoError(t, err)
+	require.Empty(t, events)
+	require.NoError(t, auditLog.Close())
+
+	// editing any event breaks the chain, also while the log is open
+	auditLog, err = service.OpenFileAuditLog(filename)
+	require.NoError(t, err)
+	defer auditLog.Close()
+	require.NoError(t, auditLog.Verify())
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	tampered := strings.Replace(string(data), `"principal":"user"`, `"principal":"admin_valid"`, 1)
+	require.NoError(t, os.WriteFile(filename, []byte(tampered), 0o600))
+
+	require.ErrorContains(t, auditLog.Verify(), "tampered")
+	require.ErrorContains(t, auditLog.Verified(), "tampered")
+
+	// rewriting the file as a valid chain of fewer events is caught by the last event appended
+	lines := strings.SplitAfter(string(data), "\n")
+	require.NoError(t, os.WriteFile(filename, []byte(strings.Join(lines[:2], "")), 0o600))
+	require.ErrorContains(t, auditLog.Verify(), "truncated")
+	require.NoError(t, os.WriteFile(filename, data, 0o600))
+	require.NoError(t, auditLog.Verify())
+	require.NoError(t, auditLog.Verified())
+
+	// a broken chain is reported on open without keeping the trail from growing
+	require.NoError(t, os.WriteFile(filename, []byte(tampered), 0o600))
+	reopened, err := service.OpenFileAuditLog(filename)
+	require.NoError(t, err)
+	defer reopened.Close()
+	require.ErrorContains(t, reopened.Verified(), "tampered")
+	require.NoError(t, reopened.Append(service.AuditEvent{Time: time.Now().UTC(), Principal: "user", Outcome: "OK"}))
+	events, err = reopened.List(service.AuditFilter{})
+	require.NoError(t, err)
+	require.EqualValues(t, 5, events[len(events)-1].Sequence)
+}
+
+func TestFileAuditLogVerifyWhileAppending(t *testing.T) {
+	t.Parallel()
+
+	auditLog, err := service.OpenFileAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	defer auditLog.Close()
+
+	// the events appended while the file is read back are left to the next verification
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 500 {
+			auditLog.Append(service.AuditEvent{Time: time.Now().UTC(), Principal: "user", Outcome: "OK"})
+		}
+	}()
+	for verified := false; !verified; {
+		select {
+		case <-done:
+			verified = true
+		default:
+		}
+		require.NoError(t, auditLog.Verify())
+	}
+}
+
+func TestFileAuditLogListOlderEvents(t *testing.T) {
+	t.Parallel()
+
+	auditLog, err := service.OpenFileAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	defer auditLog.Close()
+
+	// the first events of acme are no longer kept in memory once globex appended many more
+	start := time.Now().UTC()
+	for i := range 20005 {
+		event := service.AuditEvent{Time: start.Add(time.Duration(i) * time.Millisecond), Principal: "user", Tenant: "globex", Outcome: "OK"}
+		if i < 3 || i == 20004 {
+			event.Tenant = "acme"
+		}
+		require.NoError(t, auditLog.Append(event))
+	}
+
+	events, err := auditLog.List(service.AuditFilter{Tenant: "acme"})
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	require.EqualValues(t, 1, events[0].Sequence)
+	require.EqualValues(t, 20005, events[3].Sequence)
+
+	events, err = auditLog.List(service.AuditFilter{Tenant: "acme", From: start.Add(time.Millisecond), Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.EqualValues(t, 3, events[0].Sequence)
+	require.EqualValues(t, 20005, events[1].Sequence)
+
+	events, err = auditLog.List(service.AuditFilter{Tenant: "globex", From: start, To: start.Add(10 * time.Millisecond)})
+	require.NoError(t, err)
+	require.Len(t, events, 7)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"slices"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -13,13 +14,17 @@ import (
 
 // AuthInterceptor is a middleware that checks if the user is authenticated and has the required role to access the endpoint.
 type AuthInterceptor struct {
-	maker           TokenMaker
-	accessableRoles map[string][]string
+	maker          TokenMaker
+	policy         AccessPolicy
+	certIdentities *CertIdentities
+	apiKeys        APIKeyStore
 }
 
-// NewAuthInterceptor creates a new AuthInterceptor with the given TokenMaker and accessable roles.
-func NewAuthInterceptor(maker TokenMaker, accessableRoles map[string][]string) *AuthInterceptor {
-	return &AuthInterceptor{maker: maker, accessableRoles: accessableRoles}
+// NewAuthInterceptor creates a new AuthInterceptor with the given TokenMaker and access policy.
+// certIdentities maps mTLS client certificates to principals, nil disables certificate authentication.
+// apiKeys verifies keys sent in the x-api-key metadata wherever a token is accepted, nil disables API keys.
+func NewAuthInterceptor(maker TokenMaker, policy AccessPolicy, certIdentities *CertIdentities, apiKeys APIKeyStore) *AuthInterceptor {
+	return &AuthInterceptor{maker: maker, policy: policy, certIdentities: certIdentities, apiKeys: apiKeys}
 }
 
 // Unary returns a unary server interceptor that checks if the user is authenticated and has the required role to access the endpoint.
@@ -27,7 +32,7 @@ func (interceptor *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 		log.Println("--- Unary Interceptor ---", info.FullMethod)
 
-		err := interceptor.authorize(ctx, info.FullMethod)
+		ctx, err := interceptor.authorize(ctx, info.FullMethod)
 		if err != nil {
 			return nil, err
 		}
@@ -41,41 +46,131 @@ func (interceptor *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
 	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		log.Println("--- Stream Interceptor ---", info.FullMethod)
 
-		err := interceptor.authorize(ss.Context(), info.FullMethod)
+		ctx, err := interceptor.authorize(ss.Context(), info.FullMethod)
 		if err != nil {
 			return err
 		}
 
-		return handler(srv, ss)
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
 	}
 }
 
-func (interceptor *AuthInterceptor) authorize(ctx context.Context, method string) error {
-	accessableRoles, ok := interceptor.accessableRoles[method]
-	if !ok {
+// authorize checks the caller may access method and returns ctx carrying the caller payload and
+// the policy authorizing the call.
+func (interceptor *AuthInterceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	public, accessableRoles := interceptor.policy.Access(method)
+	if public {
 		// public method, no authorization needed
-		return nil
+		return ctx, nil
 	}
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return status.Errorf(codes.Unauthenticated, "metadata not provided")
+	payload, err := interceptor.authenticate(ctx, method, interceptor.policy.Credentials(method))
+	if payload != nil {
+		setAuditPayload(ctx, payload)
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	if slices.Contains(accessableRoles, payload.Role) {
+		return contextWithPolicy(ContextWithPayload(ctx, payload), interceptor.policy), nil
+	}
+
+	return nil, status.Errorf(codes.PermissionDenied, "user with role %s is not allowed to access %s", payload.Role, method)
+}
+
+// authenticate returns the payload of the caller of method from the credential kinds accepted.
+// An API key counts as a token. When both are accepted, a token takes precedence over the client certificate.
+// A caller that is identified but denied is returned along with the error, so the denial can be audited.
+func (interceptor *AuthInterceptor) authenticate(ctx context.Context, method, accept string) (*Payload, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
 	values := md.Get("authorization")
+	apiKeys := md.Get("x-api-key")
+
+	if accept == CredentialCert || (accept == CredentialAny && len(values) == 0 && len(apiKeys) == 0) {
+		return interceptor.authenticateCert(ctx)
+	}
+
+	if len(apiKeys) > 0 {
+		return interceptor.authenticateAPIKey(apiKeys[0], method)
+	}
+
 	if len(values) == 0 {
-		return status.Errorf(codes.Unauthenticated, "authorization token not provided")
+		return nil, status.Errorf(codes.Unauthenticated, "authorization token not provided")
 	}
 
 	accessToken := values[0]
 	payload, err := interceptor.maker.VerifyToken(accessToken)
 	if err != nil {
-		return status.Errorf(codes.Unauthenticated, "invalid access token: %v", err)
+		return nil, status.Errorf(codes.Unauthenticated, "invalid access token: %v", err)
 	}
 
-	if slices.Contains(accessableRoles, payload.Role) {
-		return nil
+	return payload, nil
+}
+
+// authenticateCert returns the payload mapped to the verified client certificate of the caller,
+// or to its UID when it is connected through a Unix socket.
+func (interceptor *AuthInterceptor) authenticateCert(ctx context.Context) (*Payload, error) {
+	if interceptor.certIdentities == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "client certificate authentication is not enabled")
+	}
+
+	if info, ok := peerCredInfo(ctx); ok {
+		payload, ok := interceptor.certIdentities.IdentifyUID(info.UID)
+		if !ok {
+			return nil, status.Errorf(codes.Unauthenticated, "unix socket peer uid %d is not mapped to an identity", info.UID)
+		}
+		return payload, nil
+	}
+
+	cert, ok := verifiedPeerCertificate(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "verified client certificate not provided")
+	}
+
+	payload, ok := interceptor.certIdentities.Identify(cert)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "client certificate %s is not mapped to an identity", cert.Subject)
+	}
+
+	return payload, nil
+}
+
+// authenticateAPIKey returns the payload of the API key raw when it is valid and may call method.
+func (interceptor *AuthInterceptor) authenticateAPIKey(raw, method string) (*Payload, error) {
+	if interceptor.apiKeys == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "api key authentication is not enabled")
+	}
+
+	id, err := apiKeyID(raw)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid api key: %v", err)
 	}
 
-	return status.Errorf(codes.PermissionDenied, "user with role %s is not allowed to access %s", payload.Role, method)
+	key, err := interceptor.apiKeys.Find(id)
+	if err != nil || !key.IsCorrectKey(raw) {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid api key")
+	}
+
+	err = key.IsValid(time.Now())
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid api key: %v", err)
+	}
+
+	payload := &Payload{ID: key.ID, Username: key.CreatedBy, Role: key.Role, Tenant: key.Tenant, Methods: key.Methods}
+	if !key.Allows(method) {
+		return payload, status.Errorf(codes.PermissionDenied, "api key %s is not allowed to access %s", key.ID, method)
+	}
+
+	return payload, nil
+}
+
+// contextServerStream is a grpc.ServerStream whose context is replaced, e.g. to carry the authenticated caller.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *contextServerStream) Context() context.Context {
+	return stream.ctx
 }
@@ -0,0 +1,285 @@
+package service_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/go-http-server/grpc/certgen"
+	"github.com/go-http-server/grpc/protoc"
+	"github.com/go-http-server/grpc/sample"
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func startTestAuthServer(t *testing.T) string {
+	t.Helper()
+	accountStore := service.NewInMemoryAccountStore()
+	hasher := &service.BcryptHasher{Cost: bcrypt.MinCost}
+	acc, err := service.NewAccount("admin_valid", "password", "admin", hasher)
+	require.NoError(t, err)
+	require.NoError(t, accountStore.Save(acc))
+
+	policy := &service.Policy{
+		DefaultDeny: true,
+		Public:      []string{"/AuthService/Login"},
+		Permissions: map[string][]string{
+			"auth.admin": {"/AuthService/*"},
+			"laptop":     {"/LaptopService/*"},
+			"laptop.own": {"/LaptopService/CreateLaptop"},
+		},
+		Roles: map[string][]string{"admin": {"auth.admin", "laptop"}, "owner": {"laptop.own"}},
+		Audit: []string{"/AuthService/Login", "/LaptopService/CreateLaptop"},
+	}
+
+	maker := service.NewPasetoMaker(paseto.NewV4AsymmetricSecretKey(), service.TokenConfig{})
+	apiKeyStore := service.NewInMemoryAPIKeyStore()
+	limiter := service.NewLoginLimiter(service.LoginLimiterConfig{MaxFailures: 5, Window: time.Minute, BaseLockout: time.Minute, MaxLockout: time.Minute})
+	auditLog, err := service.OpenFileAuditLog("")
+	require.NoError(t, err)
+	authServer, err := service.NewAuthServer(accountStore, hasher, maker, time.Minute, limiter, apiKeyStore, auditLog)
+	require.NoError(t, err)
+	interceptor := service.NewAuthInterceptor(maker, policy, nil, apiKeyStore)
+	auditInterceptor := service.NewAuditInterceptor(auditLog, policy)
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auditInterceptor.Unary(), interceptor.Unary()),
+		grpc.ChainStreamInterceptor(auditInterceptor.Stream(), interceptor.Stream()),
+	)
+	protoc.RegisterAuthServiceServer(grpcServer, authServer)
+	protoc.RegisterLaptopServiceServer(grpcServer, service.NewLaptopServer(service.NewInMemoryLaptopStore(), nil, nil))
+
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	return listener.Addr().String()
+}
+
+func TestAuthInterceptorAPIKey(t *testing.T) {
+	t.Parallel()
+
+	conn := newClientConnection(t, startTestAuthServer(t))
+	defer conn.Close()
+	authClient := protoc.NewAuthServiceClient(conn)
+	laptopClient := protoc.NewLaptopServiceClient(conn)
+
+	login, err := authClient.Login(t.Context(), &protoc.LoginRequest{Username: "admin_valid", Password: "password"})
+	require.NoError(t, err)
+	adminCtx := metadata.AppendToOutgoingContext(t.Context(), "authorization", login.GetAccessToken())
+
+	created, err := authClient.CreateApiKey(adminCtx, &protoc.CreateApiKeyRequest{
+		Name:    "batch",
+		Role:    "admin",
+		Methods: []string{"/LaptopService/*"},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, created.GetKey())
+	require.Equal(t, "admin_valid", created.GetApiKey().GetCreatedBy())
+	keyCtx := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(t.Context(), "x-api-key", key)
+	}
+
+	_, err = laptopClient.CreateLaptop(keyCtx(created.GetKey()), &protoc.CreateLaptopRequest{Laptop: sample.NewLaptop()})
+	require.NoError(t, err)
+
+	// the key is limited to LaptopService even though its role may call AuthService
+	_, err = authClient.ListApiKeys(keyCtx(created.GetKey()), &protoc.ListApiKeysRequest{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = laptopClient.CreateLaptop(keyCtx(created.GetKey()+"x"), &protoc.CreateLaptopRequest{Laptop: sample.NewLaptop()})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	list, err := authClient.ListApiKeys(adminCtx, &protoc.ListApiKeysRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetApiKeys(), 1)
+	require.Equal(t, created.GetApiKey().GetId(), list.GetApiKeys()[0].GetId())
+
+	revoked, err := authClient.RevokeApiKey(adminCtx, &protoc.RevokeApiKeyRequest{Id: created.GetApiKey().GetId()})
+	require.NoError(t, err)
+	require.NotNil(t, revoked.GetApiKey().GetRevokedAt())
+
+	_, err = laptopClient.CreateLaptop(keyCtx(created.GetKey()), &protoc.CreateLaptopRequest{Laptop: sample.NewLaptop()})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	audit, err := authClient.ListAuditEvents(adminCtx, &protoc.ListAuditEventsRequest{Principal: "admin_valid"})
+	require.NoError(t, err)
+	require.True(t, audit.GetChainVerified())
+
+	outcomes := make([]string, 0, len(audit.GetEvents()))
+	for _, event := range audit.GetEvents() {
+		outcomes = append(outcomes, event.GetMethod()+" "+event.GetOutcome())
+	}
+	require.Equal(t, []string{
+		"/AuthService/Login OK",
+		"/LaptopService/CreateLaptop OK",
+		"/AuthService/ListApiKeys PermissionDenied",
+	}, outcomes)
+	require.NotEmpty(t, audit.GetEvents()[1].GetResourceId())
+
+	// keys may only act as roles of the policy granting permissions their creator holds
+	_, err = authClient.CreateApiKey(adminCtx, &protoc.CreateApiKeyRequest{Name: "typo", Role: "admni"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = authClient.CreateApiKey(adminCtx, &protoc.CreateApiKeyRequest{Name: "owner", Role: "owner"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// and a key restricted to some methods cannot create keys reaching further
+	minter, err := authClient.CreateApiKey(adminCtx, &protoc.CreateApiKeyRequest{
+		Name:    "minter",
+		Role:    "admin",
+		Methods: []string{"/AuthService/CreateApiKey", "/LaptopService/*"},
+	})
+	require.NoError(t, err)
+	minterCtx := keyCtx(minter.GetKey())
+	for _, methods := range [][]string{nil, {"/*/*"}, {"/LaptopService/*", "/AuthService/ListApiKeys"}, {"/AuthService/*"}} {
+		_, err = authClient.CreateApiKey(minterCtx, &protoc.CreateApiKeyRequest{Name: "wider", Role: "admin", Methods: methods})
+		require.Equal(t, codes.PermissionDenied, status.Code(err), "%v", methods)
+	}
+	_, err = authClient.CreateApiKey(minterCtx, &protoc.CreateApiKeyRequest{
+		Name:    "narrower",
+		Role:    "admin",
+		Methods: []string{"/LaptopService/*", "/LaptopService/CreateLaptop"},
+	})
+	require.NoError(t, err)
+}
+
+// startTestMTLSServer serves LaptopService over mTLS, authenticating callers by their client certificate.
+func startTestMTLSServer(t *testing.T, ca *certgen.Authority, keyType certgen.KeyType, identities *service.CertIdentities) string {
+	t.Helper()
+	pair, err := ca.IssueServer("localhost", []string{"localhost", "127.0.0.1"}, keyType, time.Hour)
+	require.NoError(t, err)
+
+	policy := &service.Policy{
+		DefaultDeny:     true,
+		Permissions:     map[string][]string{"laptop": {"/LaptopService/*"}},
+		Roles:           map[string][]string{"admin": {"laptop"}},
+		CredentialRules: []service.CredentialRule{{Methods: []string{"/LaptopService/*"}, Accept: service.CredentialCert}},
+	}
+	interceptor := service.NewAuthInterceptor(nil, policy, identities, nil)
+
+	grpcServer := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(ca.ServerTLSConfig(pair))),
+		grpc.UnaryInterceptor(interceptor.Unary()),
+	)
+	protoc.RegisterLaptopServiceServer(grpcServer, service.NewLaptopServer(service.NewInMemoryLaptopStore(), nil, nil))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	return listener.Addr().String()
+}
+
+func TestAuthInterceptorClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	for _, keyType := range []certgen.KeyType{certgen.ECDSA, certgen.Ed25519} {
+		t.Run(string(keyType), func(t *testing.T) {
+			t.Parallel()
+
+			ca, err := certgen.NewAuthority("test CA", keyType, time.Hour)
+			require.NoError(t, err)
+			otherCA, err := certgen.NewAuthority("other CA", keyType, time.Hour)
+			require.NoError(t, err)
+
+			identities := &service.CertIdentities{Identities: []service.CertIdentity{
+				{URI: ca.ClientURI("batch").String(), Username: "batch-job", Role: "admin", Tenant: "default"},
+				{Subject: "CN=reporting,OU=user,O=default", Username: "reporting", Role: "user", Tenant: "default"},
+			}}
+			addr := startTestMTLSServer(t, ca, keyType, identities)
+
+			testCases := []struct {
+				name     string
+				ca       *certgen.Authority
+				identity certgen.ClientIdentity
+				code     codes.Code
+			}{
+				{name: "mapped by URI", ca: ca, identity: certgen.ClientIdentity{Name: "batch", Role: "admin", Tenant: "default"}, code: codes.OK},
+				{name: "mapped by subject", ca: ca, identity: certgen.ClientIdentity{Name: "reporting", Role: "user", Tenant: "default"}, code: codes.PermissionDenied},
+				{name: "not mapped", ca: ca, identity: certgen.ClientIdentity{Name: "stranger", Role: "admin"}, code: codes.Unauthenticated},
+				{name: "untrusted CA", ca: otherCA, identity: certgen.ClientIdentity{Name: "batch", Role: "admin", Tenant: "default"}, code: codes.Unavailable},
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					t.Parallel()
+
+					pair, err := tc.ca.IssueClient(tc.identity, keyType, time.Hour)
+					require.NoError(t, err)
+					clientConfig := ca.ClientTLSConfig(pair)
+					clientConfig.ServerName = "localhost"
+					conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(clientConfig)))
+					require.NoError(t, err)
+					defer conn.Close()
+
+					_, err = protoc.NewLaptopServiceClient(conn).CreateLaptop(t.Context(), &protoc.CreateLaptopRequest{Laptop: sample.NewLaptop()})
+					require.Equal(t, tc.code, status.Code(err), "%v", err)
+				})
+			}
+		})
+	}
+}
+
+func TestAuthInterceptorUnixPeer(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS != "linux" {
+		t.Skip("SO_PEERCRED is Linux only")
+	}
+
+	uid, otherUID := uint32(os.Getuid()), uint32(os.Getuid()+1)
+	policy := &service.Policy{
+		DefaultDeny:     true,
+		Permissions:     map[string][]string{"laptop": {"/LaptopService/*"}},
+		Roles:           map[string][]string{"admin": {"laptop"}},
+		CredentialRules: []service.CredentialRule{{Methods: []string{"/LaptopService/*"}, Accept: service.CredentialCert}},
+	}
+
+	testCases := []struct {
+		name string
+		uid  uint32
+		code codes.Code
+	}{
+		{name: "trusted uid", uid: uid, code: codes.OK},
+		{name: "other uid", uid: otherUID, code: codes.Unauthenticated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			identities := &service.CertIdentities{Identities: []service.CertIdentity{
+				{UID: &tc.uid, Username: "sidecar", Role: "admin", Tenant: "default"},
+			}}
+			interceptor := service.NewAuthInterceptor(nil, policy, identities, nil)
+			grpcServer := grpc.NewServer(grpc.Creds(service.NewPeerCredentials()), grpc.UnaryInterceptor(interceptor.Unary()))
+			protoc.RegisterLaptopServiceServer(grpcServer, service.NewLaptopServer(service.NewInMemoryLaptopStore(), nil, nil))
+
+			socket := filepath.Join(t.TempDir(), "server.sock")
+			listener, err := net.Listen("unix", socket)
+			require.NoError(t, err)
+			go grpcServer.Serve(listener)
+			t.Cleanup(grpcServer.Stop)
+
+			conn := newClientConnection(t, "unix://"+socket)
+			defer conn.Close()
+
+			_, err = protoc.NewLaptopServiceClient(conn).CreateLaptop(t.Context(), &protoc.CreateLaptopRequest{Laptop: sample.NewLaptop()})
+			require.Equal(t, tc.code, status.Code(err), "%v", err)
+		})
+	}
+}
@@ -2,37 +2,81 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
+	"log"
+	"net"
+	"path"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-http-server/grpc/protoc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// defaultAuditEventsLimit caps ListAuditEvents responses when the request sets no limit.
+const defaultAuditEventsLimit = 1000
+
+// errInvalidCredentials is the single response of every failed login, so callers cannot tell an unknown username,
+// a wrong password and a lockout apart.
+var errInvalidCredentials = status.Error(codes.Unauthenticated, "invalid username or password")
+
 // AuthServer is the server API for AuthService service.
 type AuthServer struct {
 	protoc.UnimplementedAuthServiceServer
-	store AccountStore
-	maker TokenMaker
+	store         AccountStore
+	hasher        PasswordHasher
+	maker         TokenMaker
+	tokenDuration time.Duration
+	limiter       *LoginLimiter
+	apiKeys       APIKeyStore
+	auditLog      AuditLog
+	dummyAccount  *Account // password check target for unknown usernames, keeps their timing equal to known ones
 }
 
-// NewAuthServer creates a new instance of AuthServer.
-func NewAuthServer(store AccountStore, maker TokenMaker) *AuthServer {
-	return &AuthServer{store: store, maker: maker}
+// NewAuthServer creates a new instance of AuthServer issuing tokens valid for tokenDuration.
+// Passwords hashed by another algorithm or with other parameters than hasher uses are rehashed on login.
+func NewAuthServer(store AccountStore, hasher PasswordHasher, maker TokenMaker, tokenDuration time.Duration, limiter *LoginLimiter, apiKeys APIKeyStore, auditLog AuditLog) (*AuthServer, error) {
+	dummyAccount, err := NewAccount("", rand.Text(), "", hasher)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthServer{store: store, hasher: hasher, maker: maker, tokenDuration: tokenDuration, limiter: limiter, apiKeys: apiKeys, auditLog: auditLog, dummyAccount: dummyAccount}, nil
 }
 
 func (s *AuthServer) Login(ctx context.Context, req *protoc.LoginRequest) (*protoc.LoginResponse, error) {
-	acc, err := s.store.Find(req.GetUsername())
+	username := req.GetUsername()
+	peerIP := peerAddress(ctx)
+
+	if !s.limiter.Allow(username, peerIP) {
+		log.Printf("login throttled for username %s from peer %s", username, peerIP)
+		return nil, errInvalidCredentials
+	}
+
+	acc, err := s.store.Find(username)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "store: %s", err)
+		_ = s.dummyAccount.IsCorrectPassword(req.GetPassword())
+		s.limiter.RecordFailure(username, peerIP)
+		return nil, errInvalidCredentials
 	}
 
+	// audit the login as the account, so it is listed to the admins of its tenant
+	setAuditPayload(ctx, &Payload{Username: acc.Username, Role: acc.Role, Tenant: acc.Tenant})
+
 	err = acc.IsCorrectPassword(req.GetPassword())
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "password is incorrect: %s", err)
+		s.limiter.RecordFailure(username, peerIP)
+		return nil, errInvalidCredentials
 	}
+	s.limiter.RecordSuccess(username)
+	s.rehashPassword(acc, req.GetPassword())
 
-	token, err := s.maker.CreateToken(acc, 5*time.Minute)
+	token, err := s.maker.CreateToken(acc, s.tokenDuration)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "camnnot create token: %s", err)
 	}
@@ -43,3 +87,230 @@ func (s *AuthServer) Login(ctx context.Context, req *protoc.LoginRequest) (*prot
 
 	return res, nil
 }
+
+// rehashPassword upgrades the stored hash of acc once its password is known to be correct.
+// A failure only delays the upgrade to the next login.
+func (s *AuthServer) rehashPassword(acc *Account, password string) {
+	if !s.hasher.NeedsRehash(acc.HashedPassword) {
+		return
+	}
+
+	hashed, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Printf("cannot rehash password of %s: %s", acc.Username, err)
+		return
+	}
+
+	acc.HashedPassword = hashed
+	err = s.store.Update(acc)
+	if err != nil {
+		log.Printf("cannot save rehashed password of %s: %s", acc.Username, err)
+		return
+	}
+	log.Printf("password of %s rehashed", acc.Username)
+}
+
+// ListLockouts returns the failed login state of every tracked username and peer.
+func (s *AuthServer) ListLockouts(ctx context.Context, req *protoc.ListLockoutsRequest) (*protoc.ListLockoutsResponse, error) {
+	res := &protoc.ListLockoutsResponse{}
+	for _, lockout := range s.limiter.Lockouts() {
+		item := &protoc.Lockout{
+			Kind:           protoc.Lockout_USERNAME,
+			Key:            lockout.Username,
+			RecentFailures: uint32(lockout.RecentFailures),
+			LockoutCount:   uint32(lockout.LockoutCount),
+		}
+		if lockout.Peer != "" {
+			item.Kind = protoc.Lockout_PEER
+			item.Key = lockout.Peer
+		}
+		if !lockout.LockedUntil.IsZero() {
+			item.LockedUntil = timestamppb.New(lockout.LockedUntil)
+		}
+
+		res.Lockouts = append(res.Lockouts, item)
+	}
+
+	return res, nil
+}
+
+// CreateApiKey creates an API key acting on behalf of the caller, the secret key is only returned here.
+// The role of the key must be a role of the policy granting no permission the caller does not hold,
+// and a caller restricted to some methods may only restrict the key to methods it may call.
+func (s *AuthServer) CreateApiKey(ctx context.Context, req *protoc.CreateApiKeyRequest) (*protoc.CreateApiKeyResponse, error) {
+	for _, method := range req.GetMethods() {
+		if _, err := path.Match(method, ""); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "malformed method pattern %q: %s", method, err)
+		}
+	}
+
+	creator, ok := PayloadFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "api keys can only be created by an authenticated caller")
+	}
+
+	policy, ok := policyFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "no access policy to check the api key role against")
+	}
+	permissions, ok := policy.RolePermissions(req.GetRole())
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown role %q", req.GetRole())
+	}
+	held, _ := policy.RolePermissions(creator.Role)
+	for _, permission := range permissions {
+		if !slices.Contains(held, permission) {
+			return nil, status.Errorf(codes.PermissionDenied, "role %s grants permission %s, which role %s does not hold", req.GetRole(), permission, creator.Role)
+		}
+	}
+
+	if len(creator.Methods) > 0 {
+		if len(req.GetMethods()) == 0 {
+			return nil, status.Errorf(codes.PermissionDenied, "an api key restricted to some methods cannot create an unrestricted api key")
+		}
+		for _, method := range req.GetMethods() {
+			if !coversPattern(creator.Methods, method) {
+				return nil, status.Errorf(codes.PermissionDenied, "method pattern %q is not covered by the methods of the calling api key", method)
+			}
+		}
+	}
+
+	key, raw, err := NewAPIKey(req.GetName(), req.GetRole(), req.GetMethods(), creator, req.GetTtl().AsDuration())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot create api key: %s", err)
+	}
+
+	err = s.apiKeys.Save(key)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot save api key: %s", err)
+	}
+	log.Printf("api key %s (%s) created by %s", key.ID, key.Name, key.CreatedBy)
+
+	return &protoc.CreateApiKeyResponse{ApiKey: apiKeyToProto(key), Key: raw}, nil
+}
+
+// ListApiKeys returns the API keys of the caller tenant, revoked ones included.
+func (s *AuthServer) ListApiKeys(ctx context.Context, req *protoc.ListApiKeysRequest) (*protoc.ListApiKeysResponse, error) {
+	keys, err := s.apiKeys.List()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot list api keys: %s", err)
+	}
+
+	_, tenant := callerIdentity(ctx)
+	res := &protoc.ListApiKeysResponse{}
+	for _, key := range keys {
+		if key.Tenant == tenant {
+			res.ApiKeys = append(res.ApiKeys, apiKeyToProto(key))
+		}
+	}
+
+	return res, nil
+}
+
+// RevokeApiKey revokes an API key of the caller tenant, revoking twice keeps the first revocation time.
+func (s *AuthServer) RevokeApiKey(ctx context.Context, req *protoc.RevokeApiKeyRequest) (*protoc.RevokeApiKeyResponse, error) {
+	key, err := s.apiKeys.Find(req.GetId())
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return nil, status.Errorf(codes.NotFound, "api key with id %s not found", req.GetId())
+		}
+		return nil, status.Errorf(codes.Internal, "cannot find api key: %s", err)
+	}
+
+	username, tenant := callerIdentity(ctx)
+	if key.Tenant != tenant {
+		return nil, status.Errorf(codes.NotFound, "api key with id %s not found", req.GetId())
+	}
+
+	key, err = s.apiKeys.Revoke(key.ID, time.Now())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot revoke api key: %s", err)
+	}
+	log.Printf("api key %s (%s) revoked by %s", key.ID, key.Name, username)
+
+	return &protoc.RevokeApiKeyResponse{ApiKey: apiKeyToProto(key)}, nil
+}
+
+// ListAuditEvents returns the most recent audit events of the caller tenant matching the request
+// filters, oldest first.
+func (s *AuthServer) ListAuditEvents(ctx context.Context, req *protoc.ListAuditEventsRequest) (*protoc.ListAuditEventsResponse, error) {
+	_, tenant := callerIdentity(ctx)
+	filter := AuditFilter{Tenant: tenant, Principal: req.GetPrincipal(), Limit: int(req.GetLimit())}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditEventsLimit
+	}
+	if req.GetFrom() != nil {
+		filter.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		filter.To = req.GetTo().AsTime()
+	}
+
+	events, err := s.auditLog.List(filter)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot list audit events: %s", err)
+	}
+
+	// the trail is verified periodically, reading the whole file back on every call would be too slow
+	res := &protoc.ListAuditEventsResponse{ChainVerified: s.auditLog.Verified() == nil}
+	for _, event := range events {
+		res.Events = append(res.Events, &protoc.AuditEvent{
+			Sequence:   event.Sequence,
+			Time:       timestamppb.New(event.Time),
+			Principal:  event.Principal,
+			Role:       event.Role,
+			Tenant:     event.Tenant,
+			Peer:       event.Peer,
+			Method:     event.Method,
+			ResourceId: event.ResourceID,
+			Outcome:    event.Outcome,
+			PrevHash:   event.PrevHash,
+			Hash:       event.Hash,
+		})
+	}
+
+	return res, nil
+}
+
+func apiKeyToProto(key *APIKey) *protoc.ApiKey {
+	res := &protoc.ApiKey{
+		Id:        key.ID,
+		Name:      key.Name,
+		Role:      key.Role,
+		Methods:   key.Methods,
+		CreatedBy: key.CreatedBy,
+		CreatedAt: timestamppb.New(key.CreatedAt),
+	}
+	if !key.ExpiresAt.IsZero() {
+		res.ExpiresAt = timestamppb.New(key.ExpiresAt)
+	}
+	if !key.RevokedAt.IsZero() {
+		res.RevokedAt = timestamppb.New(key.RevokedAt)
+	}
+
+	return res
+}
+
+// peerAddress returns the IP address of the caller, or the raw address for non IP transports.
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+// coversPattern reports whether every method matched by pattern is matched by one of patterns. A pattern
+// with wildcards is only covered by the same pattern.
+func coversPattern(patterns []string, pattern string) bool {
+	if strings.ContainsAny(pattern, `*?[\`) {
+		return slices.Contains(patterns, pattern)
+	}
+	return matchAny(patterns, pattern)
+}
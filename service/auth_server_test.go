@@ -0,0 +1,107 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/go-http-server/grpc/protoc"
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestAuthServer(t *testing.T, config service.LoginLimiterConfig) *service.AuthServer {
+	t.Helper()
+	store := service.NewInMemoryAccountStore()
+	hasher := &service.BcryptHasher{Cost: bcrypt.MinCost}
+	acc, err := service.NewAccount("admin_valid", "password", "admin", hasher)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(acc))
+
+	maker := service.NewPasetoMaker(paseto.NewV4AsymmetricSecretKey(), service.TokenConfig{})
+	server, err := service.NewAuthServer(store, hasher, maker, time.Minute, service.NewLoginLimiter(config), service.NewInMemoryAPIKeyStore(), &service.FileAuditLog{})
+	require.NoError(t, err)
+
+	return server
+}
+
+func TestServerLoginLockout(t *testing.T) {
+	t.Parallel()
+
+	server := newTestAuthServer(t, service.LoginLimiterConfig{
+		MaxFailures: 2,
+		Window:      time.Minute,
+		BaseLockout: time.Hour,
+		MaxLockout:  time.Hour,
+	})
+	login := func(username, password string) (*protoc.LoginResponse, error) {
+		return server.Login(context.Background(), &protoc.LoginRequest{Username: username, Password: password})
+	}
+
+	res, err := login("admin_valid", "password")
+	require.NoError(t, err)
+	require.NotEmpty(t, res.GetAccessToken())
+
+	// unknown usernames and wrong passwords are indistinguishable
+	_, errUnknown := login("nobody_here", "password")
+	_, errWrong := login("admin_valid", "wrong_password")
+	require.Equal(t, codes.Unauthenticated, status.Code(errUnknown))
+	require.Equal(t, status.Convert(errUnknown).Message(), status.Convert(errWrong).Message())
+
+	// the second failure locks the username out, even for the correct password
+	_, err = login("admin_valid", "wrong_password")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = login("admin_valid", "password")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	lockouts, err := server.ListLockouts(context.Background(), &protoc.ListLockoutsRequest{})
+	require.NoError(t, err)
+	require.Len(t, lockouts.GetLockouts(), 2)
+
+	locked := lockouts.GetLockouts()[0]
+	require.Equal(t, protoc.Lockout_USERNAME, locked.GetKind())
+	require.Equal(t, "admin_valid", locked.GetKey())
+	require.EqualValues(t, 1, locked.GetLockoutCount())
+	require.True(t, locked.GetLockedUntil().AsTime().After(time.Now()))
+
+	unlocked := lockouts.GetLockouts()[1]
+	require.Equal(t, "nobody_here", unlocked.GetKey())
+	require.EqualValues(t, 1, unlocked.GetRecentFailures())
+	require.Nil(t, unlocked.GetLockedUntil())
+}
+
+func TestServerLoginRehash(t *testing.T) {
+	t.Parallel()
+
+	store := service.NewInMemoryAccountStore()
+	acc, err := service.NewAccount("admin_valid", "password", "admin", &service.BcryptHasher{Cost: bcrypt.MinCost})
+	require.NoError(t, err)
+	require.NoError(t, store.Save(acc))
+
+	hasher := &service.Argon2idHasher{Params: service.Argon2idParams{Time: 1, Memory: 64, Threads: 1, SaltLength: 16, KeyLength: 32}}
+	maker := service.NewPasetoMaker(paseto.NewV4AsymmetricSecretKey(), service.TokenConfig{})
+	limiter := service.NewLoginLimiter(service.LoginLimiterConfig{MaxFailures: 5, Window: time.Minute, BaseLockout: time.Minute, MaxLockout: time.Minute})
+	server, err := service.NewAuthServer(store, hasher, maker, time.Minute, limiter, service.NewInMemoryAPIKeyStore(), &service.FileAuditLog{})
+	require.NoError(t, err)
+
+	// a failed login leaves the outdated hash alone
+	_, err = server.Login(context.Background(), &protoc.LoginRequest{Username: "admin_valid", Password: "wrong_password"})
+	require.Error(t, err)
+	stored, err := store.Find("admin_valid")
+	require.NoError(t, err)
+	require.Equal(t, acc.HashedPassword, stored.HashedPassword)
+
+	_, err = server.Login(context.Background(), &protoc.LoginRequest{Username: "admin_valid", Password: "password"})
+	require.NoError(t, err)
+	stored, err = store.Find("admin_valid")
+	require.NoError(t, err)
+	require.False(t, hasher.NeedsRehash(stored.HashedPassword))
+
+	// the upgraded hash keeps accepting the password
+	_, err = server.Login(context.Background(), &protoc.LoginRequest{Username: "admin_valid", Password: "password"})
+	require.NoError(t, err)
+}
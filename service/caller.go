@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-http-server/grpc/protoc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type payloadContextKey struct{}
+
+// ContextWithPayload returns a copy of ctx carrying the payload of the authenticated caller.
+func ContextWithPayload(ctx context.Context, payload *Payload) context.Context {
+	return context.WithValue(ctx, payloadContextKey{}, payload)
+}
+
+// PayloadFromContext returns the payload of the authenticated caller, if the request has one.
+func PayloadFromContext(ctx context.Context) (*Payload, bool) {
+	payload, ok := ctx.Value(payloadContextKey{}).(*Payload)
+	return payload, ok
+}
+
+type policyContextKey struct{}
+
+// contextWithPolicy returns a copy of ctx carrying the access policy the call was authorized by.
+func contextWithPolicy(ctx context.Context, policy AccessPolicy) context.Context {
+	return context.WithValue(ctx, policyContextKey{}, policy)
+}
+
+// policyFromContext returns the access policy the call was authorized by, the policy of the
+// listener it came through.
+func policyFromContext(ctx context.Context) (AccessPolicy, bool) {
+	policy, ok := ctx.Value(policyContextKey{}).(AccessPolicy)
+	return policy, ok
+}
+
+// callerIdentity returns the username and tenant of the caller, both empty for anonymous requests.
+func callerIdentity(ctx context.Context) (username, tenant string) {
+	payload, ok := PayloadFromContext(ctx)
+	if !ok {
+		return "", ""
+	}
+
+	return payload.Username, payload.Tenant
+}
+
+// canSeeLaptop reports whether the caller belongs to the tenant of laptop.
+func canSeeLaptop(ctx context.Context, laptop *protoc.Laptop) bool {
+	_, tenant := callerIdentity(ctx)
+	return laptop.GetTenant() == tenant
+}
+
+// authorizeLaptop checks the caller may use laptop, and owns it when manage is set.
+// Laptops of other tenants are reported as not found so their existence does not leak.
+func authorizeLaptop(ctx context.Context, laptop *protoc.Laptop, manage bool) error {
+	if !canSeeLaptop(ctx, laptop) {
+		return status.Errorf(codes.NotFound, "laptop with id %s not found", laptop.GetId())
+	}
+
+	username, _ := callerIdentity(ctx)
+	if manage && laptop.GetOwner() != username {
+		return status.Errorf(codes.PermissionDenied, "laptop %s is not owned by %s", laptop.GetId(), username)
+	}
+
+	return nil
+}
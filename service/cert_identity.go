@@ -0,0 +1,113 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"gopkg.in/yaml.v3"
+)
+
+// CertIdentity maps a verified client certificate, or the UID of a Unix socket peer, to a principal.
+// A certificate matches on its URI SAN (e.g. a SPIFFE ID) or on its full subject.
+type CertIdentity struct {
+	URI      string  `yaml:"uri,omitempty"`     // URI SAN, e.g. spiffe://grpc.local/service/batch
+	Subject  string  `yaml:"subject,omitempty"` // subject in RFC 2253 form, e.g. CN=batch,O=Example
+	UID      *uint32 `yaml:"uid,omitempty"`     // UID of a process connected through a Unix socket listener
+	Username string  `yaml:"username"`
+	Role     string  `yaml:"role"`
+	Tenant   string  `yaml:"tenant"`
+}
+
+// CertIdentities resolves the principal of mTLS and Unix socket peers.
+type CertIdentities struct {
+	Identities []CertIdentity `yaml:"identities"`
+}
+
+// LoadCertIdentities reads a YAML or JSON certificate identity mapping file.
+func LoadCertIdentities(filename string) (*CertIdentities, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	identities := &CertIdentities{}
+	err = decoder.Decode(identities)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse certificate identities %s: %w", filename, err)
+	}
+
+	for i, identity := range identities.Identities {
+		set := 0
+		for _, ok := range []bool{identity.URI != "", identity.Subject != "", identity.UID != nil} {
+			if ok {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("certificate identity %d: exactly one of uri, subject or uid must be set", i)
+		}
+		if identity.Username == "" || identity.Role == "" {
+			return nil, fmt.Errorf("certificate identity %d: username and role are required", i)
+		}
+	}
+
+	return identities, nil
+}
+
+// Identify returns the payload of the principal cert maps to. URI SAN mappings take precedence over subject ones.
+func (identities *CertIdentities) Identify(cert *x509.Certificate) (*Payload, bool) {
+	for _, identity := range identities.Identities {
+		for _, uri := range cert.URIs {
+			if identity.URI != "" && identity.URI == uri.String() {
+				return identity.payload(), true
+			}
+		}
+	}
+
+	subject := cert.Subject.String()
+	for _, identity := range identities.Identities {
+		if identity.Subject != "" && identity.Subject == subject {
+			return identity.payload(), true
+		}
+	}
+
+	return nil, false
+}
+
+// IdentifyUID returns the payload of the principal the UID of a Unix socket peer maps to.
+func (identities *CertIdentities) IdentifyUID(uid uint32) (*Payload, bool) {
+	for _, identity := range identities.Identities {
+		if identity.UID != nil && *identity.UID == uid {
+			return identity.payload(), true
+		}
+	}
+
+	return nil, false
+}
+
+func (identity CertIdentity) payload() *Payload {
+	return &Payload{Username: identity.Username, Role: identity.Role, Tenant: identity.Tenant}
+}
+
+// verifiedPeerCertificate returns the leaf certificate of the caller once the TLS handshake verified its chain.
+func verifiedPeerCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	return tlsInfo.State.VerifiedChains[0][0], true
+}
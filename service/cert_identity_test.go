@@ -0,0 +1,182 @@
+package service_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// testCertIdentities maps a SPIFFE ID and a subject, as in cert_identities.yaml.
+const testCertIdentities = `identities:
+  - uri: spiffe://grpc.local/service/batch
+    username: batch-job
+    role: admin
+    tenant: default
+  - subject: CN=localhost,O=Example
+    username: localhost-client
+    role: user
+    tenant: default
+`
+
+func loadTestCertIdentities(t *testing.T, content string) (*service.CertIdentities, error) {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "cert_identities.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+	return service.LoadCertIdentities(filename)
+}
+
+// testCert returns a certificate with the subject common name and URI SAN, empty for none.
+func testCert(t *testing.T, commonName, uri string) *x509.Certificate {
+	t.Helper()
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName, Organization: []string{"Example"}}}
+	if uri != "" {
+		parsed, err := url.Parse(uri)
+		require.NoError(t, err)
+		cert.URIs = []*url.URL{parsed}
+	}
+	return cert
+}
+
+func TestLoadCertIdentities(t *testing.T) {
+	t.Parallel()
+
+	identities, err := loadTestCertIdentities(t, testCertIdentities)
+	require.NoError(t, err)
+	require.Len(t, identities.Identities, 2)
+
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{name: "uri and subject", content: "identities:\n  - {uri: spiffe://a, subject: CN=a, username: a, role: user}\n"},
+		{name: "no uri nor subject", content: "identities:\n  - {username: a, role: user}\n"},
+		{name: "no role", content: "identities:\n  - {uri: spiffe://a, username: a}\n"},
+		{name: "unknown field", content: "identities:\n  - {uri: spiffe://a, username: a, role: user, team: b}\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := loadTestCertIdentities(t, tc.content)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCertIdentitiesIdentify(t *testing.T) {
+	t.Parallel()
+
+	identities, err := loadTestCertIdentities(t, testCertIdentities)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		cert     *x509.Certificate
+		username string
+	}{
+		{name: "uri", cert: testCert(t, "batch", "spiffe://grpc.local/service/batch"), username: "batch-job"},
+		{name: "uri before subject", cert: testCert(t, "localhost", "spiffe://grpc.local/service/batch"), username: "batch-job"},
+		{name: "subject", cert: testCert(t, "localhost", "spiffe://grpc.local/service/other"), username: "localhost-client"},
+		{name: "unmapped", cert: testCert(t, "other", "")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			payload, ok := identities.Identify(tc.cert)
+			require.Equal(t, tc.username != "", ok)
+			if ok {
+				require.Equal(t, tc.username, payload.Username)
+				require.Equal(t, "default", payload.Tenant)
+			}
+		})
+	}
+}
+
+func TestAuthInterceptorCredentials(t *testing.T) {
+	t.Parallel()
+
+	identities, err := loadTestCertIdentities(t, testCertIdentities)
+	require.NoError(t, err)
+	maker := service.NewPasetoMaker(paseto.NewV4AsymmetricSecretKey(), service.TokenConfig{})
+	token, err := maker.CreateToken(&service.Account{Username: "admin_valid", Role: "admin"}, time.Minute)
+	require.NoError(t, err)
+	policy := &service.Policy{
+		DefaultDeny: true,
+		Permissions: map[string][]string{"laptop.read": {"/LaptopService/*"}},
+		Roles:       map[string][]string{"admin": {"laptop.read"}, "user": {"laptop.read"}},
+		CredentialRules: []service.CredentialRule{
+			{Methods: []string{"/LaptopService/CreateLaptop"}, Accept: service.CredentialCert},
+			{Methods: []string{"/LaptopService/SearchLaptop"}, Accept: service.CredentialAny},
+		},
+	}
+
+	// callerContext returns the context of a call with the access token and the verified client
+	// certificate, when set
+	callerContext := func(token string, cert *x509.Certificate) context.Context {
+		ctx := context.Background()
+		if token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", token))
+		}
+		if cert != nil {
+			state := tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+			ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+		}
+		return ctx
+	}
+	batchCert := testCert(t, "batch", "spiffe://grpc.local/service/batch")
+
+	testCases := []struct {
+		name       string
+		identities *service.CertIdentities
+		method     string
+		token      string
+		cert       *x509.Certificate
+		code       codes.Code
+		username   string
+	}{
+		{name: "token", identities: identities, method: "/LaptopService/RateLaptop", token: token, code: codes.OK, username: "admin_valid"},
+		{name: "token refuses certificate", identities: identities, method: "/LaptopService/RateLaptop", cert: batchCert, code: codes.Unauthenticated},
+		{name: "certificate", identities: identities, method: "/LaptopService/CreateLaptop", cert: batchCert, code: codes.OK, username: "batch-job"},
+		{name: "certificate refuses token", identities: identities, method: "/LaptopService/CreateLaptop", token: token, code: codes.Unauthenticated},
+		{name: "unmapped certificate", identities: identities, method: "/LaptopService/CreateLaptop", cert: testCert(t, "other", ""), code: codes.Unauthenticated},
+		{name: "certificates disabled", method: "/LaptopService/CreateLaptop", cert: batchCert, code: codes.Unauthenticated},
+		{name: "any with certificate", identities: identities, method: "/LaptopService/SearchLaptop", cert: batchCert, code: codes.OK, username: "batch-job"},
+		{name: "any prefers token", identities: identities, method: "/LaptopService/SearchLaptop", token: token, cert: batchCert, code: codes.OK, username: "admin_valid"},
+		{name: "any without credentials", identities: identities, method: "/LaptopService/SearchLaptop", code: codes.Unauthenticated},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			interceptor := service.NewAuthInterceptor(maker, policy, tc.identities, nil)
+			var username string
+			_, err := interceptor.Unary()(callerContext(tc.token, tc.cert), nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, func(ctx context.Context, _ any) (any, error) {
+				payload, ok := service.PayloadFromContext(ctx)
+				require.True(t, ok)
+				username = payload.Username
+				return nil, nil
+			})
+			require.Equal(t, tc.code, status.Code(err), "%v", err)
+			require.Equal(t, tc.username, username)
+		})
+	}
+}
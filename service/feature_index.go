@@ -0,0 +1,334 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/go-http-server/grpc/protoc"
+)
+
+// maxRadius in metres covers the whole globe, half of the earth circumference rounded up.
+const maxRadius int32 = 20_015_087
+
+// defaultFeatureCellSize is the side of a feature index cell in E7 units, 0.1 degree or about 11 km of latitude.
+const defaultFeatureCellSize = 1_000_000
+
+type pointKey struct {
+	latitude, longitude int32
+}
+
+type cellKey struct {
+	latitude, longitude int64
+}
+
+// FeatureDistance is a feature along with its distance in metres to a query location.
+type FeatureDistance struct {
+	Feature  *protoc.Feature
+	Distance int32
+	position int
+}
+
+// featureIndex is a spatial index of features over a uniform grid of E7 coordinates.
+// Queries return features in insertion order, the order the linear scans it replaces returned them in.
+// It is not safe for concurrent use.
+type featureIndex struct {
+	cellSize int64
+	features []*protoc.Feature // nil once removed
+	removed  int
+	points   map[pointKey][]int // positions in features by exact location, ascending
+	cells    map[cellKey][]int  // positions in features by grid cell, ascending
+}
+
+// newFeatureIndex creates a featureIndex of features with cells of cellSize E7 units.
+// Features without a location are kept but never match a query.
+func newFeatureIndex(features []*protoc.Feature, cellSize int64) *featureIndex {
+	index := &featureIndex{
+		cellSize: cellSize,
+		points:   make(map[pointKey][]int),
+		cells:    make(map[cellKey][]int),
+	}
+	for _, feature := range features {
+		index.add(feature)
+	}
+	return index
+}
+
+func (index *featureIndex) add(feature *protoc.Feature) {
+	position := len(index.features)
+	index.features = append(index.features, feature)
+	if feature.GetLocation() == nil {
+		return
+	}
+
+	index.link(position)
+}
+
+// replace puts feature at position in place of the feature there, keeping its place in the insertion order.
+func (index *featureIndex) replace(position int, feature *protoc.Feature) {
+	index.unlink(position)
+	index.features[position] = feature
+	index.link(position)
+}
+
+// remove removes the feature at position, the index is compacted once half of it is removed features.
+func (index *featureIndex) remove(position int) {
+	index.unlink(position)
+	index.features[position] = nil
+	index.removed++
+
+	if index.removed > len(index.features)/2 {
+		*index = *newFeatureIndex(index.all(), index.cellSize)
+	}
+}
+
+// all returns the features in insertion order.
+func (index *featureIndex) all() []*protoc.Feature {
+	features := make([]*protoc.Feature, 0, len(index.features)-index.removed)
+	for _, feature := range index.features {
+		if feature != nil {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+// position returns the position of the first feature located exactly at point.
+func (index *featureIndex) position(point *protoc.Point) (int, bool) {
+	if point == nil {
+		return 0, false
+	}
+
+	positions := index.points[pointKey{point.Latitude, point.Longitude}]
+	if len(positions) == 0 {
+		return 0, false
+	}
+	return positions[0], true
+}
+
+// link adds the feature at position to the point and cell maps.
+func (index *featureIndex) link(position int) {
+	location := index.features[position].GetLocation()
+	if location == nil {
+		return
+	}
+
+	point := pointKey{location.Latitude, location.Longitude}
+	index.points[point] = insertPosition(index.points[point], position)
+
+	cell := index.cell(location.Latitude, location.Longitude)
+	index.cells[cell] = insertPosition(index.cells[cell], position)
+}
+
+// unlink removes the feature at position from the point and cell maps.
+func (index *featureIndex) unlink(position int) {
+	location := index.features[position].GetLocation()
+	if location == nil {
+		return
+	}
+
+	point := pointKey{location.Latitude, location.Longitude}
+	index.points[point] = deletePosition(index.points[point], position)
+	if len(index.points[point]) == 0 {
+		delete(index.points, point)
+	}
+
+	cell := index.cell(location.Latitude, location.Longitude)
+	index.cells[cell] = deletePosition(index.cells[cell], position)
+	if len(index.cells[cell]) == 0 {
+		delete(index.cells, cell)
+	}
+}
+
+func insertPosition(positions []int, position int) []int {
+	i := sort.SearchInts(positions, position)
+	return slices.Insert(positions, i, position)
+}
+
+func deletePosition(positions []int, position int) []int {
+	i := sort.SearchInts(positions, position)
+	if i < len(positions) && positions[i] == position {
+		return slices.Delete(positions, i, i+1)
+	}
+	return positions
+}
+
+// cell returns the grid cell containing the E7 coordinates.
+func (index *featureIndex) cell(latitude, longitude int32) cellKey {
+	return cellKey{floorDiv(int64(latitude), index.cellSize), floorDiv(int64(longitude), index.cellSize)}
+}
+
+// lookup returns the features located exactly at point.
+func (index *featureIndex) lookup(point *protoc.Point) []*protoc.Feature {
+	if point == nil {
+		return nil
+	}
+	return index.collect(index.points[pointKey{point.Latitude, point.Longitude}])
+}
+
+// within returns the features inside rect, borders included.
+func (index *featureIndex) within(rect *protoc.Rectangle) []*protoc.Feature {
+	positions := index.positionsWithin(rect)
+	sort.Ints(positions)
+	return index.collect(positions)
+}
+
+// withinRadius returns the features at most meters away from point, closest first, ties in insertion order.
+func (index *featureIndex) withinRadius(point *protoc.Point, meters int32) []FeatureDistance {
+	var found []FeatureDistance
+	seen := make(map[int]bool)
+	for _, rect := range boundingRectangles(point, meters) {
+		for _, position := range index.positionsWithin(rect) {
+			if seen[position] {
+				continue
+			}
+			seen[position] = true
+
+			feature := index.features[position]
+			distance := calcDistance(point, feature.Location)
+			if distance <= meters {
+				found = append(found, FeatureDistance{Feature: feature, Distance: distance, position: position})
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Distance != found[j].Distance {
+			return found[i].Distance < found[j].Distance
+		}
+		return found[i].position < found[j].position
+	})
+	return found
+}
+
+// nearest returns the k features closest to point, closest first, ties in insertion order.
+// The search radius doubles until k features are found or the whole globe is covered.
+func (index *featureIndex) nearest(point *protoc.Point, k int) []FeatureDistance {
+	radius := int32(1000)
+	for {
+		found := index.withinRadius(point, radius)
+		if len(found) >= k || radius == maxRadius {
+			return found[:min(k, len(found))]
+		}
+		radius = int32(min(int64(radius)*2, int64(maxRadius)))
+	}
+}
+
+// positionsWithin returns the positions of the features inside rect, borders included, in no particular order.
+func (index *featureIndex) positionsWithin(rect *protoc.Rectangle) []int {
+	rect = &protoc.Rectangle{
+		Lo: &protoc.Point{
+			Latitude:  min(rect.GetLo().GetLatitude(), rect.GetHi().GetLatitude()),
+			Longitude: min(rect.GetLo().GetLongitude(), rect.GetHi().GetLongitude()),
+		},
+		Hi: &protoc.Point{
+			Latitude:  max(rect.GetLo().GetLatitude(), rect.GetHi().GetLatitude()),
+			Longitude: max(rect.GetLo().GetLongitude(), rect.GetHi().GetLongitude()),
+		},
+	}
+	lo := index.cell(rect.Lo.Latitude, rect.Lo.Longitude)
+	hi := index.cell(rect.Hi.Latitude, rect.Hi.Longitude)
+
+	var positions []int
+	visit := func(cell cellKey) {
+		for _, position := range index.cells[cell] {
+			if inRange(index.features[position].Location, rect) {
+				positions = append(positions, position)
+			}
+		}
+	}
+
+	// walk the covered cells, or the occupied ones when the rectangle spans more cells than are occupied
+	spanned := float64(hi.latitude-lo.latitude+1) * float64(hi.longitude-lo.longitude+1)
+	if spanned <= float64(len(index.cells)) {
+		for latitude := lo.latitude; latitude <= hi.latitude; latitude++ {
+			for longitude := lo.longitude; longitude <= hi.longitude; longitude++ {
+				visit(cellKey{latitude, longitude})
+			}
+		}
+	} else {
+		for cell := range index.cells {
+			if cell.latitude >= lo.latitude && cell.latitude <= hi.latitude &&
+				cell.longitude >= lo.longitude && cell.longitude <= hi.longitude {
+				visit(cell)
+			}
+		}
+	}
+
+	return positions
+}
+
+func (index *featureIndex) collect(positions []int) []*protoc.Feature {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	features := make([]*protoc.Feature, len(positions))
+	for i, position := range positions {
+		features[i] = index.features[position]
+	}
+	return features
+}
+
+// floorDiv divides rounding towards negative infinity, so cells do not straddle the equator or the prime meridian.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// boundingRectangles returns disjoint rectangles covering every point at most meters away from point.
+// A circle crossing the antimeridian is split in two, one reaching a pole covers every longitude.
+func boundingRectangles(point *protoc.Point, meters int32) []*protoc.Rectangle {
+	const cordFactor = 1e7
+	// widen a little so rounding never drops a point calcDistance keeps
+	delta := float64(meters) / earthRadius * 1.0001
+	lat := float64(point.Latitude) / cordFactor
+	lng := float64(point.Longitude) / cordFactor
+	dLat := delta * 180 / math.Pi
+
+	toE7 := func(degrees float64) int32 {
+		return int32(max(math.MinInt32, min(math.MaxInt32, math.Round(degrees*cordFactor))))
+	}
+	whole := func(lo, hi int32) []*protoc.Rectangle {
+		return []*protoc.Rectangle{{
+			Lo: &protoc.Point{Latitude: lo, Longitude: math.MinInt32},
+			Hi: &protoc.Point{Latitude: hi, Longitude: math.MaxInt32},
+		}}
+	}
+
+	loLat, hiLat := toE7(lat-dLat)-1, toE7(lat+dLat)+1
+	if lat-dLat <= -90 {
+		loLat = math.MinInt32
+	}
+	if lat+dLat >= 90 {
+		hiLat = math.MaxInt32
+	}
+
+	ratio := math.Sin(delta) / math.Cos(toRadians(lat))
+	if delta >= math.Pi/2 || loLat == math.MinInt32 || hiLat == math.MaxInt32 || ratio >= 1 {
+		return whole(loLat, hiLat)
+	}
+
+	dLng := math.Asin(ratio) * 180 / math.Pi
+	if dLng >= 180 {
+		return whole(loLat, hiLat)
+	}
+
+	rect := func(lo, hi float64) *protoc.Rectangle {
+		return &protoc.Rectangle{
+			Lo: &protoc.Point{Latitude: loLat, Longitude: toE7(lo) - 1},
+			Hi: &protoc.Point{Latitude: hiLat, Longitude: toE7(hi) + 1},
+		}
+	}
+	switch {
+	case lng-dLng < -180:
+		return []*protoc.Rectangle{rect(-180, lng+dLng), rect(lng-dLng+360, 180)}
+	case lng+dLng > 180:
+		return []*protoc.Rectangle{rect(lng-dLng, 180), rect(-180, lng+dLng-360)}
+	default:
+		return []*protoc.Rectangle{rect(lng-dLng, lng+dLng)}
+	}
+}
@@ -0,0 +1,486 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-http-server/grpc/geojson"
+	"github.com/go-http-server/grpc/protoc"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrFeatureNotFound = errors.New("feature not found")
+	ErrFeatureExists   = errors.New("a feature already exists at this location")
+	ErrInvalidFeature  = errors.New("invalid feature")
+)
+
+// FeatureStore defines the interface of the RouteGuide feature catalogue, holding at most one feature per location.
+// Returned features must not be modified.
+type FeatureStore interface {
+	// Find returns the feature located exactly at point, or nil.
+	Find(point *protoc.Point) *protoc.Feature
+
+	// Within returns the features inside rect, borders included, in catalogue order.
+	Within(rect *protoc.Rectangle) []*protoc.Feature
+
+	// WithinRadius returns the features at most meters away from point, closest first.
+	WithinRadius(point *protoc.Point, meters int32) []FeatureDistance
+
+	// Nearest returns the k features closest to point, closest first.
+	Nearest(point *protoc.Point, k int) []FeatureDistance
+
+	// Create adds a new feature.
+	Create(feature *protoc.Feature) error
+
+	// Update replaces the feature at location, the new feature may be located elsewhere.
+	Update(location *protoc.Point, feature *protoc.Feature) error
+
+	// Delete removes the feature at location and returns it.
+	Delete(location *protoc.Point) (*protoc.Feature, error)
+
+	// Import creates or replaces the features at the locations of features, all at once.
+	Import(features []*protoc.Feature) (created, updated int, err error)
+}
+
+// FileFeatureStore is a FeatureStore indexed in memory and persisted as a JSON array of E7 features
+// or as a GeoJSON FeatureCollection. Every change rewrites the file in the format it was loaded in,
+// and Watch reloads it when it is changed by someone else.
+type FileFeatureStore struct {
+	mutex    sync.RWMutex
+	filename string
+	geoJSON  bool // the file is a GeoJSON FeatureCollection
+	index    *featureIndex
+	modTime  time.Time // version of the file last loaded or written
+	size     int64
+}
+
+// OpenFileFeatureStore loads the features of filename. An empty filename starts an empty catalogue kept in memory only.
+func OpenFileFeatureStore(filename string) (*FileFeatureStore, error) {
+	store := &FileFeatureStore{filename: filename, index: newFeatureIndex(nil, defaultFeatureCellSize)}
+	if filename == "" {
+		return store, nil
+	}
+
+	err := store.reload()
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// SeedFeatureFile copies the features of seed to filename when filename does not exist yet, so the
+// store never rewrites the seed. Nothing is done when either filename is empty.
+func SeedFeatureFile(filename, seed string) error {
+	if filename == "" || seed == "" {
+		return nil
+	}
+	if _, err := os.Stat(filename); err == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(seed)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filename)
+		return fmt.Errorf("cannot seed features %s: %w", filename, err)
+	}
+	return nil
+}
+
+// loadFeatures reads and checks the features of a JSON file, and reports whether it is a GeoJSON FeatureCollection.
+func loadFeatures(filename string) ([]*protoc.Feature, bool, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var features []*protoc.Feature
+	isGeoJSON := geojson.IsFeatureCollection(data)
+	if isGeoJSON {
+		features, err = geojson.UnmarshalFeatures(data)
+	} else {
+		err = json.Unmarshal(data, &features)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot parse features %s: %w", filename, err)
+	}
+
+	err = validateFeatures(features)
+	if err != nil {
+		return nil, false, fmt.Errorf("features %s: %w", filename, err)
+	}
+
+	return features, isGeoJSON, nil
+}
+
+// validateFeatures checks every feature and that no two share a location.
+func validateFeatures(features []*protoc.Feature) error {
+	seen := make(map[pointKey]bool, len(features))
+	for i, feature := range features {
+		err := validateFeature(feature)
+		if err != nil {
+			return fmt.Errorf("feature %d: %w", i, err)
+		}
+
+		point := pointKey{feature.Location.Latitude, feature.Location.Longitude}
+		if seen[point] {
+			return fmt.Errorf("feature %d: %w", i, ErrFeatureExists)
+		}
+		seen[point] = true
+	}
+
+	return nil
+}
+
+// validateFeature checks the feature has a location inside the E7 latitude and longitude ranges.
+func validateFeature(feature *protoc.Feature) error {
+	location := feature.GetLocation()
+	if location == nil {
+		return fmt.Errorf("%w: no location", ErrInvalidFeature)
+	}
+	err := validateLocation(location)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFeature, err)
+	}
+	return nil
+}
+
+// validateLocation checks the point is inside the E7 latitude and longitude ranges.
+func validateLocation(point *protoc.Point) error {
+	if point.Latitude < -900000000 || point.Latitude > 900000000 {
+		return fmt.Errorf("latitude %d out of range [-90, 90] degrees", point.Latitude)
+	}
+	if point.Longitude < -1800000000 || point.Longitude > 1800000000 {
+		return fmt.Errorf("longitude %d out of range [-180, 180] degrees", point.Longitude)
+	}
+	return nil
+}
+
+// cloneFeature copies feature for the catalogue, dropping the RecordRoute only timestamp of its location.
+func cloneFeature(feature *protoc.Feature) *protoc.Feature {
+	feature = proto.Clone(feature).(*protoc.Feature)
+	feature.Location.RecordedAt = nil
+	return feature
+}
+
+func (store *FileFeatureStore) Find(point *protoc.Point) *protoc.Feature {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	position, ok := store.index.position(point)
+	if !ok {
+		return nil
+	}
+	return store.index.features[position]
+}
+
+func (store *FileFeatureStore) Within(rect *protoc.Rectangle) []*protoc.Feature {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return store.index.within(rect)
+}
+
+func (store *FileFeatureStore) WithinRadius(point *protoc.Point, meters int32) []FeatureDistance {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return store.index.withinRadius(point, meters)
+}
+
+func (store *FileFeatureStore) Nearest(point *protoc.Point, k int) []FeatureDistance {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return store.index.nearest(point, k)
+}
+
+func (store *FileFeatureStore) Create(feature *protoc.Feature) error {
+	err := validateFeature(feature)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.index.position(feature.Location); ok {
+		return ErrFeatureExists
+	}
+
+	feature = cloneFeature(feature)
+	err = store.persist(append(store.index.all(), feature))
+	if err != nil {
+		return err
+	}
+
+	store.index.add(feature)
+	return nil
+}
+
+func (store *FileFeatureStore) Update(location *protoc.Point, feature *protoc.Feature) error {
+	err := validateFeature(feature)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	position, ok := store.index.position(location)
+	if !ok {
+		return ErrFeatureNotFound
+	}
+	if other, ok := store.index.position(feature.Location); ok && other != position {
+		return ErrFeatureExists
+	}
+
+	feature = cloneFeature(feature)
+	features := store.index.all()
+	for i, existing := range features {
+		if existing == store.index.features[position] {
+			features[i] = feature
+		}
+	}
+	err = store.persist(features)
+	if err != nil {
+		return err
+	}
+
+	store.index.replace(position, feature)
+	return nil
+}
+
+func (store *FileFeatureStore) Delete(location *protoc.Point) (*protoc.Feature, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	position, ok := store.index.position(location)
+	if !ok {
+		return nil, ErrFeatureNotFound
+	}
+
+	deleted := store.index.features[position]
+	features := store.index.all()
+	for i, existing := range features {
+		if existing == deleted {
+			features = append(features[:i], features[i+1:]...)
+			break
+		}
+	}
+	err := store.persist(features)
+	if err != nil {
+		return nil, err
+	}
+
+	store.index.remove(position)
+	return deleted, nil
+}
+
+func (store *FileFeatureStore) Import(features []*protoc.Feature) (int, int, error) {
+	err := validateFeatures(features)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	// rows maps index positions to their place in the persisted list
+	all := make([]*protoc.Feature, 0, len(store.index.features)+len(features))
+	rows := make(map[int]int, len(store.index.features))
+	for position, feature := range store.index.features {
+		if feature != nil {
+			rows[position] = len(all)
+			all = append(all, feature)
+		}
+	}
+
+	replaced := make(map[int]*protoc.Feature)
+	var added []*protoc.Feature
+	for _, feature := range features {
+		feature = cloneFeature(feature)
+		if position, ok := store.index.position(feature.Location); ok {
+			replaced[position] = feature
+			all[rows[position]] = feature
+		} else {
+			added = append(added, feature)
+			all = append(all, feature)
+		}
+	}
+
+	err = store.persist(all)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for position, feature := range replaced {
+		store.index.replace(position, feature)
+	}
+	for _, feature := range added {
+		store.index.add(feature)
+	}
+	return len(added), len(replaced), nil
+}
+
+// persist atomically replaces the file with features, the caller must hold the write lock.
+func (store *FileFeatureStore) persist(features []*protoc.Feature) error {
+	if store.filename == "" {
+		return nil
+	}
+
+	var data []byte
+	var err error
+	if store.geoJSON {
+		data, err = geojson.MarshalFeatures(features)
+	} else {
+		data, err = json.MarshalIndent(features, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(store.filename), filepath.Base(store.filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot write features: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(store.filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	_, err = tmp.Write(append(data, '\n'))
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("cannot write features: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), store.filename)
+	if err != nil {
+		return fmt.Errorf("cannot write features: %w", err)
+	}
+
+	info, err := os.Stat(store.filename)
+	if err != nil {
+		return err
+	}
+	store.modTime, store.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// CheckHealth makes sure the features file is still there and its folder accepts the rewrites of a change.
+func (store *FileFeatureStore) CheckHealth(ctx context.Context) error {
+	if store.filename == "" {
+		return nil
+	}
+
+	info, err := os.Stat(store.filename)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("features %s is not a regular file", store.filename)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(store.filename), filepath.Base(store.filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("features folder is not writable: %w", err)
+	}
+	return errors.Join(tmp.Close(), os.Remove(tmp.Name()))
+}
+
+// Watch polls the file every interval and reloads it when it changed, until ctx is done.
+// A file that fails to load is logged and the current features are kept.
+func (store *FileFeatureStore) Watch(ctx context.Context, interval time.Duration) error {
+	if store.filename == "" {
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(store.filename)
+		if err != nil {
+			log.Printf("cannot stat features %s: %s", store.filename, err)
+			continue
+		}
+
+		store.mutex.RLock()
+		changed := !info.ModTime().Equal(store.modTime) || info.Size() != store.size
+		store.mutex.RUnlock()
+		if !changed {
+			continue
+		}
+
+		err = store.reload()
+		if err != nil {
+			log.Printf("keep previous features, cannot reload %s: %s", store.filename, err)
+			continue
+		}
+		log.Printf("features %s reloaded", store.filename)
+	}
+}
+
+func (store *FileFeatureStore) reload() error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	info, err := os.Stat(store.filename)
+	if err != nil {
+		return err
+	}
+	// remember the file version even when it is invalid, so a broken file is reported once
+	store.modTime, store.size = info.ModTime(), info.Size()
+
+	features, isGeoJSON, err := loadFeatures(store.filename)
+	if err != nil {
+		return err
+	}
+
+	store.geoJSON = isGeoJSON
+	store.index = newFeatureIndex(features, defaultFeatureCellSize)
+	return nil
+}
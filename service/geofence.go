@@ -0,0 +1,174 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-http-server/grpc/protoc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// regionEventBufferSize is the number of events a WatchRegion stream may lag behind before it is ended.
+const regionEventBufferSize = 256
+
+// region is an area the RecordRoute points are checked against.
+type region interface {
+	contains(point *protoc.Point) bool
+}
+
+type rectangleRegion struct {
+	rect *protoc.Rectangle
+}
+
+func (region rectangleRegion) contains(point *protoc.Point) bool {
+	return inRange(point, region.rect)
+}
+
+type polygonRegion struct {
+	vertices []*protoc.Point
+	bounds   *protoc.Rectangle
+}
+
+// newPolygonRegion checks the vertices of polygon are valid E7 coordinates and computes its bounding rectangle.
+func newPolygonRegion(polygon *protoc.Polygon) (polygonRegion, error) {
+	vertices := polygon.GetVertices()
+	if len(vertices) < 3 {
+		return polygonRegion{}, fmt.Errorf("polygon has %d vertices, want at least 3", len(vertices))
+	}
+
+	lo := &protoc.Point{Latitude: vertices[0].GetLatitude(), Longitude: vertices[0].GetLongitude()}
+	hi := &protoc.Point{Latitude: vertices[0].GetLatitude(), Longitude: vertices[0].GetLongitude()}
+	for i, vertex := range vertices {
+		err := validateLocation(vertex)
+		if err != nil {
+			return polygonRegion{}, fmt.Errorf("vertex %d: %w", i, err)
+		}
+
+		lo.Latitude, lo.Longitude = min(lo.Latitude, vertex.GetLatitude()), min(lo.Longitude, vertex.GetLongitude())
+		hi.Latitude, hi.Longitude = max(hi.Latitude, vertex.GetLatitude()), max(hi.Longitude, vertex.GetLongitude())
+	}
+
+	return polygonRegion{vertices: vertices, bounds: &protoc.Rectangle{Lo: lo, Hi: hi}}, nil
+}
+
+func (region polygonRegion) contains(point *protoc.Point) bool {
+	// the bounds keep the coordinates below in range, so the products never overflow
+	return inRange(point, region.bounds) && inPolygon(point, region.vertices)
+}
+
+// inPolygon checks if a point is within the polygon of vertices, borders included, with the even-odd rule.
+// The arithmetic is exact on E7 coordinates, so points on an edge are never misclassified.
+func inPolygon(point *protoc.Point, vertices []*protoc.Point) bool {
+	x, y := int64(point.GetLongitude()), int64(point.GetLatitude())
+
+	inside := false
+	for i, j := 0, len(vertices)-1; i < len(vertices); j, i = i, i+1 {
+		xi, yi := int64(vertices[i].GetLongitude()), int64(vertices[i].GetLatitude())
+		xj, yj := int64(vertices[j].GetLongitude()), int64(vertices[j].GetLatitude())
+
+		// the point lies on the edge when it is collinear with it and inside its bounding box
+		if (xj-xi)*(y-yi) == (yj-yi)*(x-xi) &&
+			x >= min(xi, xj) && x <= max(xi, xj) && y >= min(yi, yj) && y <= max(yi, yj) {
+			return true
+		}
+
+		// count the edges crossed by a ray going east from the point
+		if (yi > y) != (yj > y) {
+			lhs, rhs := (x-xi)*(yj-yi), (y-yi)*(xj-xi)
+			if (yj > yi && lhs < rhs) || (yj < yi && lhs > rhs) {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// regionHub routes the points of the RecordRoute streams to the WatchRegion streams.
+// Tracking a point never waits for a watcher: a watch lagging too far behind is ended.
+type regionHub struct {
+	mutex   sync.Mutex
+	watches map[*regionWatch]struct{}
+}
+
+// regionWatch is the state of one WatchRegion stream.
+type regionWatch struct {
+	region region
+	tenant string
+	events chan *protoc.RegionEvent
+	lagged chan struct{} // closed when events was full, the stream must then end
+
+	inside map[string]bool // ids of the routes whose last point was inside, guarded by the hub mutex
+}
+
+func newRegionHub() *regionHub {
+	return &regionHub{watches: make(map[*regionWatch]struct{})}
+}
+
+// watch starts routing the points of the routes of tenant crossing the boundary of region.
+func (hub *regionHub) watch(region region, tenant string) *regionWatch {
+	watch := &regionWatch{
+		region: region,
+		tenant: tenant,
+		events: make(chan *protoc.RegionEvent, regionEventBufferSize),
+		lagged: make(chan struct{}),
+		inside: make(map[string]bool),
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	hub.watches[watch] = struct{}{}
+	return watch
+}
+
+// unwatch stops routing points to watch.
+func (hub *regionHub) unwatch(watch *regionWatch) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	delete(hub.watches, watch)
+}
+
+// track sends an event to the watches of tenant whose region point enters or leaves.
+func (hub *regionHub) track(routeID, owner, tenant string, point *protoc.Point) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	now := timestamppb.New(time.Now())
+	for watch := range hub.watches {
+		if watch.tenant != tenant {
+			continue
+		}
+
+		inside := watch.region.contains(point)
+		if inside == watch.inside[routeID] {
+			continue
+		}
+
+		kind := protoc.RegionEvent_LEAVE
+		if inside {
+			kind = protoc.RegionEvent_ENTER
+			watch.inside[routeID] = true
+		} else {
+			delete(watch.inside, routeID)
+		}
+
+		select {
+		case watch.events <- &protoc.RegionEvent{Kind: kind, RouteId: routeID, Owner: owner, Point: point, ReceivedAt: now}:
+		default:
+			delete(hub.watches, watch)
+			close(watch.lagged)
+		}
+	}
+}
+
+// forget drops the state of a route whose stream ended.
+func (hub *regionHub) forget(routeID string) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	for watch := range hub.watches {
+		delete(watch.inside, routeID)
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthChecker is implemented by the stores backing a service.
+type HealthChecker interface {
+	// CheckHealth returns an error when the store cannot serve requests.
+	CheckHealth(ctx context.Context) error
+}
+
+// HealthMonitor periodically checks the stores backing each service and reports the results
+// on a grpc.health.v1 server: a service is NOT_SERVING while one of its stores fails, and the
+// whole server, the empty service name, while any service does.
+type HealthMonitor struct {
+	server   *health.Server
+	services []string
+	checkers map[string][]HealthChecker
+	statuses map[string]healthpb.HealthCheckResponse_ServingStatus // last status of each service, to log changes
+}
+
+// NewHealthMonitor creates a monitor reporting on server.
+func NewHealthMonitor(server *health.Server) *HealthMonitor {
+	return &HealthMonitor{
+		server:   server,
+		checkers: make(map[string][]HealthChecker),
+		statuses: make(map[string]healthpb.HealthCheckResponse_ServingStatus),
+	}
+}
+
+// Add registers checkers backing the fully qualified service, which is SERVING until checked.
+// Services must be added before Run.
+func (monitor *HealthMonitor) Add(service string, checkers ...HealthChecker) {
+	if _, ok := monitor.checkers[service]; !ok {
+		monitor.services = append(monitor.services, service)
+		monitor.statuses[service] = healthpb.HealthCheckResponse_SERVING
+		monitor.server.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+	monitor.checkers[service] = append(monitor.checkers[service], checkers...)
+}
+
+// Check runs every checker once, each bounded by timeout, and updates the statuses.
+func (monitor *HealthMonitor) Check(ctx context.Context, timeout time.Duration) {
+	overall := healthpb.HealthCheckResponse_SERVING
+	for _, service := range monitor.services {
+		status := healthpb.HealthCheckResponse_SERVING
+		for _, checker := range monitor.checkers[service] {
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			err := checker.CheckHealth(checkCtx)
+			cancel()
+			if err != nil {
+				if monitor.statuses[service] == healthpb.HealthCheckResponse_SERVING {
+					log.Printf("%s is not serving, health check failed: %s", service, err)
+				}
+				status = healthpb.HealthCheckResponse_NOT_SERVING
+				break
+			}
+		}
+
+		if status == healthpb.HealthCheckResponse_SERVING && monitor.statuses[service] != status {
+			log.Printf("%s is serving again", service)
+		}
+		monitor.statuses[service] = status
+		monitor.server.SetServingStatus(service, status)
+		if status != healthpb.HealthCheckResponse_SERVING {
+			overall = status
+		}
+	}
+	monitor.server.SetServingStatus("", overall)
+}
+
+// Run checks the stores every interval until ctx is done.
+func (monitor *HealthMonitor) Run(ctx context.Context, interval, timeout time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		monitor.Check(ctx, timeout)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown sets every service NOT_SERVING for good, so load balancers stop routing new calls
+// before the server drains.
+func (monitor *HealthMonitor) Shutdown() {
+	monitor.server.Shutdown()
+}
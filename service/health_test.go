@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-http-server/grpc/protoc"
+	"github.com/go-http-server/grpc/sample"
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type fakeHealthChecker struct {
+	err error
+}
+
+func (checker *fakeHealthChecker) CheckHealth(ctx context.Context) error {
+	return checker.err
+}
+
+func TestHealthMonitor(t *testing.T) {
+	t.Parallel()
+
+	healthServer := health.NewServer()
+	monitor := service.NewHealthMonitor(healthServer)
+
+	requireStatus := func(service string, expected healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		res, err := healthServer.Check(t.Context(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		require.Equal(t, expected, res.GetStatus(), service)
+	}
+
+	features := &fakeHealthChecker{}
+	monitor.Add("LaptopService", service.NewInMemoryLaptopStore(), service.NewDiskImageStore(t.TempDir()))
+	monitor.Add("RouteGuide", features)
+	monitor.Check(t.Context(), time.Second)
+	requireStatus("", healthpb.HealthCheckResponse_SERVING)
+	requireStatus("LaptopService", healthpb.HealthCheckResponse_SERVING)
+	requireStatus("RouteGuide", healthpb.HealthCheckResponse_SERVING)
+
+	features.err = errors.New("features file removed")
+	monitor.Check(t.Context(), time.Second)
+	requireStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	requireStatus("LaptopService", healthpb.HealthCheckResponse_SERVING)
+	requireStatus("RouteGuide", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	features.err = nil
+	monitor.Check(t.Context(), time.Second)
+	requireStatus("RouteGuide", healthpb.HealthCheckResponse_SERVING)
+
+	// the shutdown is final
+	monitor.Shutdown()
+	monitor.Check(t.Context(), time.Second)
+	requireStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	requireStatus("LaptopService", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	missing := service.NewDiskImageStore(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, missing.CheckHealth(t.Context()))
+}
+
+func TestInMemoryLaptopStoreCheckHealth(t *testing.T) {
+	t.Parallel()
+
+	store := service.NewInMemoryLaptopStore()
+	require.NoError(t, store.Save(sample.NewLaptop()))
+	require.NoError(t, store.CheckHealth(t.Context()))
+
+	// a search holding the lock keeps a writer waiting, and the writer keeps the check waiting
+	searching := make(chan struct{})
+	release := make(chan struct{})
+	go store.Search(t.Context(), &protoc.Filter{MaxPriceUsd: math.MaxFloat64}, func(*protoc.Laptop) error {
+		close(searching)
+		<-release
+		return nil
+	})
+	<-searching
+	saved := make(chan error)
+	go func() {
+		saved <- store.Save(sample.NewLaptop())
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, store.CheckHealth(ctx), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, <-saved)
+	require.NoError(t, store.CheckHealth(t.Context()))
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
@@ -34,6 +36,16 @@ func NewDiskImageStore(imageFolder string) *DiskImageStore {
 	return &DiskImageStore{imageFolder: imageFolder, images: make(map[string]*MapInfo)}
 }
 
+// CheckHealth makes sure images can be written to the image folder.
+func (store *DiskImageStore) CheckHealth(ctx context.Context) error {
+	file, err := os.CreateTemp(store.imageFolder, ".health-*")
+	if err != nil {
+		return fmt.Errorf("image folder is not writable: %w", err)
+	}
+
+	return errors.Join(file.Close(), os.Remove(file.Name()))
+}
+
 func (store *DiskImageStore) Save(laptopID string, imageType string, imageData bytes.Buffer) (string, error) {
 	imgID, err := uuid.NewRandom()
 	if err != nil {
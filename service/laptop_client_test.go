@@ -37,7 +37,7 @@ func TestClientCreateLaptop(t *testing.T) {
 	require.NotNil(t, res)
 	require.Equal(t, expectedID, res.GetId())
 
-	laptopFound, err := laptopStore.Find(expectedID)
+	laptopFound, err := laptopStore.Find("", expectedID)
 	require.NoError(t, err)
 	require.NotNil(t, laptopFound)
 	require.Equal(t, expectedID, laptopFound.GetId())
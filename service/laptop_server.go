@@ -63,6 +63,9 @@ func (s *LaptopServer) CreateLaptop(ctx context.Context, req *protoc.CreateLapto
 		return nil, err
 	}
 
+	// the caller becomes the owner, clients cannot pick another owner or tenant
+	laptopReq.Owner, laptopReq.Tenant = callerIdentity(ctx)
+
 	// save laptop to database
 	err := s.LaptopStore.Save(laptopReq)
 	if err != nil {
@@ -83,6 +86,25 @@ func (s *LaptopServer) CreateLaptop(ctx context.Context, req *protoc.CreateLapto
 	return res, nil
 }
 
+// GetLaptop returns a laptop of the caller's tenant, laptops of other tenants are reported as not found.
+func (s *LaptopServer) GetLaptop(ctx context.Context, req *protoc.GetLaptopRequest) (*protoc.Laptop, error) {
+	_, tenant := callerIdentity(ctx)
+	laptop, err := s.LaptopStore.Find(tenant, req.GetId())
+	if err != nil {
+		if errors.Is(err, ErrLaptopNotFound) {
+			return nil, status.Errorf(codes.NotFound, "laptop with id %s not found", req.GetId())
+		}
+		return nil, status.Errorf(codes.Internal, "cannot find laptop with id %s: %s", req.GetId(), err)
+	}
+
+	err = authorizeLaptop(ctx, laptop, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return laptop, nil
+}
+
 // SearchLaptop handles the search for laptops based on filter criteria.
 func (s *LaptopServer) SearchLaptop(req *protoc.SearchLaptopRequest, streaming grpc.ServerStreamingServer[protoc.SearchLaptopResponse]) error {
 	defer func() {
@@ -126,13 +148,17 @@ func (s *LaptopServer) UploadImage(clientStreaming grpc.ClientStreamingServer[pr
 	imageType := req.GetInfo().GetImageType()
 	log.Printf("Received request to upload image for laptop: %s, type: %s", laptopID, imageType)
 
-	laptop, err := s.LaptopStore.Find(laptopID)
+	_, tenant := callerIdentity(clientStreaming.Context())
+	laptop, err := s.LaptopStore.Find(tenant, laptopID)
 	if err != nil {
 		return status.Errorf(codes.Internal, "cannot find laptop with id %s: %s", laptopID, err)
 	}
 	if laptop == nil {
 		return status.Errorf(codes.InvalidArgument, "laptop not found")
 	}
+	if err := authorizeLaptop(clientStreaming.Context(), laptop, true); err != nil {
+		return err
+	}
 
 	imageData := bytes.Buffer{}
 	imageSize := 0
@@ -213,7 +239,8 @@ func (s *LaptopServer) RateLaptop(stream grpc.BidiStreamingServer[protoc.RateLap
 		score := req.GetScore()
 		log.Printf("Received rating for laptop %s with score %.2f", laptopID, score)
 
-		found, err := s.LaptopStore.Find(laptopID)
+		_, tenant := callerIdentity(stream.Context())
+		found, err := s.LaptopStore.Find(tenant, laptopID)
 		if err != nil {
 			return status.Errorf(codes.Internal, "cannot find laptop with id %s: %s", laptopID, err)
 		}
@@ -221,8 +248,11 @@ func (s *LaptopServer) RateLaptop(stream grpc.BidiStreamingServer[protoc.RateLap
 		if found == nil {
 			return status.Errorf(codes.NotFound, "laptop with id %s not found", laptopID)
 		}
+		if err := authorizeLaptop(stream.Context(), found, false); err != nil {
+			return err
+		}
 
-		rating, err := s.RateStore.AddRating(laptopID, score)
+		rating, err := s.RateStore.AddRating(tenant, laptopID, score)
 		if err != nil {
 			return status.Errorf(codes.Internal, "cannot add rating for laptop %s: %s", laptopID, err)
 		}
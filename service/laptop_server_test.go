@@ -86,3 +86,60 @@ func TestServerCreateLaptop(t *testing.T) {
 		})
 	}
 }
+
+func TestServerLaptopOwnerAndTenant(t *testing.T) {
+	t.Parallel()
+
+	store := service.NewInMemoryLaptopStore()
+	server := service.NewLaptopServer(store, nil, nil)
+
+	seller := service.ContextWithPayload(context.Background(), &service.Payload{Username: "seller", Role: "admin", Tenant: "acme"})
+	colleague := service.ContextWithPayload(context.Background(), &service.Payload{Username: "colleague", Role: "admin", Tenant: "acme"})
+	outsider := service.ContextWithPayload(context.Background(), &service.Payload{Username: "outsider", Role: "admin", Tenant: "globex"})
+
+	laptop := sample.NewLaptop()
+	laptop.Owner = "outsider" // the caller always becomes the owner
+	res, err := server.CreateLaptop(seller, &protoc.CreateLaptopRequest{Laptop: laptop})
+	require.NoError(t, err)
+
+	saved, err := store.Find("acme", res.GetId())
+	require.NoError(t, err)
+	require.Equal(t, "seller", saved.GetOwner())
+	require.Equal(t, "acme", saved.GetTenant())
+
+	search := func(ctx context.Context) []string {
+		var ids []string
+		filter := &protoc.Filter{MaxPriceUsd: 1e6}
+		err := store.Search(ctx, filter, func(laptop *protoc.Laptop) error {
+			ids = append(ids, laptop.GetId())
+			return nil
+		})
+		require.NoError(t, err)
+		return ids
+	}
+	require.Equal(t, []string{res.GetId()}, search(colleague))
+	require.Empty(t, search(outsider))
+	require.Empty(t, search(context.Background()))
+
+	found, err := server.GetLaptop(colleague, &protoc.GetLaptopRequest{Id: res.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, res.GetId(), found.GetId())
+	for _, ctx := range []context.Context{outsider, context.Background()} {
+		_, err = server.GetLaptop(ctx, &protoc.GetLaptopRequest{Id: res.GetId()})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	}
+	_, err = server.GetLaptop(seller, &protoc.GetLaptopRequest{Id: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// the IDs are unique within a tenant, another tenant reusing one does not learn it is taken
+	_, err = server.CreateLaptop(colleague, &protoc.CreateLaptopRequest{Laptop: &protoc.Laptop{Id: res.GetId()}})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = server.CreateLaptop(outsider, &protoc.CreateLaptopRequest{Laptop: &protoc.Laptop{Id: res.GetId(), Brand: "globex"}})
+	require.NoError(t, err)
+	found, err = server.GetLaptop(colleague, &protoc.GetLaptopRequest{Id: res.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, laptop.GetBrand(), found.GetBrand())
+	found, err = server.GetLaptop(outsider, &protoc.GetLaptopRequest{Id: res.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, "globex", found.GetBrand())
+}
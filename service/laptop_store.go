@@ -10,39 +10,68 @@ import (
 	"github.com/jinzhu/copier"
 )
 
-var ErrAlreadyExists = errors.New("laptop already exists")
+var (
+	ErrAlreadyExists  = errors.New("laptop already exists")
+	ErrLaptopNotFound = errors.New("laptop not found")
+)
 
-// LaptopStore defines the interface for storing laptops.
+// LaptopStore defines the interface for storing laptops. The IDs are unique within a tenant, so
+// tenants cannot tell the IDs of each other's laptops are taken.
 type LaptopStore interface {
 	// Save persists a laptop to the storage.
 	Save(laptop *protoc.Laptop) error
 
-	// Find retrieves a laptop by its ID.
-	Find(id string) (*protoc.Laptop, error)
+	// Find retrieves a laptop of tenant by its ID.
+	Find(tenant, id string) (*protoc.Laptop, error)
 
+	// Search streams the laptops matching filter that belong to the tenant of the caller in ctx.
 	Search(ctx context.Context, filter *protoc.Filter, found func(laptop *protoc.Laptop) error) error
 }
 
 // InMemoryLaptopStore is an in-memory implementation of LaptopStore.
 type InMemoryLaptopStore struct {
 	mu      sync.RWMutex
-	laptops map[string]*protoc.Laptop
+	laptops map[laptopKey]*protoc.Laptop
+}
+
+// laptopKey identifies a laptop across tenants.
+type laptopKey struct {
+	tenant, id string
 }
 
 // NewInMemoryLaptopStore creates a new instance of InMemoryLaptopStore.
 func NewInMemoryLaptopStore() *InMemoryLaptopStore {
 	return &InMemoryLaptopStore{
-		laptops: make(map[string]*protoc.Laptop),
+		laptops: make(map[laptopKey]*protoc.Laptop),
 	}
 }
 
 // TODO: use ScyllaDB storage to save laptops
 
+// CheckHealth makes sure the store is not stuck behind a writer, an in-memory store cannot fail otherwise.
+// It gives up when ctx is done, the lock is then released by the goroutine waiting for it.
+func (mem *InMemoryLaptopStore) CheckHealth(ctx context.Context) error {
+	locked := make(chan struct{})
+	go func() {
+		mem.mu.RLock()
+		mem.mu.RUnlock()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (mem *InMemoryLaptopStore) Save(laptop *protoc.Laptop) error {
 	mem.mu.Lock()
 	defer mem.mu.Unlock()
 
-	if mem.laptops[laptop.Id] != nil {
+	key := laptopKey{tenant: laptop.GetTenant(), id: laptop.GetId()}
+	if mem.laptops[key] != nil {
 		return ErrAlreadyExists
 	}
 
@@ -52,17 +81,17 @@ func (mem *InMemoryLaptopStore) Save(laptop *protoc.Laptop) error {
 		return err
 	}
 
-	mem.laptops[laptop.Id] = other
+	mem.laptops[key] = other
 	return nil
 }
 
-func (mem *InMemoryLaptopStore) Find(id string) (*protoc.Laptop, error) {
+func (mem *InMemoryLaptopStore) Find(tenant, id string) (*protoc.Laptop, error) {
 	mem.mu.RLock()
 	defer mem.mu.RUnlock()
 
-	laptop, ok := mem.laptops[id]
+	laptop, ok := mem.laptops[laptopKey{tenant: tenant, id: id}]
 	if !ok {
-		return nil, fmt.Errorf("laptop with id %s not found", id)
+		return nil, fmt.Errorf("laptop with id %s: %w", id, ErrLaptopNotFound)
 	}
 
 	// deep copy the laptop to avoid external modifications
@@ -79,7 +108,7 @@ func (mem *InMemoryLaptopStore) Search(ctx context.Context, filter *protoc.Filte
 			return err
 		}
 
-		if isQualified(filter, laptop) {
+		if canSeeLaptop(ctx, laptop) && isQualified(filter, laptop) {
 			// deep copy the laptop to avoid external modifications
 			other, err := deepCopyLaptop(laptop)
 			if err != nil {
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// PriorityHeader is the metadata header a caller sets the priority of its calls with, up to the
+// priority of its role.
+const PriorityHeader = "x-priority"
+
+const (
+	shortLatencyWeight = 0.2       // weight of a sample in the latency of the last calls
+	longLatencyWeight  = 2.0 / 601 // weight of a sample in the baseline latency, about the last 600 calls
+	minLimitGradient   = 0.5       // the limit shrinks by half at most per estimate
+	baselineRecovery   = 0.95      // decay of a baseline latency far above the recent one
+)
+
+// Priority orders calls for load shedding, lower priorities are shed first.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	PriorityCritical
+)
+
+var priorityNames = [...]string{PriorityLow: "low", PriorityNormal: "normal", PriorityHigh: "high", PriorityCritical: "critical"}
+
+// priorityShares are the shares of the concurrency limit the calls of each priority may fill.
+var priorityShares = [...]float64{PriorityLow: 0.5, PriorityNormal: 0.75, PriorityHigh: 0.9, PriorityCritical: 1}
+
+// ParsePriority returns the priority named "low", "normal", "high" or "critical".
+func ParsePriority(name string) (Priority, error) {
+	for priority, priorityName := range priorityNames {
+		if name == priorityName {
+			return Priority(priority), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority %q", name)
+}
+
+func (priority Priority) String() string {
+	return priorityNames[priority]
+}
+
+// LoadShedConfig configures the adaptive concurrency limit of a LoadShedInterceptor.
+type LoadShedConfig struct {
+	InitialLimit int // unary calls in progress allowed before latencies are measured
+	MinLimit     int
+	MaxLimit     int
+	// Tolerance is the growth of the latency of the last calls over the baseline latency tolerated
+	// before the limit shrinks, e.g. 2.
+	Tolerance float64
+	// Smoothing is the weight of each new estimate of the limit, between 0 and 1.
+	Smoothing      float64
+	RolePriorities map[string]Priority // priority of the callers of each role, normal by default
+}
+
+// LoadShedInterceptor sheds calls when the server is overloaded. It estimates how many unary calls
+// may be in progress at once from their latency, with a gradient: the limit shrinks as the latency
+// of the last calls grows over the baseline latency, and grows while the latency stays within the
+// tolerance and the calls in progress use the limit. Calls of each priority may only fill a share of
+// the limit, so low priority calls are shed first, with Unavailable for clients to back off and retry.
+//
+// Streams are shed alike but neither counted in progress nor measured, as long-lived streams such as
+// WatchRegion would hold the limit and skew the latency.
+//
+// Unary and Stream run first, to shed calls before they are validated and authenticated: they admit
+// calls up to the share of the highest role priority, lowered by the priority header. RoleUnary and
+// RoleStream run last, after the AuthInterceptor and the rate limits, to shed the admitted calls over
+// the share of their role. Only the latency of the unary calls reaching the handler is measured, as
+// calls failing early, e.g. unauthenticated or throttled, would hide the latency of the others.
+type LoadShedInterceptor struct {
+	config      LoadShedConfig
+	maxPriority Priority // highest priority a caller may get from its role
+
+	mutex        sync.Mutex
+	limit        float64
+	inProgress   int
+	shortLatency float64 // seconds, moving average of the last calls, 0 before the first call
+	longLatency  float64 // seconds, moving average of the baseline
+	now          func() time.Time
+
+	shed metric.Int64Counter // nil until RegisterMetrics
+}
+
+// NewLoadShedInterceptor creates a new LoadShedInterceptor with the given configuration.
+func NewLoadShedInterceptor(config LoadShedConfig) (*LoadShedInterceptor, error) {
+	if config.MinLimit < 1 || config.InitialLimit < config.MinLimit || config.MaxLimit < config.InitialLimit {
+		return nil, fmt.Errorf("load shedding limits must satisfy 1 <= min %d <= initial %d <= max %d", config.MinLimit, config.InitialLimit, config.MaxLimit)
+	}
+	if config.Tolerance < 1 || config.Smoothing <= 0 || config.Smoothing > 1 {
+		return nil, fmt.Errorf("load shedding tolerance must be at least 1 and smoothing between 0 and 1")
+	}
+
+	maxPriority := PriorityNormal
+	for _, priority := range config.RolePriorities {
+		maxPriority = max(maxPriority, priority)
+	}
+
+	return &LoadShedInterceptor{
+		config:      config,
+		maxPriority: maxPriority,
+		limit:       float64(config.InitialLimit),
+		now:         time.Now,
+	}, nil
+}
+
+// Limit returns the current estimate of the unary calls that may be in progress at once.
+func (interceptor *LoadShedInterceptor) Limit() int {
+	interceptor.mutex.Lock()
+	defer interceptor.mutex.Unlock()
+
+	return int(interceptor.limit)
+}
+
+// RegisterMetrics exports the shed calls and the state of the limiter: its limit, the unary calls in
+// progress and the latencies it compares.
+func (interceptor *LoadShedInterceptor) RegisterMetrics(meter metric.Meter) error {
+	shed, err := meter.Int64Counter("grpc.server.call.shed",
+		metric.WithDescription("Calls shed by the adaptive concurrency limit."),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	limit, err := meter.Int64ObservableGauge("grpc.server.load_shedding.limit",
+		metric.WithDescription("Unary calls that may be in progress at once."),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return err
+	}
+	inProgress, err := meter.Int64ObservableGauge("grpc.server.load_shedding.in_progress",
+		metric.WithDescription("Unary calls in progress."),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return err
+	}
+	latency, err := meter.Float64ObservableGauge("grpc.server.load_shedding.latency",
+		metric.WithDescription("Moving averages of the unary call latency, of the last calls and of the baseline."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		interceptor.mutex.Lock()
+		defer interceptor.mutex.Unlock()
+
+		observer.ObserveInt64(limit, int64(interceptor.limit))
+		observer.ObserveInt64(inProgress, int64(interceptor.inProgress))
+		observer.ObserveFloat64(latency, interceptor.shortLatency, metric.WithAttributes(attribute.String("window", "short")))
+		observer.ObserveFloat64(latency, interceptor.longLatency, metric.WithAttributes(attribute.String("window", "long")))
+		return nil
+	}, limit, inProgress, latency)
+	if err != nil {
+		return err
+	}
+
+	interceptor.mutex.Lock()
+	interceptor.shed = shed
+	interceptor.mutex.Unlock()
+	return nil
+}
+
+// admission is the priority a call was admitted with before authentication.
+type admission struct {
+	priority Priority
+	counted  bool // the call is counted in progress
+	reached  bool // the call reached the handler, its latency is measured
+}
+
+type admissionContextKey struct{}
+
+// Unary returns a unary server interceptor that sheds calls over the limit of the priority they may
+// get, and measures the latency of the others. It must run before the costly interceptors.
+func (interceptor *LoadShedInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		admitted, err := interceptor.admit(ctx, info.FullMethod, true)
+		if err != nil {
+			return nil, err
+		}
+
+		start := interceptor.now()
+		defer func() {
+			interceptor.complete(interceptor.now().Sub(start), admitted.reached)
+		}()
+		return handler(context.WithValue(ctx, admissionContextKey{}, admitted), req)
+	}
+}
+
+// Stream returns a stream server interceptor that sheds streams over the limit of the priority they
+// may get. It must run before the costly interceptors.
+func (interceptor *LoadShedInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		admitted, err := interceptor.admit(ss.Context(), info.FullMethod, false)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.WithValue(ss.Context(), admissionContextKey{}, admitted)
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// RoleUnary returns a unary server interceptor shedding the calls admitted by Unary over the limit of
+// the priority of their role. It must run last, after the AuthInterceptor.
+func (interceptor *LoadShedInterceptor) RoleUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		err := interceptor.refine(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// RoleStream returns a stream server interceptor shedding the streams admitted by Stream over the
+// limit of the priority of their role. It must run last, after the AuthInterceptor.
+func (interceptor *LoadShedInterceptor) RoleStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := interceptor.refine(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// admit sheds a call over the share of the highest priority its caller may get before it is
+// authenticated, or admits it, counting it in progress if counted is set.
+func (interceptor *LoadShedInterceptor) admit(ctx context.Context, method string, counted bool) (*admission, error) {
+	priority := interceptor.maxPriority
+	if requested, ok := requestedPriority(ctx); ok {
+		priority = min(priority, requested)
+	}
+
+	interceptor.mutex.Lock()
+	defer interceptor.mutex.Unlock()
+
+	if interceptor.inProgress >= interceptor.allowed(priority) {
+		return nil, interceptor.shedError(ctx, method, priority)
+	}
+
+	if counted {
+		interceptor.inProgress++
+	}
+	return &admission{priority: priority, counted: counted}, nil
+}
+
+// refine sheds an admitted call over the share of the priority of its role, when it is lower than
+// the one it was admitted with, or marks it as reaching the handler.
+func (interceptor *LoadShedInterceptor) refine(ctx context.Context, method string) error {
+	admitted, ok := ctx.Value(admissionContextKey{}).(*admission)
+	if !ok {
+		return nil
+	}
+	priority := interceptor.priority(ctx)
+	if priority >= admitted.priority {
+		admitted.reached = true
+		return nil
+	}
+
+	interceptor.mutex.Lock()
+	defer interceptor.mutex.Unlock()
+
+	// the other calls in progress, the call itself is counted
+	inProgress := interceptor.inProgress
+	if admitted.counted {
+		inProgress--
+	}
+	if inProgress >= interceptor.allowed(priority) {
+		return interceptor.shedError(ctx, method, priority)
+	}
+	admitted.reached = true
+	return nil
+}
+
+// allowed returns the calls in progress the calls of priority may fill, the caller must hold the mutex.
+func (interceptor *LoadShedInterceptor) allowed(priority Priority) int {
+	return max(1, int(interceptor.limit*priorityShares[priority]))
+}
+
+// shedError counts a shed call and returns its Unavailable error, the caller must hold the mutex.
+func (interceptor *LoadShedInterceptor) shedError(ctx context.Context, method string, priority Priority) error {
+	if interceptor.shed != nil {
+		interceptor.shed.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("grpc.method", method),
+			attribute.String("priority", priority.String()),
+		))
+	}
+	return status.Errorf(codes.Unavailable, "server overloaded, %s priority calls are shed", priority)
+}
+
+// complete ends a unary call, and records its latency to update the limit when measured is set.
+func (interceptor *LoadShedInterceptor) complete(latency time.Duration, measured bool) {
+	interceptor.mutex.Lock()
+	defer interceptor.mutex.Unlock()
+
+	// the calls in progress along with this one tell whether the limit is used
+	inProgress := interceptor.inProgress
+	interceptor.inProgress--
+	if !measured {
+		return
+	}
+
+	sample := latency.Seconds()
+	if interceptor.longLatency == 0 {
+		interceptor.shortLatency, interceptor.longLatency = sample, sample
+		return
+	}
+	interceptor.shortLatency += (sample - interceptor.shortLatency) * shortLatencyWeight
+	interceptor.longLatency += (sample - interceptor.longLatency) * longLatencyWeight
+	if interceptor.longLatency > 2*interceptor.shortLatency {
+		// the load dropped after a long overload, let the baseline follow
+		interceptor.longLatency *= baselineRecovery
+	}
+	if interceptor.shortLatency <= 0 {
+		return
+	}
+
+	gradient := math.Max(minLimitGradient, math.Min(1, interceptor.config.Tolerance*interceptor.longLatency/interceptor.shortLatency))
+	if gradient == 1 && float64(inProgress) < interceptor.limit/2 {
+		// the latency is fine, but the limit is not used enough to tell whether it could grow
+		return
+	}
+	// the queue allowance lets the limit grow, and keeps it from collapsing
+	estimate := interceptor.limit*gradient + math.Sqrt(interceptor.limit)
+	limit := interceptor.limit*(1-interceptor.config.Smoothing) + estimate*interceptor.config.Smoothing
+	interceptor.limit = math.Max(float64(interceptor.config.MinLimit), math.Min(float64(interceptor.config.MaxLimit), limit))
+}
+
+// priority returns the priority of the call: the one of the role of the caller, normal when it has
+// none, lowered by the priority header.
+func (interceptor *LoadShedInterceptor) priority(ctx context.Context) Priority {
+	priority := PriorityNormal
+	if payload, ok := PayloadFromContext(ctx); ok {
+		if rolePriority, ok := interceptor.config.RolePriorities[payload.Role]; ok {
+			priority = rolePriority
+		}
+	}
+
+	if requested, ok := requestedPriority(ctx); ok {
+		priority = min(priority, requested)
+	}
+	return priority
+}
+
+// requestedPriority returns the priority set by the priority header, if valid.
+func requestedPriority(ctx context.Context) (Priority, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(PriorityHeader)
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	priority, err := ParsePriority(values[0])
+	return priority, err == nil
+}
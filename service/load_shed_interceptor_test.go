@@ -0,0 +1,214 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testLoadShedConfig returns a configuration with a fixed limit of 10 calls in progress.
+func testLoadShedConfig() service.LoadShedConfig {
+	return service.LoadShedConfig{
+		InitialLimit:   10,
+		MinLimit:       10,
+		MaxLimit:       10,
+		Tolerance:      2,
+		Smoothing:      0.2,
+		RolePriorities: map[string]service.Priority{"admin": service.PriorityHigh},
+	}
+}
+
+func TestLoadShedInterceptorLatency(t *testing.T) {
+	t.Parallel()
+
+	config := testLoadShedConfig()
+	config.InitialLimit, config.MinLimit, config.MaxLimit = 100, 1, 1000
+	interceptor, err := service.NewLoadShedInterceptor(config)
+	require.NoError(t, err)
+	unary, role := interceptor.Unary(), interceptor.RoleUnary()
+	info := &grpc.UnaryServerInfo{FullMethod: "/LaptopService/SearchLaptop"}
+	// call runs the interceptors around a rate limiter, throttling the call when throttle returns true
+	call := func(throttle func() bool, latency time.Duration) error {
+		_, err := unary(t.Context(), nil, info, func(ctx context.Context, req any) (any, error) {
+			if throttle() {
+				return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+			}
+			return role(ctx, req, info, func(context.Context, any) (any, error) {
+				time.Sleep(latency)
+				return nil, nil
+			})
+		})
+		return err
+	}
+	pass := func() bool { return false }
+
+	// fast calls using little of the limit leave it unchanged
+	for range 20 {
+		require.NoError(t, call(pass, 0))
+	}
+	require.Equal(t, 100, interceptor.Limit())
+
+	// the limit shrinks once the latency grows over the tolerance
+	for range 20 {
+		require.NoError(t, call(pass, 20*time.Millisecond))
+	}
+	limit := interceptor.Limit()
+	require.Less(t, limit, 50)
+
+	// a flood of throttled calls using the limit does not make it grow back
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	held := make(chan struct{})
+	for range limit / 2 {
+		wg.Go(func() {
+			call(func() bool {
+				held <- struct{}{}
+				<-release
+				return true
+			}, 0)
+		})
+		<-held
+	}
+	for range 100 {
+		require.Equal(t, codes.ResourceExhausted, status.Code(call(func() bool { return true }, 0)))
+	}
+	require.Equal(t, limit, interceptor.Limit())
+	close(release)
+	wg.Wait()
+	require.Equal(t, limit, interceptor.Limit())
+}
+
+func TestLoadShedInterceptorPriority(t *testing.T) {
+	t.Parallel()
+
+	interceptor, err := service.NewLoadShedInterceptor(testLoadShedConfig())
+	require.NoError(t, err)
+	reader := sdkmetric.NewManualReader()
+	require.NoError(t, interceptor.RegisterMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")))
+	unary, role := interceptor.Unary(), interceptor.RoleUnary()
+	info := &grpc.UnaryServerInfo{FullMethod: "/LaptopService/SearchLaptop"}
+
+	// 7 calls in progress fill the shares of the low and normal priorities, 5 and 7 calls
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	started := make(chan struct{})
+	for range 7 {
+		wg.Go(func() {
+			unary(t.Context(), nil, info, func(context.Context, any) (any, error) {
+				started <- struct{}{}
+				<-release
+				return nil, nil
+			})
+		})
+		<-started
+	}
+	defer func() {
+		close(release)
+		wg.Wait()
+	}()
+
+	// before authentication calls may get the high priority of admins, unless they lower it
+	testCases := []struct {
+		name     string
+		role     string
+		priority string
+		code     codes.Code
+		preAuth  bool // shed before authentication
+	}{
+		{name: "anonymous", code: codes.Unavailable},
+		{name: "anonymous lowering its priority", priority: "low", code: codes.Unavailable, preAuth: true},
+		{name: "user", role: "user", code: codes.Unavailable},
+		{name: "admin", role: "admin", code: codes.OK},
+		{name: "admin lowering its priority", role: "admin", priority: "low", code: codes.Unavailable, preAuth: true},
+		{name: "user raising its priority", role: "user", priority: "critical", code: codes.Unavailable},
+		{name: "unknown priority", role: "admin", priority: "urgent", code: codes.OK},
+	}
+	shed := 0
+	for _, tc := range testCases {
+		ctx := t.Context()
+		if tc.role != "" {
+			ctx = service.ContextWithPayload(ctx, &service.Payload{Username: tc.name, Role: tc.role})
+		}
+		if tc.priority != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(service.PriorityHeader, tc.priority))
+		}
+
+		// the payload stands for the one the AuthInterceptor sets between both stages
+		authenticated := false
+		_, err := unary(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			authenticated = true
+			return role(ctx, req, info, func(context.Context, any) (any, error) {
+				return nil, nil
+			})
+		})
+		require.Equal(t, tc.code, status.Code(err), tc.name)
+		require.Equal(t, tc.preAuth, !authenticated, tc.name)
+		if err != nil {
+			shed++
+		}
+	}
+
+	var metrics metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &metrics))
+	values := map[string]int64{}
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, point := range data.DataPoints {
+					values[m.Name] += point.Value
+				}
+			case metricdata.Gauge[int64]:
+				for _, point := range data.DataPoints {
+					values[m.Name] += point.Value
+				}
+			}
+		}
+	}
+	require.EqualValues(t, shed, values["grpc.server.call.shed"])
+	require.EqualValues(t, 10, values["grpc.server.load_shedding.limit"])
+	require.EqualValues(t, 7, values["grpc.server.load_shedding.in_progress"])
+}
+
+func TestParsePriority(t *testing.T) {
+	t.Parallel()
+
+	for _, priority := range []service.Priority{service.PriorityLow, service.PriorityNormal, service.PriorityHigh, service.PriorityCritical} {
+		parsed, err := service.ParsePriority(priority.String())
+		require.NoError(t, err)
+		require.Equal(t, priority, parsed)
+	}
+	_, err := service.ParsePriority("urgent")
+	require.Error(t, err)
+}
+
+func TestNewLoadShedInterceptorInvalid(t *testing.T) {
+	t.Parallel()
+
+	for name, update := range map[string]func(*service.LoadShedConfig){
+		"no min limit":       func(config *service.LoadShedConfig) { config.MinLimit = 0 },
+		"initial over max":   func(config *service.LoadShedConfig) { config.InitialLimit = 11 },
+		"low tolerance":      func(config *service.LoadShedConfig) { config.Tolerance = 0.5 },
+		"no smoothing":       func(config *service.LoadShedConfig) { config.Smoothing = 0 },
+		"smoothing over one": func(config *service.LoadShedConfig) { config.Smoothing = 1.5 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config := testLoadShedConfig()
+			update(&config)
+			_, err := service.NewLoadShedInterceptor(config)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	usernameKeyPrefix = "username:"
+	peerKeyPrefix     = "peer:"
+)
+
+// LoginLimiterConfig configures the sliding window and the exponential lockout of a LoginLimiter.
+type LoginLimiterConfig struct {
+	MaxFailures int           // failures allowed inside Window before a key is locked
+	Window      time.Duration // sliding window failures are counted in
+	BaseLockout time.Duration // duration of the first lockout, doubled for each following one
+	MaxLockout  time.Duration // upper bound of a lockout duration
+}
+
+// LoginLockout is a snapshot of the failed login state of a username or a peer.
+type LoginLockout struct {
+	Username       string // set when the state belongs to a username
+	Peer           string // set when the state belongs to a peer IP address
+	RecentFailures int
+	LockoutCount   int
+	LockedUntil    time.Time // zero when the key is not locked
+}
+
+// loginAttempts holds the failure timestamps and lockout state of one key.
+type loginAttempts struct {
+	failures    []time.Time
+	lockouts    int
+	lockedUntil time.Time
+}
+
+// LoginLimiter throttles failed logins per username and per peer IP address.
+type LoginLimiter struct {
+	mutex     sync.Mutex
+	config    LoginLimiterConfig
+	attempts  map[string]*loginAttempts
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewLoginLimiter creates a new LoginLimiter with the given configuration.
+func NewLoginLimiter(config LoginLimiterConfig) *LoginLimiter {
+	return &LoginLimiter{
+		config:   config,
+		attempts: make(map[string]*loginAttempts),
+		now:      time.Now,
+	}
+}
+
+// Allow reports whether a login for username from peer may be attempted now.
+func (limiter *LoginLimiter) Allow(username, peer string) bool {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := limiter.now()
+	for _, key := range limiterKeys(username, peer) {
+		if attempts, ok := limiter.attempts[key]; ok && now.Before(attempts.lockedUntil) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RecordFailure counts a failed login for username and peer, locking out any key that reaches the limit.
+func (limiter *LoginLimiter) RecordFailure(username, peer string) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := limiter.now()
+	limiter.prune(now)
+
+	for _, key := range limiterKeys(username, peer) {
+		attempts := limiter.attempts[key]
+		if attempts == nil {
+			attempts = &loginAttempts{}
+			limiter.attempts[key] = attempts
+		}
+
+		attempts.failures = append(limiter.recentFailures(attempts, now), now)
+		if len(attempts.failures) < limiter.config.MaxFailures {
+			continue
+		}
+
+		attempts.lockouts++
+		attempts.lockedUntil = now.Add(limiter.lockoutDuration(attempts.lockouts))
+		attempts.failures = nil
+	}
+}
+
+// RecordSuccess clears the failed login state of username after a successful login.
+func (limiter *LoginLimiter) RecordSuccess(username string) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	delete(limiter.attempts, usernameKeyPrefix+username)
+}
+
+// Lockouts returns the state of every tracked username and peer, ordered by key.
+func (limiter *LoginLimiter) Lockouts() []LoginLockout {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := limiter.now()
+	limiter.prune(now)
+
+	keys := make([]string, 0, len(limiter.attempts))
+	for key := range limiter.attempts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lockouts := make([]LoginLockout, 0, len(keys))
+	for _, key := range keys {
+		attempts := limiter.attempts[key]
+		lockout := LoginLockout{
+			RecentFailures: len(limiter.recentFailures(attempts, now)),
+			LockoutCount:   attempts.lockouts,
+		}
+		if now.Before(attempts.lockedUntil) {
+			lockout.LockedUntil = attempts.lockedUntil
+		}
+		if username, ok := strings.CutPrefix(key, usernameKeyPrefix); ok {
+			lockout.Username = username
+		} else {
+			lockout.Peer = strings.TrimPrefix(key, peerKeyPrefix)
+		}
+
+		lockouts = append(lockouts, lockout)
+	}
+
+	return lockouts
+}
+
+// lockoutDuration returns the exponential lockout duration for the n-th lockout.
+func (limiter *LoginLimiter) lockoutDuration(n int) time.Duration {
+	duration := limiter.config.BaseLockout
+	for i := 1; i < n && duration < limiter.config.MaxLockout; i++ {
+		duration *= 2
+	}
+
+	return min(duration, limiter.config.MaxLockout)
+}
+
+// recentFailures returns the failures of attempts that are still inside the sliding window.
+func (limiter *LoginLimiter) recentFailures(attempts *loginAttempts, now time.Time) []time.Time {
+	cutoff := now.Add(-limiter.config.Window)
+	i := sort.Search(len(attempts.failures), func(i int) bool {
+		return attempts.failures[i].After(cutoff)
+	})
+
+	return attempts.failures[i:]
+}
+
+// prune drops keys without recent failures once their lockout history has cooled down, at most once per window.
+func (limiter *LoginLimiter) prune(now time.Time) {
+	if now.Sub(limiter.lastPrune) < limiter.config.Window {
+		return
+	}
+	limiter.lastPrune = now
+
+	for key, attempts := range limiter.attempts {
+		if len(limiter.recentFailures(attempts, now)) > 0 {
+			continue
+		}
+		if now.Before(attempts.lockedUntil.Add(limiter.config.MaxLockout)) {
+			continue
+		}
+
+		delete(limiter.attempts, key)
+	}
+}
+
+func limiterKeys(username, peer string) []string {
+	keys := []string{usernameKeyPrefix + username}
+	if peer != "" {
+		keys = append(keys, peerKeyPrefix+peer)
+	}
+
+	return keys
+}
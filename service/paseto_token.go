@@ -7,29 +7,53 @@ import (
 	"time"
 
 	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
 )
 
-// Payload is the structure that holds the account information
+// Payload is the structure that holds the account information,
+// encoded with the registered PASETO claims plus custom role and tenant claims.
 type Payload struct {
-	Username  string    `json:"username"`
+	ID        string    `json:"jti"`
+	Issuer    string    `json:"iss,omitempty"`
+	Audience  string    `json:"aud,omitempty"`
+	Username  string    `json:"sub"`
 	Role      string    `json:"role"`
-	IssuedAt  time.Time `json:"issued_at"`
-	ExpiredAt time.Time `json:"expired_at"`
+	Tenant    string    `json:"tenant,omitempty"`
+	IssuedAt  time.Time `json:"iat"`
+	NotBefore time.Time `json:"nbf"`
+	ExpiredAt time.Time `json:"exp"`
+	// Methods are the method patterns an API key caller is restricted to, none for other callers.
+	Methods []string `json:"-"`
 }
 
 func NewPayload(username, role string, duration time.Duration) (*Payload, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
 	payload := &Payload{
+		ID:        id.String(),
 		Username:  username,
 		Role:      role,
-		IssuedAt:  time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		IssuedAt:  now,
+		NotBefore: now,
+		ExpiredAt: now.Add(duration),
 	}
 
 	return payload, nil
 }
 
-func (payload Payload) IsValid() error {
-	if time.Now().After(payload.ExpiredAt) {
+// IsValid checks the time claims of the payload against now, tolerating a clock drift of skew.
+func (payload Payload) IsValid(now time.Time, skew time.Duration) error {
+	if now.Add(skew).Before(payload.IssuedAt) {
+		return errors.New("token used before issued")
+	}
+	if now.Add(skew).Before(payload.NotBefore) {
+		return errors.New("token is not valid yet")
+	}
+	if now.Add(-skew).After(payload.ExpiredAt) {
 		return errors.New("token expired")
 	}
 	return nil
@@ -43,18 +67,59 @@ type TokenMaker interface {
 	VerifyToken(token string) (*Payload, error)
 }
 
+// TokenConfig holds the registered claims a TokenMaker sets on new tokens and enforces on verify.
+type TokenConfig struct {
+	Issuer    string        // iss claim, checked on verify when not empty
+	Audience  string        // aud claim, checked on verify when not empty
+	ClockSkew time.Duration // allowed drift between the token time claims and the local clock
+}
+
 type PasetoMaker struct {
 	PrivateKey paseto.V4AsymmetricSecretKey
 	PublicKey  paseto.V4AsymmetricPublicKey
 	Parser     paseto.Parser
+	Config     TokenConfig
 }
 
-func NewPasetoMaker(privateKey paseto.V4AsymmetricSecretKey, parser paseto.Parser) TokenMaker {
+// NewPasetoMaker creates a TokenMaker whose parser enforces the time claims and the configured issuer and audience.
+func NewPasetoMaker(privateKey paseto.V4AsymmetricSecretKey, config TokenConfig) TokenMaker {
 	publicKey := privateKey.Public()
+
+	parser := paseto.NewParserWithoutExpiryCheck()
+	parser.AddRule(validTimeClaims(config.ClockSkew))
+	if config.Issuer != "" {
+		parser.AddRule(paseto.IssuedBy(config.Issuer))
+	}
+	if config.Audience != "" {
+		parser.AddRule(paseto.ForAudience(config.Audience))
+	}
+
 	return &PasetoMaker{
 		PrivateKey: privateKey,
 		PublicKey:  publicKey,
 		Parser:     parser,
+		Config:     config,
+	}
+}
+
+// validTimeClaims is a parser rule requiring the iat, nbf and exp claims to be present and valid now.
+func validTimeClaims(skew time.Duration) paseto.Rule {
+	return func(token paseto.Token) error {
+		iat, err := token.GetIssuedAt()
+		if err != nil {
+			return err
+		}
+		nbf, err := token.GetNotBefore()
+		if err != nil {
+			return err
+		}
+		exp, err := token.GetExpiration()
+		if err != nil {
+			return err
+		}
+
+		payload := Payload{IssuedAt: iat, NotBefore: nbf, ExpiredAt: exp}
+		return payload.IsValid(time.Now(), skew)
 	}
 }
 
@@ -63,6 +128,10 @@ func (maker PasetoMaker) CreateToken(acc *Account, duration time.Duration) (stri
 	if err != nil {
 		return "", err
 	}
+	payload.Tenant = acc.Tenant
+	payload.Issuer = maker.Config.Issuer
+	payload.Audience = maker.Config.Audience
+
 	claims, err := json.Marshal(payload)
 	if err != nil {
 		return "", fmt.Errorf("cannot marshal payload: %s", err)
@@ -87,10 +156,6 @@ func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot unmarshal from claims json: %s", err)
 	}
-	err = payload.IsValid()
-	if err != nil {
-		return nil, err
-	}
 
 	return payload, nil
 }
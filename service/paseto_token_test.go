@@ -0,0 +1,117 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoMakerVerifyToken(t *testing.T) {
+	t.Parallel()
+
+	key := paseto.NewV4AsymmetricSecretKey()
+	config := service.TokenConfig{Issuer: "grpc-server", Audience: "laptop-store", ClockSkew: time.Minute}
+	maker := service.NewPasetoMaker(key, config)
+	acc := &service.Account{Username: "admin_valid", Role: "admin"}
+
+	signToken := func(t *testing.T, edit func(token *paseto.Token)) string {
+		t.Helper()
+		now := time.Now()
+		token := paseto.NewToken()
+		token.SetIssuer(config.Issuer)
+		token.SetAudience(config.Audience)
+		token.SetSubject(acc.Username)
+		token.SetString("role", acc.Role)
+		token.SetIssuedAt(now)
+		token.SetNotBefore(now)
+		token.SetExpiration(now.Add(time.Minute))
+		edit(&token)
+		return token.V4Sign(key, nil)
+	}
+
+	testCases := []struct {
+		name  string
+		token func(t *testing.T) string
+		valid bool
+	}{
+		{
+			name: "valid",
+			token: func(t *testing.T) string {
+				token, err := maker.CreateToken(acc, time.Minute)
+				require.NoError(t, err)
+				return token
+			},
+			valid: true,
+		},
+		{
+			name: "expired within clock skew",
+			token: func(t *testing.T) string {
+				return signToken(t, func(token *paseto.Token) {
+					token.SetIssuedAt(time.Now().Add(-2 * time.Minute))
+					token.SetNotBefore(time.Now().Add(-2 * time.Minute))
+					token.SetExpiration(time.Now().Add(-30 * time.Second))
+				})
+			},
+			valid: true,
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				token, err := maker.CreateToken(acc, -2*time.Minute)
+				require.NoError(t, err)
+				return token
+			},
+		},
+		{
+			name: "not valid yet",
+			token: func(t *testing.T) string {
+				return signToken(t, func(token *paseto.Token) {
+					token.SetNotBefore(time.Now().Add(5 * time.Minute))
+					token.SetExpiration(time.Now().Add(10 * time.Minute))
+				})
+			},
+		},
+		{
+			name: "missing expiration",
+			token: func(t *testing.T) string {
+				return signToken(t, func(token *paseto.Token) {
+					require.NoError(t, token.Set("exp", nil))
+				})
+			},
+		},
+		{
+			name: "wrong issuer",
+			token: func(t *testing.T) string {
+				return signToken(t, func(token *paseto.Token) { token.SetIssuer("someone-else") })
+			},
+		},
+		{
+			name: "wrong audience",
+			token: func(t *testing.T) string {
+				return signToken(t, func(token *paseto.Token) { token.SetAudience("someone-else") })
+			},
+		},
+	}
+
+	for _, currCase := range testCases {
+		t.Run(currCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			payload, err := maker.VerifyToken(currCase.token(t))
+			if !currCase.valid {
+				require.Error(t, err)
+				require.Nil(t, payload)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, acc.Username, payload.Username)
+			require.Equal(t, acc.Role, payload.Role)
+			require.Equal(t, config.Issuer, payload.Issuer)
+			require.Equal(t, config.Audience, payload.Audience)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrPasswordMismatch is returned when a password does not match its encoded hash.
+var ErrPasswordMismatch = errors.New("password does not match")
+
+// PasswordHasher defines the interface of a password hashing algorithm.
+// Encoded hashes record the algorithm and its parameters, so any hasher verifies hashes of every supported algorithm.
+type PasswordHasher interface {
+	// Hash returns the encoded hash of password.
+	Hash(password string) (string, error)
+
+	// Verify checks password against an encoded hash of any supported algorithm.
+	Verify(encoded, password string) error
+
+	// NeedsRehash reports whether encoded was produced by another algorithm or with other parameters than Hash uses.
+	NeedsRehash(encoded string) bool
+}
+
+// BcryptHasher hashes passwords with bcrypt, encoded in the usual "$2a$<cost>$..." form.
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher creates a bcrypt PasswordHasher with the given cost.
+func NewBcryptHasher(cost int) (PasswordHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost %d outside [%d, %d]", cost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &BcryptHasher{Cost: cost}, nil
+}
+
+func (hasher *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), hasher.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (hasher *BcryptHasher) Verify(encoded, password string) error {
+	return verifyPassword(encoded, password)
+}
+
+func (hasher *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != hasher.Cost
+}
+
+// Argon2idParams are the parameters of an argon2id hash.
+type Argon2idParams struct {
+	Time       uint32 // number of passes over the memory
+	Memory     uint32 // memory in KiB
+	Threads    uint8
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// DefaultArgon2idParams follows the OWASP recommendation of 19 MiB, 2 passes and 1 thread.
+var DefaultArgon2idParams = Argon2idParams{Time: 2, Memory: 19 * 1024, Threads: 1, SaltLength: 16, KeyLength: 32}
+
+// Argon2idHasher hashes passwords with argon2id, encoded in the PHC string format
+// "$argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>".
+type Argon2idHasher struct {
+	Params Argon2idParams
+}
+
+// NewArgon2idHasher creates an argon2id PasswordHasher with the given parameters.
+func NewArgon2idHasher(params Argon2idParams) (PasswordHasher, error) {
+	if params.Time == 0 || params.Threads == 0 || params.Memory < 8*uint32(params.Threads) {
+		return nil, fmt.Errorf("invalid argon2id parameters m=%d,t=%d,p=%d", params.Memory, params.Time, params.Threads)
+	}
+	if params.SaltLength < 8 || params.KeyLength < 16 {
+		return nil, fmt.Errorf("argon2id salt must be at least 8 bytes and key at least 16 bytes")
+	}
+	return &Argon2idHasher{Params: params}, nil
+}
+
+func (hasher *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, hasher.Params.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, hasher.Params.Time, hasher.Params.Memory, hasher.Params.Threads, hasher.Params.KeyLength)
+	return encodeArgon2id(hasher.Params, salt, key), nil
+}
+
+func (hasher *Argon2idHasher) Verify(encoded, password string) error {
+	return verifyPassword(encoded, password)
+}
+
+func (hasher *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+	return err != nil || params != hasher.Params
+}
+
+func encodeArgon2id(params Argon2idParams, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, params.Memory, params.Time, params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads)
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("malformed argon2id parameters %q", parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("malformed argon2id key: %w", err)
+	}
+
+	params.SaltLength, params.KeyLength = uint32(len(salt)), uint32(len(key))
+	return params, salt, key, nil
+}
+
+// verifyPassword checks password against an encoded hash, picking the algorithm from its prefix.
+func verifyPassword(encoded, password string) error {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return err
+		}
+
+		other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLength)
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return ErrPasswordMismatch
+		}
+		return nil
+
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrPasswordMismatch
+		}
+		return err
+
+	default:
+		return errors.New("unknown password hash format")
+	}
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	t.Parallel()
+
+	argon2Params := service.Argon2idParams{Time: 1, Memory: 64, Threads: 1, SaltLength: 16, KeyLength: 32}
+	testCases := []struct {
+		name    string
+		hasher  service.PasswordHasher
+		prefix  string
+		upgrade service.PasswordHasher // same algorithm with other parameters
+	}{
+		{
+			name:    "bcrypt",
+			hasher:  &service.BcryptHasher{Cost: bcrypt.MinCost},
+			prefix:  "$2a$04$",
+			upgrade: &service.BcryptHasher{Cost: bcrypt.MinCost + 1},
+		},
+		{
+			name:    "argon2id",
+			hasher:  &service.Argon2idHasher{Params: argon2Params},
+			prefix:  "$argon2id$v=19$m=64,t=1,p=1$",
+			upgrade: &service.Argon2idHasher{Params: service.Argon2idParams{Time: 2, Memory: 64, Threads: 1, SaltLength: 16, KeyLength: 32}},
+		},
+	}
+
+	for _, currCase := range testCases {
+		t.Run(currCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := currCase.hasher.Hash("Laptop#Store1")
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(encoded, currCase.prefix), encoded)
+
+			other, err := currCase.hasher.Hash("Laptop#Store1")
+			require.NoError(t, err)
+			require.NotEqual(t, encoded, other, "hashes must be salted")
+
+			require.NoError(t, currCase.hasher.Verify(encoded, "Laptop#Store1"))
+			require.ErrorIs(t, currCase.hasher.Verify(encoded, "Laptop#Store2"), service.ErrPasswordMismatch)
+
+			// any hasher verifies any algorithm, but asks to rehash what it did not produce itself
+			for _, hasher := range []service.PasswordHasher{currCase.upgrade, testCases[0].hasher, testCases[1].hasher} {
+				require.NoError(t, hasher.Verify(encoded, "Laptop#Store1"))
+			}
+			require.False(t, currCase.hasher.NeedsRehash(encoded))
+			require.True(t, currCase.upgrade.NeedsRehash(encoded))
+		})
+	}
+
+	hasher := &service.Argon2idHasher{Params: argon2Params}
+	require.Error(t, hasher.Verify("plaintext", "plaintext"))
+	require.Error(t, hasher.Verify("$argon2id$v=19$m=64,t=1$c2FsdA$a2V5", "password"))
+	require.True(t, hasher.NeedsRehash("$argon2id$v=19$m=64,t=1$c2FsdA$a2V5"))
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := service.DefaultPasswordPolicy
+	testCases := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{name: "valid", password: "Laptop#Store1", valid: true},
+		{name: "three classes", password: "laptop!store42", valid: true},
+		{name: "longest", password: "Laptop#Store1" + strings.Repeat("x", 17), valid: true},
+		{name: "too short", password: "Lap#top1"},
+		{name: "too long", password: "Laptop#Store1" + strings.Repeat("x", 18)},
+		{name: "symbol ending", password: "laptopstore42!"},
+		{name: "two classes", password: "laptopstore42"},
+		{name: "contains username", password: "Admin_Valid#2024"},
+		{name: "whitespace", password: "Laptop Store#1"},
+	}
+
+	for _, currCase := range testCases {
+		t.Run(currCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := policy.Validate("admin_valid", currCase.password)
+			if currCase.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+
+	relaxed := service.PasswordPolicy{MinLength: 6, MinCharClasses: 1}
+	require.Error(t, relaxed.Validate("user", "P@ssw0rd"), "common passwords are rejected")
+}
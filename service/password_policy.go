@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// commonPasswords are rejected whatever the policy, compared case-insensitively.
+var commonPasswords = []string{
+	"password", "password1", "password123", "passw0rd", "p@ssw0rd", "qwerty123", "qwertyuiop",
+	"123456789", "1234567890", "iloveyou", "letmein123", "welcome123", "admin123", "changeme",
+}
+
+// PasswordPolicy is the password policy enforced when an account is registered.
+type PasswordPolicy struct {
+	MinLength      int // in characters
+	MaxLength      int // in bytes, bcrypt ignores anything past 72
+	MinCharClasses int // out of lowercase, uppercase, digits and symbols
+}
+
+// DefaultPasswordPolicy requires 10 to 30 characters from at least 3 character classes, the most a
+// LoginRequest accepts.
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 10, MaxLength: 30, MinCharClasses: 3}
+
+// Validate checks password meets the policy for the account username.
+// Passwords containing the username or listed as common passwords are always rejected, and so are
+// passwords not ending with a letter, a digit or an underscore, which a LoginRequest refuses.
+func (policy PasswordPolicy) Validate(username, password string) error {
+	if utf8.RuneCountInString(password) < policy.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", policy.MinLength)
+	}
+	if policy.MaxLength > 0 && len(password) > policy.MaxLength {
+		return fmt.Errorf("password must be at most %d bytes long", policy.MaxLength)
+	}
+
+	if last, _ := utf8.DecodeLastRuneInString(password); !isWordCharacter(last) {
+		return errors.New("password must end with a letter, a digit or an underscore")
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsSpace(r) || unicode.IsControl(r):
+			return errors.New("password must not contain whitespace or control characters")
+		default:
+			symbol = true
+		}
+	}
+
+	classes := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < policy.MinCharClasses {
+		return fmt.Errorf("password must mix at least %d of lowercase letters, uppercase letters, digits and symbols", policy.MinCharClasses)
+	}
+
+	lowered := strings.ToLower(password)
+	if username != "" && strings.Contains(lowered, strings.ToLower(username)) {
+		return errors.New("password must not contain the username")
+	}
+	for _, common := range commonPasswords {
+		if lowered == common {
+			return errors.New("password is too common")
+		}
+	}
+
+	return nil
+}
+
+// isWordCharacter reports whether r is an ASCII letter, digit or underscore.
+func isWordCharacter(r rune) bool {
+	return r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
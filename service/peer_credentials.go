@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// PeerCredInfo is the AuthInfo of a Unix socket connection: the process credentials of the peer,
+// read with SO_PEERCRED when the connection was accepted.
+type PeerCredInfo struct {
+	credentials.CommonAuthInfo
+	UID uint32
+	GID uint32
+	PID int32
+}
+
+// AuthType implements credentials.AuthInfo.
+func (PeerCredInfo) AuthType() string {
+	return "peercred"
+}
+
+type peerCredentials struct{}
+
+// NewPeerCredentials returns plaintext server transport credentials for Unix socket listeners,
+// recording the UID of each peer so CertIdentities can trust it like a client certificate.
+func NewPeerCredentials() credentials.TransportCredentials {
+	return peerCredentials{}
+}
+
+func (peerCredentials) ClientHandshake(_ context.Context, _ string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return conn, PeerCredInfo{CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity}}, nil
+}
+
+func (peerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, nil, fmt.Errorf("peer credentials need a Unix socket connection, got %T", conn)
+	}
+
+	info, err := readPeerCred(unixConn)
+	if err != nil {
+		return nil, nil, err
+	}
+	// only local processes reach a Unix socket, like the credentials/local package assumes
+	info.SecurityLevel = credentials.PrivacyAndIntegrity
+	return conn, info, nil
+}
+
+func (peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+func (creds peerCredentials) Clone() credentials.TransportCredentials {
+	return creds
+}
+
+func (peerCredentials) OverrideServerName(string) error {
+	return nil
+}
+
+// peerCredInfo returns the process credentials of a caller connected through a Unix socket.
+func peerCredInfo(ctx context.Context) (PeerCredInfo, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return PeerCredInfo{}, false
+	}
+
+	info, ok := p.AuthInfo.(PeerCredInfo)
+	return info, ok
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"net"
+	"syscall"
+)
+
+// readPeerCred reads the credentials of the process connected to conn with SO_PEERCRED.
+func readPeerCred(conn *net.UnixConn) (PeerCredInfo, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return PeerCredInfo{}, err
+	}
+
+	var ucred *syscall.Ucred
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		ucred, sockErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil {
+		return PeerCredInfo{}, err
+	}
+	if sockErr != nil {
+		return PeerCredInfo{}, sockErr
+	}
+
+	return PeerCredInfo{UID: ucred.Uid, GID: ucred.Gid, PID: ucred.Pid}, nil
+}
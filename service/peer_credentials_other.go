@@ -0,0 +1,13 @@
+//go:build !linux
+
+package service
+
+import (
+	"errors"
+	"net"
+)
+
+// readPeerCred fails, SO_PEERCRED is Linux only.
+func readPeerCred(*net.UnixConn) (PeerCredInfo, error) {
+	return PeerCredInfo{}, errors.New("unix socket peer credentials are only supported on Linux")
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RetryAfterTrailer is the trailer of throttled calls telling the seconds to wait before retrying,
+// also sent as a google.rpc.RetryInfo status detail.
+const RetryAfterTrailer = "retry-after"
+
+// concurrencyRetryDelay is the retry delay of calls rejected by a concurrency limit, whose calls in
+// progress may complete any time.
+const concurrencyRetryDelay = time.Second
+
+// RateLimitKey tells whose calls share the token bucket of a rate limit rule.
+type RateLimitKey string
+
+const (
+	RateLimitPerPrincipal RateLimitKey = "principal" // the authenticated caller, the peer IP address for anonymous calls
+	RateLimitPerPeer      RateLimitKey = "peer"      // the peer IP address
+)
+
+// RateLimitRule throttles the calls of each method matching Method, a pattern as in the access
+// policy such as /LaptopService/SearchLaptop or /RouteGuide/*.
+type RateLimitRule struct {
+	Method        string
+	Per           RateLimitKey
+	Rate          float64 // calls per second of each caller, refilling a bucket of Burst calls; no rate limit when 0
+	Burst         int
+	MaxConcurrent int // calls of the method in progress at once over all callers, such as long streams; unlimited when 0
+}
+
+// rateLimitBucket is a token bucket of a rule, a method and a caller.
+type rateLimitBucket struct {
+	rule   int
+	method string
+	caller string
+}
+
+// tokenBucket holds the tokens left when it was last updated.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// concurrencyKey counts the calls in progress of a method limited by a rule.
+type concurrencyKey struct {
+	rule   int
+	method string
+}
+
+// RateLimitInterceptor throttles calls with token buckets per method and caller, and limits the calls
+// of a method in progress at once. Rejected calls fail with ResourceExhausted and tell when to retry.
+// It must run after the AuthInterceptor to limit calls per principal.
+type RateLimitInterceptor struct {
+	rules []RateLimitRule
+
+	mutex      sync.Mutex
+	buckets    map[rateLimitBucket]*tokenBucket
+	inProgress map[concurrencyKey]int
+	lastPrune  time.Time
+	now        func() time.Time
+
+	throttled metric.Int64Counter // nil until RegisterMetrics
+}
+
+// NewRateLimitInterceptor creates a new RateLimitInterceptor applying every rule matching a call.
+func NewRateLimitInterceptor(rules []RateLimitRule) (*RateLimitInterceptor, error) {
+	for i, rule := range rules {
+		_, err := path.Match(rule.Method, "")
+		if err != nil {
+			return nil, fmt.Errorf("rate limit %d: invalid method pattern %q: %w", i, rule.Method, err)
+		}
+		if rule.Per != RateLimitPerPrincipal && rule.Per != RateLimitPerPeer {
+			return nil, fmt.Errorf("rate limit %d: unknown key %q", i, rule.Per)
+		}
+		if rule.Rate < 0 || (rule.Rate > 0 && rule.Burst < 1) || rule.MaxConcurrent < 0 {
+			return nil, fmt.Errorf("rate limit %d: rate and max concurrent calls must not be negative, burst must be at least 1", i)
+		}
+	}
+
+	return &RateLimitInterceptor{
+		rules:      rules,
+		buckets:    make(map[rateLimitBucket]*tokenBucket),
+		inProgress: make(map[concurrencyKey]int),
+		now:        time.Now,
+	}, nil
+}
+
+// RegisterMetrics exports the throttled calls and the calls in progress of the methods with a
+// concurrency limit.
+func (interceptor *RateLimitInterceptor) RegisterMetrics(meter metric.Meter) error {
+	throttled, err := meter.Int64Counter("grpc.server.call.throttled",
+		metric.WithDescription("Calls rejected by a rate limit or a concurrency limit."),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.Int64ObservableGauge("grpc.server.call.in_progress",
+		metric.WithDescription("Calls in progress of the methods with a concurrency limit."),
+		metric.WithUnit("{call}"),
+		metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+			interceptor.mutex.Lock()
+			defer interceptor.mutex.Unlock()
+
+			for key, count := range interceptor.inProgress {
+				observer.Observe(int64(count), metric.WithAttributes(
+					attribute.String("grpc.method", key.method),
+					attribute.Int64("max_concurrent", int64(interceptor.rules[key.rule].MaxConcurrent)),
+				))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	interceptor.mutex.Lock()
+	interceptor.throttled = throttled
+	interceptor.mutex.Unlock()
+	return nil
+}
+
+// Unary returns a unary server interceptor that throttles calls.
+func (interceptor *RateLimitInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		release, retryAfter, err := interceptor.admit(ctx, info.FullMethod)
+		if err != nil {
+			grpc.SetTrailer(ctx, retryAfterTrailer(retryAfter))
+			return nil, err
+		}
+		defer release()
+
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns a stream server interceptor that throttles calls, counted in progress until the
+// stream ends.
+func (interceptor *RateLimitInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, retryAfter, err := interceptor.admit(ss.Context(), info.FullMethod)
+		if err != nil {
+			ss.SetTrailer(retryAfterTrailer(retryAfter))
+			return err
+		}
+		defer release()
+
+		return handler(srv, ss)
+	}
+}
+
+// admit takes a token of every bucket of the call and counts it in progress, or returns the error
+// rejecting it and the delay after which it may be retried. release must be called once when the
+// call completes.
+func (interceptor *RateLimitInterceptor) admit(ctx context.Context, method string) (release func(), retryAfter time.Duration, err error) {
+	interceptor.mutex.Lock()
+	defer interceptor.mutex.Unlock()
+
+	now := interceptor.now()
+	interceptor.prune(now)
+
+	var buckets []*tokenBucket
+	var counted []concurrencyKey
+	reason := ""
+	for i, rule := range interceptor.rules {
+		if !matchPattern(rule.Method, method) {
+			continue
+		}
+
+		if rule.MaxConcurrent > 0 {
+			key := concurrencyKey{rule: i, method: method}
+			if interceptor.inProgress[key] >= rule.MaxConcurrent {
+				reason = "concurrency"
+				retryAfter = max(retryAfter, concurrencyRetryDelay)
+			}
+			counted = append(counted, key)
+		}
+
+		if rule.Rate > 0 {
+			key := rateLimitBucket{rule: i, method: method, caller: rateLimitCaller(ctx, rule.Per)}
+			bucket := interceptor.buckets[key]
+			if bucket == nil {
+				bucket = &tokenBucket{tokens: float64(rule.Burst), updated: now}
+				interceptor.buckets[key] = bucket
+			}
+			bucket.refill(now, rule)
+			if bucket.tokens < 1 {
+				reason = "rate"
+				retryAfter = max(retryAfter, time.Duration((1-bucket.tokens)/rule.Rate*float64(time.Second)))
+			}
+			buckets = append(buckets, bucket)
+		}
+	}
+
+	if reason != "" {
+		if interceptor.throttled != nil {
+			interceptor.throttled.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("grpc.method", method),
+				attribute.String("reason", reason),
+			))
+		}
+		st := status.Newf(codes.ResourceExhausted, "%s limit of %s exceeded, retry in %s", reason, method, retryAfter.Round(time.Millisecond))
+		if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+			st = detailed
+		}
+		return nil, retryAfter, st.Err()
+	}
+
+	for _, bucket := range buckets {
+		bucket.tokens--
+	}
+	for _, key := range counted {
+		interceptor.inProgress[key]++
+	}
+	return func() {
+		interceptor.mutex.Lock()
+		defer interceptor.mutex.Unlock()
+
+		for _, key := range counted {
+			interceptor.inProgress[key]--
+			if interceptor.inProgress[key] == 0 {
+				delete(interceptor.inProgress, key)
+			}
+		}
+	}, 0, nil
+}
+
+// prune drops the buckets refilled up to their burst, which behave as new ones, once a minute.
+func (interceptor *RateLimitInterceptor) prune(now time.Time) {
+	if now.Sub(interceptor.lastPrune) < time.Minute {
+		return
+	}
+	interceptor.lastPrune = now
+
+	for key, bucket := range interceptor.buckets {
+		rule := interceptor.rules[key.rule]
+		bucket.refill(now, rule)
+		if bucket.tokens >= float64(rule.Burst) {
+			delete(interceptor.buckets, key)
+		}
+	}
+}
+
+// refill adds the tokens of the time elapsed since the bucket was updated.
+func (bucket *tokenBucket) refill(now time.Time, rule RateLimitRule) {
+	elapsed := now.Sub(bucket.updated).Seconds()
+	if elapsed > 0 {
+		bucket.tokens = math.Min(float64(rule.Burst), bucket.tokens+elapsed*rule.Rate)
+		bucket.updated = now
+	}
+}
+
+// rateLimitCaller returns the caller whose calls share a bucket.
+func rateLimitCaller(ctx context.Context, per RateLimitKey) string {
+	if per == RateLimitPerPrincipal {
+		if username, _ := callerIdentity(ctx); username != "" {
+			return usernameKeyPrefix + username
+		}
+	}
+	return peerKeyPrefix + peerAddress(ctx)
+}
+
+// retryAfterTrailer returns the trailer telling the whole seconds to wait before retrying.
+func retryAfterTrailer(retryAfter time.Duration) metadata.MD {
+	return metadata.Pairs(RetryAfterTrailer, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+}
@@ -0,0 +1,158 @@
+package service_test
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// startTestRateLimitServer serves the health service throttled by rules. Calls are authenticated
+// as the username of their user metadata.
+func startTestRateLimitServer(t *testing.T, rules []service.RateLimitRule) (healthpb.HealthClient, *sdkmetric.ManualReader) {
+	t.Helper()
+
+	interceptor, err := service.NewRateLimitInterceptor(rules)
+	require.NoError(t, err)
+	reader := sdkmetric.NewManualReader()
+	require.NoError(t, interceptor.RegisterMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")))
+
+	authenticate := func(ctx context.Context) context.Context {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if users := md.Get("user"); len(users) > 0 {
+			return service.ContextWithPayload(ctx, &service.Payload{Username: users[0]})
+		}
+		return ctx
+	}
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			return handler(authenticate(ctx), req)
+		}, interceptor.Unary()),
+		grpc.ChainStreamInterceptor(interceptor.Stream()),
+	)
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn), reader
+}
+
+func TestRateLimitInterceptorRate(t *testing.T) {
+	t.Parallel()
+
+	client, reader := startTestRateLimitServer(t, []service.RateLimitRule{
+		{Method: "/grpc.health.v1.Health/*", Per: service.RateLimitPerPrincipal, Rate: 0.01, Burst: 2},
+	})
+	check := func(user string) (metadata.MD, error) {
+		var trailer metadata.MD
+		ctx := metadata.AppendToOutgoingContext(t.Context(), "user", user)
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Trailer(&trailer))
+		return trailer, err
+	}
+
+	for range 2 {
+		_, err := check("alice")
+		require.NoError(t, err)
+	}
+	trailer, err := check("alice")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	retryAfter, err2 := strconv.Atoi(trailer.Get(service.RetryAfterTrailer)[0])
+	require.NoError(t, err2)
+	require.InDelta(t, 100, retryAfter, 1)
+
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	retryInfo, ok := details[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	require.InDelta(t, 100*time.Second, retryInfo.GetRetryDelay().AsDuration(), float64(time.Second))
+
+	// each principal has its own bucket
+	_, err = check("bob")
+	require.NoError(t, err)
+
+	var metrics metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &metrics))
+	var throttled int64
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "grpc.server.call.throttled" {
+				for _, point := range sum.DataPoints {
+					throttled += point.Value
+				}
+			}
+		}
+	}
+	require.EqualValues(t, 1, throttled)
+}
+
+func TestRateLimitInterceptorConcurrency(t *testing.T) {
+	t.Parallel()
+
+	client, _ := startTestRateLimitServer(t, []service.RateLimitRule{
+		{Method: "/grpc.health.v1.Health/Watch", Per: service.RateLimitPerPeer, MaxConcurrent: 1},
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	first, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = first.Recv()
+	require.NoError(t, err)
+
+	second, err := client.Watch(t.Context(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = second.Recv()
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, []string{"1"}, second.Trailer().Get(service.RetryAfterTrailer))
+
+	// unary calls of the other methods are not limited
+	_, err = client.Check(t.Context(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		stream, err := client.Watch(t.Context(), &healthpb.HealthCheckRequest{})
+		if err != nil {
+			return false
+		}
+		_, err = stream.Recv()
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNewRateLimitInterceptorInvalid(t *testing.T) {
+	t.Parallel()
+
+	for name, rule := range map[string]service.RateLimitRule{
+		"bad pattern":   {Method: "/LaptopService/[", Per: service.RateLimitPerPeer, Rate: 1, Burst: 1},
+		"unknown key":   {Method: "/LaptopService/*", Per: "tenant", Rate: 1, Burst: 1},
+		"no burst":      {Method: "/LaptopService/*", Per: service.RateLimitPerPeer, Rate: 1},
+		"negative rate": {Method: "/LaptopService/*", Per: service.RateLimitPerPeer, Rate: -1, Burst: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := service.NewRateLimitInterceptor([]service.RateLimitRule{rule})
+			require.Error(t, err)
+		})
+	}
+}
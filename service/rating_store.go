@@ -4,8 +4,8 @@ import "sync"
 
 // RatingStore defines the interface for storing laptop ratings.
 type RatingStore interface {
-	// AddRating adds a rating for a laptop and returns the updated rating.
-	AddRating(laptopID string, rating float64) (*Rating, error)
+	// AddRating adds a rating for a laptop of tenant and returns the updated rating.
+	AddRating(tenant, laptopID string, rating float64) (*Rating, error)
 }
 
 // Rating represents the rating of a laptop.
@@ -17,20 +17,21 @@ type Rating struct {
 // InMemoryRatingStore is an in-memory implementation of the RatingStore interface.
 type InMemoryRatingStore struct {
 	mutex   sync.RWMutex
-	ratings map[string]*Rating
+	ratings map[laptopKey]*Rating
 }
 
 func NewInMemoryRatingStore() RatingStore {
 	return &InMemoryRatingStore{
-		ratings: make(map[string]*Rating),
+		ratings: make(map[laptopKey]*Rating),
 	}
 }
 
-func (store *InMemoryRatingStore) AddRating(laptopID string, score float64) (*Rating, error) {
+func (store *InMemoryRatingStore) AddRating(tenant, laptopID string, score float64) (*Rating, error) {
 	store.mutex.Lock()
 	defer store.mutex.Unlock()
 
-	rating := store.ratings[laptopID]
+	key := laptopKey{tenant: tenant, id: laptopID}
+	rating := store.ratings[key]
 	if rating == nil {
 		rating = &Rating{
 			Count: 1,
@@ -41,7 +42,7 @@ func (store *InMemoryRatingStore) AddRating(laptopID string, score float64) (*Ra
 		rating.Sum += score
 	}
 
-	store.ratings[laptopID] = rating
+	store.ratings[key] = rating
 
 	return rating, nil
 }
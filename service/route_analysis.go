@@ -0,0 +1,145 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/go-http-server/grpc/protoc"
+)
+
+const (
+	// stopRadius is the distance in metres a route must stay within to stop.
+	stopRadius = 50
+	// stopMinDuration is the shortest stay reported as a stop.
+	stopMinDuration = 2 * time.Minute
+)
+
+// routeSpeeds returns the average and maximum speeds in metres per second between consecutive
+// points with timestamps, both 0 when no such pair exists.
+func routeSpeeds(points []*protoc.Point) (average, maximum float64) {
+	var distance, duration float64
+	for i := 1; i < len(points); i++ {
+		previous, point := points[i-1], points[i]
+		if previous.GetRecordedAt() == nil || point.GetRecordedAt() == nil {
+			continue
+		}
+
+		elapsed := point.GetRecordedAt().AsTime().Sub(previous.GetRecordedAt().AsTime()).Seconds()
+		if elapsed <= 0 {
+			continue
+		}
+
+		meters := float64(calcDistance(previous, point))
+		distance += meters
+		duration += elapsed
+		maximum = max(maximum, meters/elapsed)
+	}
+
+	if duration > 0 {
+		average = distance / duration
+	}
+	return average, maximum
+}
+
+// detectStops returns the places the route stayed within stopRadius of for at least stopMinDuration.
+// Points without timestamp are ignored.
+func detectStops(points []*protoc.Point) []*protoc.Stop {
+	var timed []*protoc.Point
+	for _, point := range points {
+		if point.GetRecordedAt() != nil {
+			timed = append(timed, point)
+		}
+	}
+
+	var stops []*protoc.Stop
+	for i := 0; i < len(timed); {
+		anchor, last := timed[i], i
+		for last+1 < len(timed) && calcDistance(anchor, timed[last+1]) <= stopRadius {
+			last++
+		}
+
+		startedAt, endedAt := anchor.GetRecordedAt(), timed[last].GetRecordedAt()
+		duration := endedAt.AsTime().Sub(startedAt.AsTime())
+		if duration >= stopMinDuration {
+			stops = append(stops, &protoc.Stop{
+				Location:  &protoc.Point{Latitude: anchor.GetLatitude(), Longitude: anchor.GetLongitude()},
+				StartedAt: startedAt,
+				EndedAt:   endedAt,
+				Duration:  int32(duration.Seconds()),
+			})
+			i = last + 1
+			continue
+		}
+
+		// a later anchor reaching the last point would stay even shorter
+		if last == len(timed)-1 {
+			break
+		}
+		i++
+	}
+
+	return stops
+}
+
+// simplifyRoute keeps the points of the route needed to stay within tolerance metres of it,
+// with the Douglas-Peucker algorithm. The first and last points are always kept.
+func simplifyRoute(points []*protoc.Point, tolerance float64) []*protoc.Point {
+	if len(points) < 3 {
+		return slices.Clone(points)
+	}
+
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+
+	// spans of points still to simplify, iterative to bound the stack on long routes
+	spans := [][2]int{{0, len(points) - 1}}
+	for len(spans) > 0 {
+		first, last := spans[len(spans)-1][0], spans[len(spans)-1][1]
+		spans = spans[:len(spans)-1]
+
+		farthest, distance := 0, 0.0
+		for i := first + 1; i < last; i++ {
+			if d := segmentDistance(points[i], points[first], points[last]); d > distance {
+				farthest, distance = i, d
+			}
+		}
+
+		if distance > tolerance {
+			keep[farthest] = true
+			spans = append(spans, [2]int{first, farthest}, [2]int{farthest, last})
+		}
+	}
+
+	var simplified []*protoc.Point
+	for i, point := range points {
+		if keep[i] {
+			simplified = append(simplified, point)
+		}
+	}
+	return simplified
+}
+
+// segmentDistance returns the distance in metres between point and the segment from a to b,
+// on an equirectangular projection centred on point, accurate for the short segments of a route.
+func segmentDistance(point, a, b *protoc.Point) float64 {
+	const CordFactor float64 = 1e7
+	metresPerUnit := toRadians(1/CordFactor) * earthRadius
+	cosLatitude := math.Cos(toRadians(float64(point.Latitude) / CordFactor))
+	project := func(p *protoc.Point) (float64, float64) {
+		x := (float64(p.Longitude) - float64(point.Longitude)) * metresPerUnit * cosLatitude
+		y := (float64(p.Latitude) - float64(point.Latitude)) * metresPerUnit
+		return x, y
+	}
+
+	ax, ay := project(a)
+	bx, by := project(b)
+	dx, dy := bx-ax, by-ay
+
+	// position on the segment of the projection of point, the origin
+	t := 0.0
+	if lengthSquared := dx*dx + dy*dy; lengthSquared > 0 {
+		t = min(max(-(ax*dx+ay*dy)/lengthSquared, 0), 1)
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
@@ -0,0 +1,425 @@
+package service
+
+import (
+	"bufio"
+	"container/list"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/go-http-server/grpc/protoc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	defaultChatBufferSize   = 64
+	defaultChatHistoryLimit = 100
+	defaultChatMaxLocations = 10000
+)
+
+// ChatBackpressure is what a ChatBroker does with a note for a subscription whose buffer is full.
+type ChatBackpressure int
+
+const (
+	// ChatDropOldest discards the oldest buffered note to make room for the new one.
+	ChatDropOldest ChatBackpressure = iota
+	// ChatDropNewest discards the new note.
+	ChatDropNewest
+	// ChatDisconnect closes the subscription, its stream ends with RESOURCE_EXHAUSTED.
+	ChatDisconnect
+)
+
+// ParseChatBackpressure returns the policy named "drop-oldest", "drop-newest" or "disconnect".
+func ParseChatBackpressure(name string) (ChatBackpressure, error) {
+	switch name {
+	case "drop-oldest":
+		return ChatDropOldest, nil
+	case "drop-newest":
+		return ChatDropNewest, nil
+	case "disconnect":
+		return ChatDisconnect, nil
+	default:
+		return 0, fmt.Errorf("unknown chat backpressure policy %q", name)
+	}
+}
+
+// ChatBrokerOptions configures a ChatBroker, zero values select the defaults.
+type ChatBrokerOptions struct {
+	BufferSize   int // notes buffered per subscription, 64 by default
+	Backpressure ChatBackpressure
+	HistoryLimit int    // notes kept per location, 100 by default
+	MaxLocations int    // locations with a history, the least recently noted are forgotten first, 10000 by default
+	HistoryFile  string // JSON lines file the history is persisted to, empty keeps it in memory
+}
+
+// ChatBroker relays RouteChat notes to every subscription interested in their location, and keeps
+// the latest notes of the most recently noted locations for new subscribers. Publishing never waits
+// for a subscriber: a full subscription buffer is handled by the backpressure policy.
+//
+// The history file is appended to, and rewritten with the notes kept once it holds twice as many.
+type ChatBroker struct {
+	options       ChatBrokerOptions
+	mutex         sync.Mutex
+	file          *os.File
+	lines         int                        // notes in the history file
+	kept          int                        // notes in the history
+	history       map[pointKey]*list.Element // of the recent list
+	recent        *list.List                 // *chatLocation, most recently noted first
+	subscriptions map[*ChatSubscription]struct{}
+}
+
+// chatLocation is the history of a location.
+type chatLocation struct {
+	key   pointKey
+	notes []*protoc.RouteNote
+}
+
+// ChatSubscription receives the notes of the locations and areas it subscribed to.
+type ChatSubscription struct {
+	broker  *ChatBroker
+	notes   chan *protoc.RouteNote
+	evicted chan struct{}
+
+	// guarded by the broker mutex
+	points  map[pointKey]bool
+	areas   []*protoc.Rectangle
+	dropped int
+	closed  bool
+}
+
+// OpenChatBroker creates a broker, loading the note history of options.HistoryFile when it exists.
+func OpenChatBroker(options ChatBrokerOptions) (*ChatBroker, error) {
+	if options.BufferSize <= 0 {
+		options.BufferSize = defaultChatBufferSize
+	}
+	if options.HistoryLimit <= 0 {
+		options.HistoryLimit = defaultChatHistoryLimit
+	}
+	if options.MaxLocations <= 0 {
+		options.MaxLocations = defaultChatMaxLocations
+	}
+
+	broker := &ChatBroker{
+		options:       options,
+		history:       make(map[pointKey]*list.Element),
+		recent:        list.New(),
+		subscriptions: make(map[*ChatSubscription]struct{}),
+	}
+	if options.HistoryFile == "" {
+		return broker, nil
+	}
+
+	err := broker.load()
+	if err != nil {
+		return nil, err
+	}
+	if broker.lines > broker.kept {
+		// drop the notes past the history limits so the file does not grow forever
+		err = broker.compact()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	broker.file, err = os.OpenFile(options.HistoryFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return broker, nil
+}
+
+// load reads the history file.
+func (broker *ChatBroker) load() error {
+	file, err := os.Open(broker.options.HistoryFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 8<<20)
+	for scanner.Scan() {
+		note := &protoc.RouteNote{}
+		err := protojson.Unmarshal(scanner.Bytes(), note)
+		if err != nil {
+			return fmt.Errorf("chat history %s: cannot parse line %d: %w", broker.options.HistoryFile, broker.lines+1, err)
+		}
+		broker.lines++
+		broker.remember(note)
+	}
+
+	return scanner.Err()
+}
+
+// compact atomically rewrites the history file with the notes kept in memory. The caller must hold the
+// mutex, and reopen the file when it was open.
+func (broker *ChatBroker) compact() error {
+	var notes []*protoc.RouteNote
+	for element := broker.recent.Front(); element != nil; element = element.Next() {
+		notes = append(notes, element.Value.(*chatLocation).notes...)
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].GetSentAt().AsTime().Before(notes[j].GetSentAt().AsTime())
+	})
+
+	filename := broker.options.HistoryFile
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot compact chat history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	for _, note := range notes {
+		data, err := protojson.Marshal(note)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		writer.Write(append(data, '\n'))
+	}
+	err = writer.Flush()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot compact chat history: %w", err)
+	}
+	broker.lines = len(notes)
+	return nil
+}
+
+// recompact compacts the open history file once it holds twice the notes kept, the caller must hold the mutex.
+func (broker *ChatBroker) recompact() error {
+	if broker.lines <= 2*broker.kept {
+		return nil
+	}
+
+	err := broker.file.Close()
+	if err != nil {
+		return err
+	}
+	err = broker.compact()
+	// appending to the previous file keeps the notes if it could not be replaced
+	file, openErr := os.OpenFile(broker.options.HistoryFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	broker.file = file
+	return err
+}
+
+// remember appends note to the history of its location, dropping the oldest note of the location past the
+// history limit, and the least recently noted location past the maximum. The caller must hold the mutex.
+func (broker *ChatBroker) remember(note *protoc.RouteNote) {
+	key := pointKey{note.GetLocation().GetLatitude(), note.GetLocation().GetLongitude()}
+	element, ok := broker.history[key]
+	if !ok {
+		element = broker.recent.PushFront(&chatLocation{key: key})
+		broker.history[key] = element
+		if broker.recent.Len() > broker.options.MaxLocations {
+			forgotten := broker.recent.Remove(broker.recent.Back()).(*chatLocation)
+			delete(broker.history, forgotten.key)
+			broker.kept -= len(forgotten.notes)
+		}
+	}
+	broker.recent.MoveToFront(element)
+
+	location := element.Value.(*chatLocation)
+	if len(location.notes) < broker.options.HistoryLimit {
+		location.notes = append(location.notes, note)
+		broker.kept++
+		return
+	}
+
+	copy(location.notes, location.notes[1:])
+	location.notes[len(location.notes)-1] = note
+}
+
+// Subscribe returns a subscription to no location yet, which must be closed when done.
+func (broker *ChatBroker) Subscribe() *ChatSubscription {
+	subscription := &ChatSubscription{
+		broker:  broker,
+		notes:   make(chan *protoc.RouteNote, broker.options.BufferSize),
+		evicted: make(chan struct{}),
+		points:  make(map[pointKey]bool),
+	}
+
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.subscriptions[subscription] = struct{}{}
+	return subscription
+}
+
+// Publish records note in the history of its location and relays it to the interested subscriptions.
+func (broker *ChatBroker) Publish(note *protoc.RouteNote) error {
+	if note.GetLocation() == nil {
+		return errors.New("route note has no location")
+	}
+
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	if broker.file != nil {
+		data, err := protojson.Marshal(note)
+		if err != nil {
+			return err
+		}
+
+		_, err = broker.file.Write(append(data, '\n'))
+		if err != nil {
+			return fmt.Errorf("cannot write chat history: %w", err)
+		}
+		broker.lines++
+	}
+
+	broker.remember(note)
+	if broker.file != nil {
+		err := broker.recompact()
+		if err != nil {
+			log.Printf("cannot compact chat history: %s", err)
+		}
+	}
+	for subscription := range broker.subscriptions {
+		if subscription.interested(note.GetLocation()) {
+			subscription.deliver(note, broker.options.Backpressure)
+		}
+	}
+	return nil
+}
+
+// Close closes the history file.
+func (broker *ChatBroker) Close() error {
+	if broker.file == nil {
+		return nil
+	}
+	return errors.Join(broker.file.Sync(), broker.file.Close())
+}
+
+// AddLocation subscribes to the notes sent from point, starting with its history.
+func (subscription *ChatSubscription) AddLocation(point *protoc.Point) {
+	broker := subscription.broker
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	key := pointKey{point.GetLatitude(), point.GetLongitude()}
+	if subscription.points[key] {
+		return
+	}
+
+	if element, ok := broker.history[key]; ok && !subscription.interested(point) {
+		for _, note := range element.Value.(*chatLocation).notes {
+			subscription.deliver(note, ChatDropOldest)
+		}
+	}
+	subscription.points[key] = true
+}
+
+// AddArea subscribes to the notes sent from inside rect, borders included, starting with their history.
+func (subscription *ChatSubscription) AddArea(rect *protoc.Rectangle) {
+	broker := subscription.broker
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	var notes []*protoc.RouteNote
+	for element := broker.recent.Front(); element != nil; element = element.Next() {
+		locationNotes := element.Value.(*chatLocation).notes
+		location := locationNotes[0].GetLocation()
+		if inRange(location, rect) && !subscription.interested(location) {
+			notes = append(notes, locationNotes...)
+		}
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].GetSentAt().AsTime().Before(notes[j].GetSentAt().AsTime())
+	})
+
+	for _, note := range notes {
+		subscription.deliver(note, ChatDropOldest)
+	}
+	subscription.areas = append(subscription.areas, rect)
+}
+
+// Notes returns the channel the subscribed notes are delivered on.
+func (subscription *ChatSubscription) Notes() <-chan *protoc.RouteNote {
+	return subscription.notes
+}
+
+// Evicted returns a channel closed when the ChatDisconnect policy ended the subscription.
+func (subscription *ChatSubscription) Evicted() <-chan struct{} {
+	return subscription.evicted
+}
+
+// Dropped returns how many notes the backpressure policy discarded.
+func (subscription *ChatSubscription) Dropped() int {
+	subscription.broker.mutex.Lock()
+	defer subscription.broker.mutex.Unlock()
+
+	return subscription.dropped
+}
+
+// Close stops the delivery of notes.
+func (subscription *ChatSubscription) Close() {
+	subscription.broker.mutex.Lock()
+	defer subscription.broker.mutex.Unlock()
+
+	subscription.closed = true
+	delete(subscription.broker.subscriptions, subscription)
+}
+
+// interested reports whether point is one of the locations or inside one of the areas, the caller must hold the mutex.
+func (subscription *ChatSubscription) interested(point *protoc.Point) bool {
+	if subscription.points[pointKey{point.GetLatitude(), point.GetLongitude()}] {
+		return true
+	}
+	for _, rect := range subscription.areas {
+		if inRange(point, rect) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver buffers note without blocking, applying backpressure when the buffer is full.
+// The caller must hold the mutex.
+func (subscription *ChatSubscription) deliver(note *protoc.RouteNote, backpressure ChatBackpressure) {
+	if subscription.closed {
+		return
+	}
+
+	select {
+	case subscription.notes <- note:
+		return
+	default:
+	}
+
+	switch backpressure {
+	case ChatDropNewest:
+		subscription.dropped++
+	case ChatDisconnect:
+		subscription.closed = true
+		delete(subscription.broker.subscriptions, subscription)
+		close(subscription.evicted)
+	default:
+		// the receiver may take a note meanwhile, then nothing needs to be dropped
+		select {
+		case <-subscription.notes:
+			subscription.dropped++
+		default:
+		}
+		subscription.notes <- note
+	}
+}
@@ -2,111 +2,239 @@ package service
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
+	"log"
 	"math"
-	"os"
-	"sync"
+	"strconv"
 	"time"
 
+	"github.com/go-http-server/grpc/geojson"
 	"github.com/go-http-server/grpc/protoc"
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
-	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // RouteGuideServer implements the RouteGuide service.
 type RouteGuideServer struct {
 	protoc.UnimplementedRouteGuideServer
-	savedFeatures []*protoc.Feature // readonly after initialize
+	featureStore FeatureStore
+	routeStore   RouteStore
+	chatBroker   *ChatBroker
+	regionHub    *regionHub
+	maxPoints    int // points RecordRoute accepts per route
+}
 
-	mutex      sync.Mutex // mutex to protect routeNotes map
-	routeNotes map[string][]*protoc.RouteNote
+// NewRouteGuideServer creates a new instance of RouteGuideServer serving the features of featureStore,
+// keeping recorded routes of up to maxPoints points in routeStore and relaying RouteChat notes through chatBroker.
+func NewRouteGuideServer(featureStore FeatureStore, routeStore RouteStore, chatBroker *ChatBroker, maxPoints int) *RouteGuideServer {
+	return &RouteGuideServer{featureStore: featureStore, routeStore: routeStore, chatBroker: chatBroker, regionHub: newRegionHub(), maxPoints: maxPoints}
 }
 
-// loadFeatures loads features from a JSON file into the server's savedFeatures slice.
-func (s *RouteGuideServer) loadFeatures(filename string) error {
-	data, err := os.ReadFile(filename)
+// GetFeature retrieves the feature at the given point and implements the GetFeature method of the RouteGuideServer interface.
+func (s *RouteGuideServer) GetFeature(ctx context.Context, point *protoc.Point) (*protoc.Feature, error) {
+	err := contextError(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = json.Unmarshal(data, &s.savedFeatures)
-	if err != nil {
-		return err
+	if feature := s.featureStore.Find(point); feature != nil {
+		return feature, nil
 	}
 
-	return nil
+	// return point feature if it exists with unnamed
+	return &protoc.Feature{Location: point}, nil
 }
 
-// NewRouteGuideServer creates a new instance of RouteGuideServer and loads features from a JSON file.
-func NewRouteGuideServer() (*RouteGuideServer, error) {
-	s := &RouteGuideServer{routeNotes: make(map[string][]*protoc.RouteNote)}
+// inRange checks if a point is within the given rectangle.
+func inRange(point *protoc.Point, rect *protoc.Rectangle) bool {
+	left := math.Min(float64(rect.GetLo().GetLongitude()), float64(rect.GetHi().GetLongitude()))
+	right := math.Max(float64(rect.GetLo().GetLongitude()), float64(rect.GetHi().GetLongitude()))
+	top := math.Max(float64(rect.GetLo().GetLatitude()), float64(rect.GetHi().GetLatitude()))
+	bottom := math.Min(float64(rect.GetLo().GetLatitude()), float64(rect.GetHi().GetLatitude()))
 
-	err := s.loadFeatures("./sample/route_guide.json")
-	if err != nil {
-		return nil, err
+	if float64(point.GetLongitude()) >= left &&
+		float64(point.GetLongitude()) <= right &&
+		float64(point.GetLatitude()) >= bottom &&
+		float64(point.GetLatitude()) <= top {
+		return true
 	}
-	return s, nil
+	return false
 }
 
-// GetFeature retrieves the feature at the given point and implements the GetFeature method of the RouteGuideServer interface.
-func (s *RouteGuideServer) GetFeature(ctx context.Context, point *protoc.Point) (*protoc.Feature, error) {
-	for _, feat := range s.savedFeatures {
-		err := contextError(ctx)
+func (s *RouteGuideServer) ListFeatures(req *protoc.Rectangle, streaming grpc.ServerStreamingServer[protoc.Feature]) error {
+	for _, feature := range s.featureStore.Within(req) {
+		err := contextError(streaming.Context())
 		if err != nil {
-			return nil, err
+			return err
 		}
-		// compare proto messages for equality
-		if proto.Equal(feat.Location, point) {
-			return feat, nil
+
+		if err := streaming.Send(feature); err != nil {
+			return status.Errorf(codes.Internal, "failed to send feature: %v", err)
 		}
 	}
 
-	// return point feature if it exists with unnamed
-	return &protoc.Feature{Location: point}, nil
+	return nil
 }
 
-// inRange checks if a point is within the given rectangle.
-func inRange(point *protoc.Point, rect *protoc.Rectangle) bool {
-	left := math.Min(float64(rect.Lo.Longitude), float64(rect.Hi.Longitude))
-	right := math.Max(float64(rect.Lo.Longitude), float64(rect.Hi.Longitude))
-	top := math.Max(float64(rect.Lo.Latitude), float64(rect.Hi.Latitude))
-	bottom := math.Min(float64(rect.Lo.Latitude), float64(rect.Hi.Latitude))
-
-	if float64(point.Longitude) >= left &&
-		float64(point.Longitude) <= right &&
-		float64(point.Latitude) >= bottom &&
-		float64(point.Latitude) <= top {
-		return true
+// FindNearest returns the k features closest to the requested location, closest first.
+func (s *RouteGuideServer) FindNearest(ctx context.Context, req *protoc.FindNearestRequest) (*protoc.FindNearestResponse, error) {
+	err := contextError(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return false
+
+	res := &protoc.FindNearestResponse{}
+	for _, found := range s.featureStore.Nearest(req.GetLocation(), int(req.GetK())) {
+		res.Features = append(res.Features, &protoc.FeatureDistance{Feature: found.Feature, Distance: found.Distance})
+	}
+
+	return res, nil
 }
 
-func (s *RouteGuideServer) ListFeatures(req *protoc.Rectangle, streaming grpc.ServerStreamingServer[protoc.Feature]) error {
-	for _, feature := range s.savedFeatures {
+// ListFeaturesWithinRadius streams the features within the requested radius of the location, closest first.
+func (s *RouteGuideServer) ListFeaturesWithinRadius(req *protoc.RadiusRequest, streaming grpc.ServerStreamingServer[protoc.FeatureDistance]) error {
+	for _, found := range s.featureStore.WithinRadius(req.GetLocation(), req.GetRadius()) {
 		err := contextError(streaming.Context())
 		if err != nil {
 			return err
 		}
 
-		if inRange(feature.Location, req) {
-			if err := streaming.Send(feature); err != nil {
-				return status.Errorf(codes.Internal, "failed to send feature: %v", err)
-			}
+		if err := streaming.Send(&protoc.FeatureDistance{Feature: found.Feature, Distance: found.Distance}); err != nil {
+			return status.Errorf(codes.Internal, "failed to send feature: %v", err)
 		}
 	}
 
 	return nil
 }
 
+// CreateFeature adds a feature to the catalogue, at most one feature may exist per location.
+func (s *RouteGuideServer) CreateFeature(ctx context.Context, feature *protoc.Feature) (*protoc.Feature, error) {
+	err := s.featureStore.Create(feature)
+	if err != nil {
+		return nil, featureStoreError(err)
+	}
+	log.Printf("feature %q created at (%d, %d)", feature.GetName(), feature.GetLocation().GetLatitude(), feature.GetLocation().GetLongitude())
+
+	return feature, nil
+}
+
+// UpdateFeature replaces the feature at the requested location, possibly moving it.
+func (s *RouteGuideServer) UpdateFeature(ctx context.Context, req *protoc.UpdateFeatureRequest) (*protoc.Feature, error) {
+	err := s.featureStore.Update(req.GetLocation(), req.GetFeature())
+	if err != nil {
+		return nil, featureStoreError(err)
+	}
+	log.Printf("feature at (%d, %d) updated", req.GetLocation().GetLatitude(), req.GetLocation().GetLongitude())
+
+	return req.GetFeature(), nil
+}
+
+// DeleteFeature removes the feature at the given point and returns it.
+func (s *RouteGuideServer) DeleteFeature(ctx context.Context, point *protoc.Point) (*protoc.Feature, error) {
+	feature, err := s.featureStore.Delete(point)
+	if err != nil {
+		return nil, featureStoreError(err)
+	}
+	log.Printf("feature at (%d, %d) deleted", point.GetLatitude(), point.GetLongitude())
+
+	return feature, nil
+}
+
+// ExportFeatures returns the features of the requested area, or every feature, as a GeoJSON FeatureCollection.
+func (s *RouteGuideServer) ExportFeatures(ctx context.Context, req *protoc.ExportFeaturesRequest) (*protoc.GeoJson, error) {
+	area := req.GetArea()
+	if area == nil {
+		area = &protoc.Rectangle{
+			Lo: &protoc.Point{Latitude: -900000000, Longitude: -1800000000},
+			Hi: &protoc.Point{Latitude: 900000000, Longitude: 1800000000},
+		}
+	}
+
+	data, err := geojson.MarshalFeatures(s.featureStore.Within(area))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot encode features: %s", err)
+	}
+
+	return &protoc.GeoJson{Data: string(data)}, nil
+}
+
+// ImportFeatures creates or replaces the features of a GeoJSON FeatureCollection.
+func (s *RouteGuideServer) ImportFeatures(ctx context.Context, req *protoc.GeoJson) (*protoc.ImportFeaturesResponse, error) {
+	features, err := geojson.UnmarshalFeatures([]byte(req.GetData()))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid GeoJSON: %s", err)
+	}
+
+	created, updated, err := s.featureStore.Import(features)
+	if err != nil {
+		return nil, featureStoreError(err)
+	}
+	log.Printf("features imported: %d created, %d updated", created, updated)
+
+	return &protoc.ImportFeaturesResponse{Created: int32(created), Updated: int32(updated)}, nil
+}
+
+// featureStoreError converts a FeatureStore error to a gRPC status.
+func featureStoreError(err error) error {
+	switch {
+	case errors.Is(err, ErrFeatureNotFound):
+		return status.Errorf(codes.NotFound, "%s", err)
+	case errors.Is(err, ErrFeatureExists):
+		return status.Errorf(codes.AlreadyExists, "%s", err)
+	case errors.Is(err, ErrInvalidFeature):
+		return status.Errorf(codes.InvalidArgument, "%s", err)
+	default:
+		return status.Errorf(codes.Internal, "cannot save feature: %s", err)
+	}
+}
+
+// SimplifyToleranceHeader is the RecordRoute request header setting the tolerance in metres
+// of the simplified route returned in the summary.
+const SimplifyToleranceHeader = "x-simplify-tolerance"
+
+// simplifyTolerance returns the tolerance requested with SimplifyToleranceHeader, 0 when the header is absent.
+func simplifyTolerance(ctx context.Context) (float64, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(SimplifyToleranceHeader)
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	tolerance, err := strconv.ParseFloat(values[0], 64)
+	if err != nil || math.IsNaN(tolerance) || math.IsInf(tolerance, 0) || tolerance <= 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "%s must be a positive number of metres, got %q", SimplifyToleranceHeader, values[0])
+	}
+	return tolerance, nil
+}
+
+// RecordRoute summarises the streamed route and stores it for the caller. Every point is tracked
+// against the regions watched by WatchRegion.
 func (s *RouteGuideServer) RecordRoute(streaming grpc.ClientStreamingServer[protoc.Point, protoc.RouteSummary]) error {
+	tolerance, err := simplifyTolerance(streaming.Context())
+	if err != nil {
+		return err
+	}
+
 	var pointCount, featureCount, distance int32
 	var lastPoint *protoc.Point
+	var points []*protoc.Point
+	var features []*protoc.Feature
+	var firstRecordedAt, lastRecordedAt *timestamppb.Timestamp
 	startTime := time.Now()
 
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot generate route id: %s", err)
+	}
+	routeID := id.String()
+	owner, tenant := callerIdentity(streaming.Context())
+	defer s.regionHub.forget(routeID)
+
 	for {
 		err := contextError(streaming.Context())
 		if err != nil {
@@ -117,23 +245,65 @@ func (s *RouteGuideServer) RecordRoute(streaming grpc.ClientStreamingServer[prot
 		if err == io.EOF {
 			// streaming client request is end of life -> return response to client
 			endTime := time.Now()
-			return streaming.SendAndClose(&protoc.RouteSummary{
+			if firstRecordedAt != nil {
+				// trust the client clock over the stream duration
+				startTime, endTime = firstRecordedAt.AsTime(), lastRecordedAt.AsTime()
+			}
+
+			summary := &protoc.RouteSummary{
 				PointCount:   pointCount,
 				FeatureCount: featureCount,
 				Distance:     distance,
 				ElapsedTime:  int32(endTime.Sub(startTime).Seconds()),
-			})
+				Stops:        detectStops(points),
+			}
+			summary.AverageSpeed, summary.MaxSpeed = routeSpeeds(points)
+			if tolerance > 0 {
+				summary.Simplified = simplifyRoute(points, tolerance)
+			}
+			if pointCount > 0 {
+				summary.RouteId = routeID
+				err = s.saveRoute(streaming.Context(), routeID, points, features, summary, startTime, endTime)
+				if err != nil {
+					return err
+				}
+			}
+
+			return streaming.SendAndClose(summary)
 		}
 
 		if err != nil {
 			return status.Errorf(codes.Internal, "cannot receive streaming request from client: %s", err)
 		}
 
-		pointCount++
-		for _, feature := range s.savedFeatures {
-			if proto.Equal(feature.Location, point) {
-				featureCount++
+		if int(pointCount) >= s.maxPoints {
+			return status.Errorf(codes.ResourceExhausted, "route exceeds the limit of %d points", s.maxPoints)
+		}
+		err = validateLocation(point)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid point %d: %s", pointCount, err)
+		}
+		if recordedAt := point.GetRecordedAt(); recordedAt != nil {
+			err := recordedAt.CheckValid()
+			if err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid timestamp of point %d: %s", pointCount, err)
 			}
+			if lastRecordedAt != nil && recordedAt.AsTime().Before(lastRecordedAt.AsTime()) {
+				return status.Errorf(codes.InvalidArgument, "timestamp of point %d goes back in time", pointCount)
+			}
+
+			if firstRecordedAt == nil {
+				firstRecordedAt = recordedAt
+			}
+			lastRecordedAt = recordedAt
+		}
+
+		pointCount++
+		points = append(points, point)
+		s.regionHub.track(routeID, owner, tenant, point)
+		if feature := s.featureStore.Find(point); feature != nil {
+			featureCount++
+			features = append(features, feature)
 		}
 
 		if lastPoint != nil {
@@ -143,13 +313,165 @@ func (s *RouteGuideServer) RecordRoute(streaming grpc.ClientStreamingServer[prot
 	}
 }
 
+// saveRoute stores a recorded route for the caller.
+func (s *RouteGuideServer) saveRoute(ctx context.Context, id string, points []*protoc.Point, features []*protoc.Feature, summary *protoc.RouteSummary, startedAt, endedAt time.Time) error {
+	owner, tenant := callerIdentity(ctx)
+	route := &protoc.Route{
+		Id:        id,
+		Owner:     owner,
+		Tenant:    tenant,
+		Points:    points,
+		Summary:   summary,
+		Features:  features,
+		StartedAt: timestamppb.New(startedAt),
+		EndedAt:   timestamppb.New(endedAt),
+	}
+
+	err := s.routeStore.Save(route)
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot save route: %s", err)
+	}
+
+	return nil
+}
+
+// ListRoutes returns the routes recorded by the caller that started in the requested period, without their points.
+func (s *RouteGuideServer) ListRoutes(ctx context.Context, req *protoc.ListRoutesRequest) (*protoc.ListRoutesResponse, error) {
+	filter := RouteFilter{}
+	filter.Owner, filter.Tenant = callerIdentity(ctx)
+	if req.GetFrom() != nil {
+		filter.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		filter.To = req.GetTo().AsTime()
+	}
+
+	routes, err := s.routeStore.List(filter)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot list routes: %s", err)
+	}
+
+	for _, route := range routes {
+		route.Points = nil
+	}
+
+	return &protoc.ListRoutesResponse{Routes: routes}, nil
+}
+
+// GetRoute returns a route recorded by the caller. Routes of other callers are reported as not found.
+func (s *RouteGuideServer) GetRoute(ctx context.Context, req *protoc.GetRouteRequest) (*protoc.Route, error) {
+	return s.findCallerRoute(ctx, req.GetId())
+}
+
+// ExportRoute returns a route recorded by the caller as a GeoJSON LineString Feature.
+func (s *RouteGuideServer) ExportRoute(ctx context.Context, req *protoc.GetRouteRequest) (*protoc.GeoJson, error) {
+	route, err := s.findCallerRoute(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := geojson.MarshalStoredRoute(route)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot export route %s: %s", route.GetId(), err)
+	}
+
+	return &protoc.GeoJson{Data: string(data)}, nil
+}
+
+// findCallerRoute returns the route id recorded by the caller, routes of other callers are reported as not found.
+func (s *RouteGuideServer) findCallerRoute(ctx context.Context, id string) (*protoc.Route, error) {
+	route, err := s.routeStore.Find(id)
+	if err != nil {
+		if errors.Is(err, ErrRouteNotFound) {
+			return nil, status.Errorf(codes.NotFound, "route with id %s not found", id)
+		}
+		return nil, status.Errorf(codes.Internal, "cannot find route: %s", err)
+	}
+
+	owner, tenant := callerIdentity(ctx)
+	if route.GetOwner() != owner || route.GetTenant() != tenant {
+		return nil, status.Errorf(codes.NotFound, "route with id %s not found", id)
+	}
+
+	return route, nil
+}
+
+// WatchRegion streams the RecordRoute points of the caller's tenant that enter or leave the requested region,
+// until the client cancels. The response headers are sent once the region is watched.
+func (s *RouteGuideServer) WatchRegion(req *protoc.WatchRegionRequest, streaming grpc.ServerStreamingServer[protoc.RegionEvent]) error {
+	var watched region
+	switch {
+	case req.GetRectangle() != nil:
+		watched = rectangleRegion{rect: req.GetRectangle()}
+	case req.GetPolygon() != nil:
+		polygon, err := newPolygonRegion(req.GetPolygon())
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid polygon: %s", err)
+		}
+		watched = polygon
+	default:
+		return status.Errorf(codes.InvalidArgument, "a rectangle or a polygon is required")
+	}
+
+	_, tenant := callerIdentity(streaming.Context())
+	watch := s.regionHub.watch(watched, tenant)
+	defer s.regionHub.unwatch(watch)
+
+	err := streaming.SendHeader(metadata.MD{})
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot send headers: %s", err)
+	}
+
+	for {
+		select {
+		case event := <-watch.events:
+			err := streaming.Send(event)
+			if err != nil {
+				return status.Errorf(codes.Internal, "cannot send region event: %s", err)
+			}
+		case <-watch.lagged:
+			return status.Errorf(codes.ResourceExhausted, "region events are not received fast enough")
+		case <-streaming.Context().Done():
+			return contextError(streaming.Context())
+		}
+	}
+}
+
+// RouteChat relays the notes of every client to the subscribers of their location. The notes already
+// relayed to the caller are flushed once it closes its side of the stream.
 func (s *RouteGuideServer) RouteChat(streaming grpc.BidiStreamingServer[protoc.RouteNote, protoc.RouteNote]) error {
+	subscription := s.chatBroker.Subscribe()
+	defer subscription.Close()
+
+	received := make(chan error, 1)
+	go func() {
+		received <- s.receiveNotes(streaming, subscription)
+	}()
+
 	for {
-		err := contextError(streaming.Context())
-		if err != nil {
-			return err
+		select {
+		case note := <-subscription.Notes():
+			err := streaming.Send(note)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to send route note: %v", err)
+			}
+		case err := <-received:
+			if err != nil {
+				return err
+			}
+			return flushNotes(streaming, subscription)
+		case <-subscription.Evicted():
+			return status.Errorf(codes.ResourceExhausted, "route chat disconnected: notes are not received fast enough")
+		case <-streaming.Context().Done():
+			return contextError(streaming.Context())
 		}
+	}
+}
 
+// receiveNotes subscribes and publishes the notes of the client until it closes its side of the stream.
+func (s *RouteGuideServer) receiveNotes(streaming grpc.BidiStreamingServer[protoc.RouteNote, protoc.RouteNote], subscription *ChatSubscription) error {
+	sender, _ := callerIdentity(streaming.Context())
+	for {
 		req, err := streaming.Recv()
 		if err == io.EOF {
 			return nil
@@ -158,27 +480,48 @@ func (s *RouteGuideServer) RouteChat(streaming grpc.BidiStreamingServer[protoc.R
 			return status.Errorf(codes.Internal, "cannot receive streaming request from client: %s", err)
 		}
 
-		key := serialize(req.Location)
-		s.mutex.Lock()
-		s.routeNotes[key] = append(s.routeNotes[key], req)
-		// Note: this copy prevents blocking other clients while serving this one.
-		// We don't need to do a deep copy, because elements in the slice are
-		// insert-only and never modified.
-		rn := make([]*protoc.RouteNote, len(s.routeNotes[key]))
-		copy(rn, s.routeNotes[key])
-		s.mutex.Unlock()
+		if req.GetArea() != nil {
+			subscription.AddArea(req.GetArea())
+			continue
+		}
+		if req.GetLocation() == nil {
+			return status.Errorf(codes.InvalidArgument, "route note needs a location or an area")
+		}
 
-		for _, note := range rn {
-			if err := streaming.Send(note); err != nil {
+		subscription.AddLocation(req.GetLocation())
+		if req.GetMessage() == "" {
+			continue
+		}
+
+		err = s.chatBroker.Publish(&protoc.RouteNote{
+			Location: &protoc.Point{Latitude: req.GetLocation().GetLatitude(), Longitude: req.GetLocation().GetLongitude()},
+			Message:  req.GetMessage(),
+			SentAt:   timestamppb.Now(),
+			Sender:   sender,
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "cannot publish route note: %s", err)
+		}
+	}
+}
+
+// flushNotes sends the notes buffered for subscription without waiting for new ones.
+func flushNotes(streaming grpc.BidiStreamingServer[protoc.RouteNote, protoc.RouteNote], subscription *ChatSubscription) error {
+	for {
+		select {
+		case note := <-subscription.Notes():
+			err := streaming.Send(note)
+			if err != nil {
 				return status.Errorf(codes.Internal, "failed to send route note: %v", err)
 			}
+		default:
+			return nil
 		}
 	}
 }
 
-func serialize(point *protoc.Point) string {
-	return fmt.Sprintf("%d %d", point.Latitude, point.Longitude)
-}
+// earthRadius is the mean earth radius in metres.
+const earthRadius = float64(6371000)
 
 func toRadians(num float64) float64 {
 	return num * math.Pi / float64(180)
@@ -188,7 +531,7 @@ func toRadians(num float64) float64 {
 // The formula is based on http://mathforum.org/library/drmath/view/51879.html.
 func calcDistance(p1 *protoc.Point, p2 *protoc.Point) int32 {
 	const CordFactor float64 = 1e7
-	const R = float64(6371000) // earth radius in metres
+	const R = earthRadius
 	lat1 := toRadians(float64(p1.Latitude) / CordFactor)
 	lat2 := toRadians(float64(p2.Latitude) / CordFactor)
 	lng1 := toRadians(float64(p1.Longitude) / CordFactor)
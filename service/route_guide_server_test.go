@@ -0,0 +1,791 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-http-server/grpc/geojson"
+	"github.com/go-http-server/grpc/protoc"
+	"github.com/go-http-server/grpc/service"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testRouteMaxPoints is the number of points the test servers accept per route.
+const testRouteMaxPoints = 100
+
+// startTestRouteGuideServer serves a copy of the sample features, and returns them along with the copy and its store.
+func startTestRouteGuideServer(t *testing.T) (protoc.RouteGuideClient, []*protoc.Feature, *service.FileFeatureStore, string) {
+	t.Helper()
+
+	data, err := os.ReadFile("../sample/route_guide.json")
+	require.NoError(t, err)
+	var features []*protoc.Feature
+	require.NoError(t, json.Unmarshal(data, &features))
+
+	filename := filepath.Join(t.TempDir(), "features.json")
+	require.NoError(t, os.WriteFile(filename, data, 0o600))
+	featureStore, err := service.OpenFileFeatureStore(filename)
+	require.NoError(t, err)
+	routeStore, err := service.OpenFileRouteStore("")
+	require.NoError(t, err)
+	chatBroker, err := service.OpenChatBroker(service.ChatBrokerOptions{})
+	require.NoError(t, err)
+
+	return serveTestRouteGuide(t, service.NewRouteGuideServer(featureStore, routeStore, chatBroker, testRouteMaxPoints)), features, featureStore, filename
+}
+
+// serveTestRouteGuide serves routeGuideServer and returns a client connected to it.
+func serveTestRouteGuide(t *testing.T, routeGuideServer *service.RouteGuideServer) protoc.RouteGuideClient {
+	t.Helper()
+
+	grpcServer := grpc.NewServer()
+	protoc.RegisterRouteGuideServer(grpcServer, routeGuideServer)
+
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn := newClientConnection(t, listener.Addr().String())
+	t.Cleanup(func() { conn.Close() })
+
+	return protoc.NewRouteGuideClient(conn)
+}
+
+// TestRouteGuideServerFeatures checks the indexed queries answer as a scan of every feature would.
+func TestRouteGuideServerFeatures(t *testing.T) {
+	t.Parallel()
+
+	routeGuideClient, features, _, _ := startTestRouteGuideServer(t)
+
+	missing := &protoc.Point{Latitude: 1, Longitude: 1}
+	for _, point := range []*protoc.Point{features[0].Location, features[len(features)-1].Location, missing} {
+		expected := &protoc.Feature{Location: point}
+		for _, feature := range features {
+			if proto.Equal(feature.Location, point) {
+				expected = feature
+				break
+			}
+		}
+
+		feature, err := routeGuideClient.GetFeature(t.Context(), point)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(expected, feature), feature)
+	}
+
+	rectangles := []*protoc.Rectangle{
+		{Lo: &protoc.Point{Latitude: 400000000, Longitude: -750000000}, Hi: &protoc.Point{Latitude: 420000000, Longitude: -730000000}},
+		{Lo: &protoc.Point{Latitude: 420000000, Longitude: -730000000}, Hi: &protoc.Point{Latitude: 400000000, Longitude: -750000000}},
+		{Lo: &protoc.Point{Latitude: 407838351, Longitude: -746143763}, Hi: &protoc.Point{Latitude: 408122808, Longitude: -743999179}},
+		{Lo: &protoc.Point{Latitude: -900000000, Longitude: -1800000000}, Hi: &protoc.Point{Latitude: 900000000, Longitude: 1800000000}},
+		{Lo: &protoc.Point{Latitude: math.MinInt32, Longitude: math.MinInt32}, Hi: &protoc.Point{Latitude: math.MaxInt32, Longitude: math.MaxInt32}},
+		{Lo: &protoc.Point{Latitude: -10, Longitude: -10}, Hi: &protoc.Point{Latitude: 10, Longitude: 10}},
+	}
+	for i, rect := range rectangles {
+		var expected []string
+		for _, feature := range features {
+			lat, lng := feature.Location.Latitude, feature.Location.Longitude
+			if lat >= min(rect.Lo.Latitude, rect.Hi.Latitude) && lat <= max(rect.Lo.Latitude, rect.Hi.Latitude) &&
+				lng >= min(rect.Lo.Longitude, rect.Hi.Longitude) && lng <= max(rect.Lo.Longitude, rect.Hi.Longitude) {
+				expected = append(expected, feature.Name)
+			}
+		}
+
+		stream, err := routeGuideClient.ListFeatures(t.Context(), rect)
+		require.NoError(t, err)
+		var names []string
+		for {
+			feature, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			names = append(names, feature.GetName())
+		}
+		require.Equal(t, expected, names, i)
+	}
+
+	stream, err := routeGuideClient.RecordRoute(t.Context())
+	require.NoError(t, err)
+	for _, point := range []*protoc.Point{features[0].Location, missing, features[1].Location, features[1].Location} {
+		require.NoError(t, stream.Send(point))
+	}
+	summary, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	require.EqualValues(t, 4, summary.GetPointCount())
+	require.EqualValues(t, 3, summary.GetFeatureCount())
+}
+
+func TestRouteGuideServerNearest(t *testing.T) {
+	t.Parallel()
+
+	routeGuideClient, features, _, _ := startTestRouteGuideServer(t)
+
+	origin := &protoc.Point{Latitude: 409146138, Longitude: -746188906}
+	all, err := routeGuideClient.FindNearest(t.Context(), &protoc.FindNearestRequest{Location: origin, K: 1000})
+	require.NoError(t, err)
+	require.Len(t, all.GetFeatures(), len(features))
+	for i := 1; i < len(all.GetFeatures()); i++ {
+		require.LessOrEqual(t, all.GetFeatures()[i-1].GetDistance(), all.GetFeatures()[i].GetDistance())
+	}
+	require.Zero(t, all.GetFeatures()[0].GetDistance())
+	require.Equal(t, "Berkshire Valley Management Area Trail, Jefferson, NJ, USA", all.GetFeatures()[0].GetFeature().GetName())
+
+	nearest, err := routeGuideClient.FindNearest(t.Context(), &protoc.FindNearestRequest{Location: origin, K: 5})
+	require.NoError(t, err)
+	require.True(t, proto.Equal(&protoc.FindNearestResponse{Features: all.GetFeatures()[:5]}, nearest))
+
+	// the whole globe is searched when nothing is close by
+	far, err := routeGuideClient.FindNearest(t.Context(), &protoc.FindNearestRequest{Location: &protoc.Point{Latitude: -338688197, Longitude: 1512092955}, K: 1})
+	require.NoError(t, err)
+	require.Len(t, far.GetFeatures(), 1)
+	require.Greater(t, far.GetFeatures()[0].GetDistance(), int32(15_000_000))
+
+	const radius = 20000
+	var expected []*protoc.FeatureDistance
+	for _, found := range all.GetFeatures() {
+		if found.GetDistance() <= radius {
+			expected = append(expected, found)
+		}
+	}
+	require.NotEmpty(t, expected)
+	require.Less(t, len(expected), len(features))
+
+	stream, err := routeGuideClient.ListFeaturesWithinRadius(t.Context(), &protoc.RadiusRequest{Location: origin, Radius: radius})
+	require.NoError(t, err)
+	var received []*protoc.FeatureDistance
+	for {
+		found, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		received = append(received, found)
+	}
+	require.Len(t, received, len(expected))
+	for i := range expected {
+		require.True(t, proto.Equal(expected[i], received[i]), i)
+	}
+}
+
+func TestRouteGuideServerFeatureCatalogue(t *testing.T) {
+	t.Parallel()
+
+	routeGuideClient, features, featureStore, filename := startTestRouteGuideServer(t)
+	world := &protoc.Rectangle{Lo: &protoc.Point{Latitude: -900000000, Longitude: -1800000000}, Hi: &protoc.Point{Latitude: 900000000, Longitude: 1800000000}}
+
+	created := &protoc.Feature{Name: "Null Island", Location: &protoc.Point{Latitude: 0, Longitude: 0}}
+	_, err := routeGuideClient.CreateFeature(t.Context(), created)
+	require.NoError(t, err)
+	_, err = routeGuideClient.CreateFeature(t.Context(), created)
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = routeGuideClient.CreateFeature(t.Context(), &protoc.Feature{Location: &protoc.Point{Latitude: 900000001}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	feature, err := routeGuideClient.GetFeature(t.Context(), created.Location)
+	require.NoError(t, err)
+	require.Equal(t, "Null Island", feature.GetName())
+
+	// moving a feature keeps its place in the catalogue order
+	moved := &protoc.Feature{Name: "Patriots Path", Location: &protoc.Point{Latitude: 1, Longitude: 1}}
+	_, err = routeGuideClient.UpdateFeature(t.Context(), &protoc.UpdateFeatureRequest{Location: features[0].Location, Feature: moved})
+	require.NoError(t, err)
+	_, err = routeGuideClient.UpdateFeature(t.Context(), &protoc.UpdateFeatureRequest{Location: moved.Location, Feature: created})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	deleted, err := routeGuideClient.DeleteFeature(t.Context(), features[1].Location)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(features[1], deleted))
+	_, err = routeGuideClient.DeleteFeature(t.Context(), features[1].Location)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	expected := append([]*protoc.Feature{moved}, features[2:]...)
+	expected = append(expected, created)
+	requireSameFeatures(t, expected, featureStore.Within(world))
+
+	// changes are persisted
+	reopened, err := service.OpenFileFeatureStore(filename)
+	require.NoError(t, err)
+	requireSameFeatures(t, expected, reopened.Within(world))
+
+	// and the file is reloaded when changed by someone else
+	go featureStore.Watch(t.Context(), 10*time.Millisecond)
+	data, err := json.Marshal([]*protoc.Feature{created})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filename, data, 0o600))
+	require.Eventually(t, func() bool {
+		return len(featureStore.Within(world)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSeedFeatureFile(t *testing.T) {
+	t.Parallel()
+
+	seed, err := os.ReadFile("../sample/route_guide.json")
+	require.NoError(t, err)
+	filename := filepath.Join(t.TempDir(), "features.json")
+
+	// the seed is copied once, later changes are kept
+	require.NoError(t, service.SeedFeatureFile(filename, "../sample/route_guide.json"))
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, seed, data)
+
+	require.NoError(t, os.WriteFile(filename, []byte("[]"), 0o600))
+	require.NoError(t, service.SeedFeatureFile(filename, "../sample/route_guide.json"))
+	data, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(data))
+	require.NoError(t, service.SeedFeatureFile(filename, "missing.json"), "the seed is only read when needed")
+
+	require.Error(t, service.SeedFeatureFile(filepath.Join(t.TempDir(), "features.json"), "missing.json"))
+}
+
+func requireSameFeatures(t *testing.T, expected, actual []*protoc.Feature) {
+	t.Helper()
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		require.True(t, proto.Equal(expected[i], actual[i]), "feature %d: %v", i, actual[i])
+	}
+}
+
+func TestRouteGuideServerGeoJSON(t *testing.T) {
+	t.Parallel()
+
+	routeGuideClient, features, _, _ := startTestRouteGuideServer(t)
+
+	exported, err := routeGuideClient.ExportFeatures(t.Context(), &protoc.ExportFeaturesRequest{})
+	require.NoError(t, err)
+	decoded, err := geojson.UnmarshalFeatures([]byte(exported.GetData()))
+	require.NoError(t, err)
+	requireSameFeatures(t, features, decoded)
+
+	// a GeoJSON catalogue is persisted as GeoJSON
+	filename := filepath.Join(t.TempDir(), "features.geojson")
+	require.NoError(t, os.WriteFile(filename, []byte(exported.GetData()), 0o600))
+	featureStore, err := service.OpenFileFeatureStore(filename)
+	require.NoError(t, err)
+	requireSameFeatures(t, features, featureStore.Within(&protoc.Rectangle{
+		Lo: &protoc.Point{Latitude: -900000000, Longitude: -1800000000},
+		Hi: &protoc.Point{Latitude: 900000000, Longitude: 1800000000},
+	}))
+
+	renamed := &protoc.Feature{Name: "Renamed", Location: features[0].Location}
+	added := &protoc.Feature{Name: "Added", Location: &protoc.Point{Latitude: 1, Longitude: 1}}
+	created, updated, err := featureStore.Import([]*protoc.Feature{renamed, added})
+	require.NoError(t, err)
+	require.Equal(t, 1, created)
+	require.Equal(t, 1, updated)
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	persisted, err := geojson.UnmarshalFeatures(data)
+	require.NoError(t, err)
+	requireSameFeatures(t, append(append([]*protoc.Feature{renamed}, features[1:]...), added), persisted)
+
+	// imports are all or nothing
+	_, err = routeGuideClient.ImportFeatures(t.Context(), &protoc.GeoJson{
+		Data: `{"type": "FeatureCollection", "features": [
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1, 1]}, "properties": {"name": "a"}},
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1, 91]}, "properties": {"name": "b"}}
+		]}`,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := routeGuideClient.ImportFeatures(t.Context(), &protoc.GeoJson{
+		Data: `{"type": "FeatureCollection", "features": [
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [0.0000001, 0.0000001]}, "properties": {"name": "a"}}
+		]}`,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.GetCreated())
+
+	feature, err := routeGuideClient.GetFeature(t.Context(), &protoc.Point{Latitude: 1, Longitude: 1})
+	require.NoError(t, err)
+	require.Equal(t, "a", feature.GetName())
+}
+
+// recordTestRoute streams points to RecordRoute and returns the summary.
+func recordTestRoute(t *testing.T, routeGuideClient protoc.RouteGuideClient, points ...*protoc.Point) (*protoc.RouteSummary, error) {
+	t.Helper()
+
+	stream, err := routeGuideClient.RecordRoute(t.Context())
+	require.NoError(t, err)
+	for _, point := range points {
+		require.NoError(t, stream.Send(point))
+	}
+	return stream.CloseAndRecv()
+}
+
+func TestRouteGuideServerRoutes(t *testing.T) {
+	t.Parallel()
+
+	featureStore, err := service.OpenFileFeatureStore("../sample/route_guide.json")
+	require.NoError(t, err)
+	filename := filepath.Join(t.TempDir(), "routes.jsonl")
+	routeStore, err := service.OpenFileRouteStore(filename)
+	require.NoError(t, err)
+	defer routeStore.Close()
+
+	chatBroker, err := service.OpenChatBroker(service.ChatBrokerOptions{})
+	require.NoError(t, err)
+
+	routeGuideServer := service.NewRouteGuideServer(featureStore, routeStore, chatBroker, testRouteMaxPoints)
+	routeGuideClient := serveTestRouteGuide(t, routeGuideServer)
+
+	feature := &protoc.Point{Latitude: 409146138, Longitude: -746188906}
+	day := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	at := func(point *protoc.Point, offset time.Duration) *protoc.Point {
+		point = proto.Clone(point).(*protoc.Point)
+		point.RecordedAt = timestamppb.New(day.Add(offset))
+		return point
+	}
+
+	// the elapsed time comes from the client timestamps
+	morning, err := recordTestRoute(t, routeGuideClient,
+		at(feature, 0), &protoc.Point{Latitude: 409146138, Longitude: -746000000}, at(&protoc.Point{Latitude: 410000000, Longitude: -746000000}, 90*time.Minute))
+	require.NoError(t, err)
+	require.EqualValues(t, 3, morning.GetPointCount())
+	require.EqualValues(t, 1, morning.GetFeatureCount())
+	require.EqualValues(t, 5400, morning.GetElapsedTime())
+	require.NotEmpty(t, morning.GetRouteId())
+
+	nextDay, err := recordTestRoute(t, routeGuideClient, at(feature, 24*time.Hour), at(feature, 25*time.Hour))
+	require.NoError(t, err)
+	require.EqualValues(t, 3600, nextDay.GetElapsedTime())
+
+	// without timestamps the route starts when the stream does
+	now, err := recordTestRoute(t, routeGuideClient, feature)
+	require.NoError(t, err)
+	require.Zero(t, now.GetElapsedTime())
+
+	_, err = recordTestRoute(t, routeGuideClient, at(feature, time.Hour), at(feature, 0))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	empty, err := recordTestRoute(t, routeGuideClient)
+	require.NoError(t, err)
+	require.Empty(t, empty.GetRouteId())
+
+	route, err := routeGuideClient.GetRoute(t.Context(), &protoc.GetRouteRequest{Id: morning.GetRouteId()})
+	require.NoError(t, err)
+	require.Len(t, route.GetPoints(), 3)
+	require.Len(t, route.GetFeatures(), 1)
+	require.Equal(t, "Berkshire Valley Management Area Trail, Jefferson, NJ, USA", route.GetFeatures()[0].GetName())
+	require.True(t, route.GetStartedAt().AsTime().Equal(day))
+	require.True(t, route.GetEndedAt().AsTime().Equal(day.Add(90*time.Minute)))
+	require.Equal(t, morning.GetDistance(), route.GetSummary().GetDistance())
+
+	exported, err := routeGuideClient.ExportRoute(t.Context(), &protoc.GetRouteRequest{Id: morning.GetRouteId()})
+	require.NoError(t, err)
+	var lineString geojson.Feature
+	require.NoError(t, json.Unmarshal([]byte(exported.GetData()), &lineString))
+	require.Equal(t, "LineString", lineString.Geometry.Type)
+	require.Equal(t, morning.GetRouteId(), lineString.Properties["id"])
+	require.EqualValues(t, morning.GetDistance(), lineString.Properties["distance"])
+	require.Equal(t, day.Format(time.RFC3339Nano), lineString.Properties["started_at"])
+	_, err = routeGuideClient.ExportRoute(t.Context(), &protoc.GetRouteRequest{Id: now.GetRouteId()})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err), "a single point is no LineString")
+
+	testCases := []struct {
+		name     string
+		from, to time.Time
+		expected []string
+	}{
+		{name: "all", expected: []string{morning.GetRouteId(), nextDay.GetRouteId(), now.GetRouteId()}},
+		{name: "first_day", from: day, to: day.Add(24 * time.Hour), expected: []string{morning.GetRouteId()}},
+		{name: "since_second_day", from: day.Add(24 * time.Hour), expected: []string{nextDay.GetRouteId(), now.GetRouteId()}},
+		{name: "before", to: day, expected: nil},
+	}
+	for _, tc := range testCases {
+		req := &protoc.ListRoutesRequest{}
+		if !tc.from.IsZero() {
+			req.From = timestamppb.New(tc.from)
+		}
+		if !tc.to.IsZero() {
+			req.To = timestamppb.New(tc.to)
+		}
+
+		res, err := routeGuideClient.ListRoutes(t.Context(), req)
+		require.NoError(t, err, tc.name)
+		var ids []string
+		for _, route := range res.GetRoutes() {
+			require.Empty(t, route.GetPoints(), tc.name)
+			ids = append(ids, route.GetId())
+		}
+		require.Equal(t, tc.expected, ids, tc.name)
+	}
+
+	// routes are private to the user who recorded them
+	other := service.ContextWithPayload(t.Context(), &service.Payload{Username: "other", Role: "user"})
+	_, err = routeGuideServer.GetRoute(other, &protoc.GetRouteRequest{Id: morning.GetRouteId()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = routeGuideServer.ExportRoute(other, &protoc.GetRouteRequest{Id: morning.GetRouteId()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	res, err := routeGuideServer.ListRoutes(other, &protoc.ListRoutesRequest{})
+	require.NoError(t, err)
+	require.Empty(t, res.GetRoutes())
+
+	_, err = routeGuideClient.GetRoute(t.Context(), &protoc.GetRouteRequest{Id: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// the routes survive a restart
+	reopened, err := service.OpenFileRouteStore(filename)
+	require.NoError(t, err)
+	defer reopened.Close()
+	stored, err := reopened.Find(morning.GetRouteId())
+	require.NoError(t, err)
+	require.True(t, proto.Equal(route, stored))
+}
+
+// receiveTestNotes returns the messages of the next count notes of stream.
+func receiveTestNotes(t *testing.T, stream grpc.BidiStreamingClient[protoc.RouteNote, protoc.RouteNote], count int) []string {
+	t.Helper()
+
+	var messages []string
+	for range count {
+		note, err := stream.Recv()
+		require.NoError(t, err)
+		messages = append(messages, note.GetMessage())
+	}
+	return messages
+}
+
+func TestRouteGuideServerChat(t *testing.T) {
+	t.Parallel()
+
+	routeGuideClient, _, _, _ := startTestRouteGuideServer(t)
+
+	here := &protoc.Point{Latitude: 10, Longitude: 10}
+	there := &protoc.Point{Latitude: 20, Longitude: 20}
+	elsewhere := &protoc.Point{Latitude: -50, Longitude: -50}
+
+	alice, err := routeGuideClient.RouteChat(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, alice.Send(&protoc.RouteNote{Location: here, Message: "first"}))
+	require.Equal(t, []string{"first"}, receiveTestNotes(t, alice, 1))
+
+	// bob watches an area, starting with the notes already sent inside it
+	bob, err := routeGuideClient.RouteChat(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, bob.Send(&protoc.RouteNote{Area: &protoc.Rectangle{Lo: &protoc.Point{}, Hi: &protoc.Point{Latitude: 30, Longitude: 30}}}))
+	require.Equal(t, []string{"first"}, receiveTestNotes(t, bob, 1))
+
+	// carol subscribes to a single location without sending a note
+	carol, err := routeGuideClient.RouteChat(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, carol.Send(&protoc.RouteNote{Location: there}))
+
+	require.NoError(t, alice.Send(&protoc.RouteNote{Location: elsewhere, Message: "outside"}))
+	require.NoError(t, alice.Send(&protoc.RouteNote{Location: here, Message: "second"}))
+	require.Equal(t, []string{"outside", "second"}, receiveTestNotes(t, alice, 2))
+	require.Equal(t, []string{"second"}, receiveTestNotes(t, bob, 1))
+
+	require.NoError(t, carol.Send(&protoc.RouteNote{Location: there, Message: "third"}))
+	require.Equal(t, []string{"third"}, receiveTestNotes(t, bob, 1))
+	note, err := carol.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "third", note.GetMessage())
+	require.NotNil(t, note.GetSentAt())
+
+	// a disconnected subscriber does not hold back the others
+	require.NoError(t, carol.CloseSend())
+	_, err = carol.Recv()
+	require.ErrorIs(t, err, io.EOF)
+	require.NoError(t, alice.Send(&protoc.RouteNote{Location: there, Message: "fourth"}))
+	require.Equal(t, []string{"third", "fourth"}, receiveTestNotes(t, alice, 2))
+	require.Equal(t, []string{"fourth"}, receiveTestNotes(t, bob, 1))
+
+	require.NoError(t, alice.Send(&protoc.RouteNote{Message: "nowhere"}))
+	_, err = alice.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestChatBroker(t *testing.T) {
+	t.Parallel()
+
+	here := &protoc.Point{Latitude: 1, Longitude: 1}
+	note := func(message string) *protoc.RouteNote {
+		return &protoc.RouteNote{Location: here, Message: message, SentAt: timestamppb.Now()}
+	}
+	messages := func(subscription *service.ChatSubscription) []string {
+		var messages []string
+		for {
+			select {
+			case note := <-subscription.Notes():
+				messages = append(messages, note.GetMessage())
+			default:
+				return messages
+			}
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		backpressure service.ChatBackpressure
+		expected     []string
+		evicted      bool
+	}{
+		{name: "drop_oldest", backpressure: service.ChatDropOldest, expected: []string{"b", "c"}},
+		{name: "drop_newest", backpressure: service.ChatDropNewest, expected: []string{"a", "b"}},
+		{name: "disconnect", backpressure: service.ChatDisconnect, expected: []string{"a", "b"}, evicted: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			broker, err := service.OpenChatBroker(service.ChatBrokerOptions{BufferSize: 2, Backpressure: tc.backpressure})
+			require.NoError(t, err)
+
+			slow := broker.Subscribe()
+			defer slow.Close()
+			slow.AddLocation(here)
+			fast := broker.Subscribe()
+			defer fast.Close()
+			fast.AddLocation(here)
+
+			var received []string
+			for _, message := range []string{"a", "b", "c"} {
+				require.NoError(t, broker.Publish(note(message)))
+				received = append(received, messages(fast)...)
+			}
+
+			require.Equal(t, []string{"a", "b", "c"}, received)
+			require.Equal(t, tc.expected, messages(slow))
+			select {
+			case <-slow.Evicted():
+				require.True(t, tc.evicted)
+			default:
+				require.False(t, tc.evicted)
+				require.Equal(t, 1, slow.Dropped())
+			}
+		})
+	}
+
+	// the history is capped per location and survives a restart
+	filename := filepath.Join(t.TempDir(), "chat.jsonl")
+	broker, err := service.OpenChatBroker(service.ChatBrokerOptions{HistoryLimit: 2, HistoryFile: filename})
+	require.NoError(t, err)
+	for _, message := range []string{"a", "b", "c"} {
+		require.NoError(t, broker.Publish(note(message)))
+	}
+	require.NoError(t, broker.Close())
+
+	broker, err = service.OpenChatBroker(service.ChatBrokerOptions{HistoryLimit: 2, HistoryFile: filename})
+	require.NoError(t, err)
+	defer broker.Close()
+	subscription := broker.Subscribe()
+	defer subscription.Close()
+	subscription.AddLocation(here)
+	require.Equal(t, []string{"b", "c"}, messages(subscription))
+
+	// the least recently noted locations are forgotten, and the file is compacted while running
+	there := &protoc.Point{Latitude: 2, Longitude: 2}
+	elsewhere := &protoc.Point{Latitude: 3, Longitude: 3}
+	filename = filepath.Join(t.TempDir(), "chat.jsonl")
+	limited, err := service.OpenChatBroker(service.ChatBrokerOptions{HistoryLimit: 2, MaxLocations: 2, HistoryFile: filename})
+	require.NoError(t, err)
+	for range 10 {
+		require.NoError(t, limited.Publish(note("here")))
+	}
+	require.NoError(t, limited.Publish(&protoc.RouteNote{Location: there, Message: "there", SentAt: timestamppb.Now()}))
+	require.NoError(t, limited.Publish(&protoc.RouteNote{Location: elsewhere, Message: "elsewhere", SentAt: timestamppb.Now()}))
+
+	subscription = limited.Subscribe()
+	defer subscription.Close()
+	subscription.AddArea(&protoc.Rectangle{Lo: here, Hi: elsewhere})
+	require.Equal(t, []string{"there", "elsewhere"}, messages(subscription))
+	require.NoError(t, limited.Close())
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.LessOrEqual(t, strings.Count(string(data), "\n"), 4, "at most twice the notes kept")
+}
+
+func TestRouteGuideServerWatchRegion(t *testing.T) {
+	t.Parallel()
+
+	routeGuideClient, _, _, _ := startTestRouteGuideServer(t)
+
+	// a U shaped polygon, its notch is outside
+	polygon := &protoc.Polygon{Vertices: []*protoc.Point{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 0, Longitude: 300},
+		{Latitude: 300, Longitude: 300},
+		{Latitude: 300, Longitude: 200},
+		{Latitude: 100, Longitude: 200},
+		{Latitude: 100, Longitude: 100},
+		{Latitude: 300, Longitude: 100},
+		{Latitude: 300, Longitude: 0},
+	}}
+	watch := func(req *protoc.WatchRegionRequest) grpc.ServerStreamingClient[protoc.RegionEvent] {
+		ctx, cancel := context.WithCancel(t.Context())
+		t.Cleanup(cancel)
+
+		stream, err := routeGuideClient.WatchRegion(ctx, req)
+		require.NoError(t, err)
+		// the headers are sent once the region is watched
+		_, err = stream.Header()
+		require.NoError(t, err)
+		return stream
+	}
+	polygonWatch := watch(&protoc.WatchRegionRequest{Region: &protoc.WatchRegionRequest_Polygon{Polygon: polygon}})
+	rectangleWatch := watch(&protoc.WatchRegionRequest{Region: &protoc.WatchRegionRequest_Rectangle{
+		Rectangle: &protoc.Rectangle{Lo: &protoc.Point{Latitude: 200, Longitude: 150}, Hi: &protoc.Point{Latitude: 400, Longitude: 400}},
+	}})
+
+	summary, err := recordTestRoute(t, routeGuideClient,
+		&protoc.Point{Latitude: -50, Longitude: 150}, // outside both
+		&protoc.Point{Latitude: 50, Longitude: 150},  // enters the polygon
+		&protoc.Point{Latitude: 250, Longitude: 50},  // inside the left arm
+		&protoc.Point{Latitude: 250, Longitude: 150}, // leaves the polygon for its notch, enters the rectangle
+		&protoc.Point{Latitude: 300, Longitude: 250}, // on the border of the right arm, enters the polygon
+		&protoc.Point{Latitude: 500, Longitude: 250}, // leaves both
+	)
+	require.NoError(t, err)
+
+	type event struct {
+		kind     protoc.RegionEvent_Kind
+		latitude int32
+	}
+	receive := func(stream grpc.ServerStreamingClient[protoc.RegionEvent], count int) []event {
+		var events []event
+		for range count {
+			received, err := stream.Recv()
+			require.NoError(t, err)
+			require.Equal(t, summary.GetRouteId(), received.GetRouteId())
+			require.NotNil(t, received.GetReceivedAt())
+			events = append(events, event{received.GetKind(), received.GetPoint().GetLatitude()})
+		}
+		return events
+	}
+	require.Equal(t, []event{
+		{protoc.RegionEvent_ENTER, 50},
+		{protoc.RegionEvent_LEAVE, 250},
+		{protoc.RegionEvent_ENTER, 300},
+		{protoc.RegionEvent_LEAVE, 500},
+	}, receive(polygonWatch, 4))
+	require.Equal(t, []event{
+		{protoc.RegionEvent_ENTER, 250},
+		{protoc.RegionEvent_LEAVE, 500},
+	}, receive(rectangleWatch, 2))
+
+	// a finished watch does not hold back the routes
+	ctx, cancel := context.WithCancel(t.Context())
+	stream, err := routeGuideClient.WatchRegion(ctx, &protoc.WatchRegionRequest{Region: &protoc.WatchRegionRequest_Polygon{Polygon: polygon}})
+	require.NoError(t, err)
+	_, err = stream.Header()
+	require.NoError(t, err)
+	cancel()
+	_, err = recordTestRoute(t, routeGuideClient, &protoc.Point{Latitude: 50, Longitude: 50})
+	require.NoError(t, err)
+
+	invalid := &protoc.Polygon{Vertices: []*protoc.Point{{Latitude: 0}, {Latitude: 1}, {Latitude: 1000000000}}}
+	stream, err = routeGuideClient.WatchRegion(t.Context(), &protoc.WatchRegionRequest{Region: &protoc.WatchRegionRequest_Polygon{Polygon: invalid}})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRouteGuideServerRouteAnalysis(t *testing.T) {
+	t.Parallel()
+
+	routeGuideClient, _, _, _ := startTestRouteGuideServer(t)
+
+	start := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	point := func(latitude, longitude int32, seconds int) *protoc.Point {
+		return &protoc.Point{Latitude: latitude, Longitude: longitude, RecordedAt: timestamppb.New(start.Add(time.Duration(seconds) * time.Second))}
+	}
+
+	// east at 111m every 10s, a 3 minute stop, then north at 222m every 10s
+	var points []*protoc.Point
+	for i := range 6 {
+		points = append(points, point(0, int32(i)*10000, i*10))
+	}
+	points = append(points, point(0, 50000, 110), point(0, 50000, 170), point(0, 50000, 230))
+	for i := 1; i <= 3; i++ {
+		points = append(points, point(int32(i)*20000, 50000, 230+i*10))
+	}
+
+	ctx := metadata.AppendToOutgoingContext(t.Context(), service.SimplifyToleranceHeader, "10")
+	stream, err := routeGuideClient.RecordRoute(ctx)
+	require.NoError(t, err)
+	for _, point := range points {
+		require.NoError(t, stream.Send(point))
+	}
+	summary, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+
+	require.EqualValues(t, 5*111+3*222, summary.GetDistance())
+	require.InDelta(t, float64(5*111+3*222)/260, summary.GetAverageSpeed(), 1e-9)
+	require.InDelta(t, 22.2, summary.GetMaxSpeed(), 1e-9)
+
+	require.Len(t, summary.GetStops(), 1)
+	stop := summary.GetStops()[0]
+	require.EqualValues(t, 180, stop.GetDuration())
+	require.EqualValues(t, 50000, stop.GetLocation().GetLongitude())
+	require.True(t, stop.GetStartedAt().AsTime().Equal(start.Add(50*time.Second)))
+
+	require.Len(t, summary.GetSimplified(), 3)
+	for i, expected := range []*protoc.Point{points[0], points[5], points[len(points)-1]} {
+		require.True(t, proto.Equal(expected, summary.GetSimplified()[i]), i)
+	}
+
+	// the analysis is stored with the route
+	route, err := routeGuideClient.GetRoute(t.Context(), &protoc.GetRouteRequest{Id: summary.GetRouteId()})
+	require.NoError(t, err)
+	require.True(t, proto.Equal(summary, route.GetSummary()))
+
+	// without timestamps nor tolerance only the distance is known
+	untimed, err := recordTestRoute(t, routeGuideClient, &protoc.Point{}, &protoc.Point{Longitude: 10000}, &protoc.Point{Longitude: 10000})
+	require.NoError(t, err)
+	require.Zero(t, untimed.GetAverageSpeed())
+	require.Zero(t, untimed.GetMaxSpeed())
+	require.Empty(t, untimed.GetStops())
+	require.Empty(t, untimed.GetSimplified())
+
+	for _, tolerance := range []string{"-1", "0", "NaN", "+Inf", "ten"} {
+		ctx = metadata.AppendToOutgoingContext(t.Context(), service.SimplifyToleranceHeader, tolerance)
+		stream, err = routeGuideClient.RecordRoute(ctx)
+		require.NoError(t, err)
+		_, err = stream.CloseAndRecv()
+		require.Equal(t, codes.InvalidArgument, status.Code(err), tolerance)
+	}
+
+	// points off the globe are refused like the features, instead of being stored and tracked
+	for _, invalid := range []*protoc.Point{{Latitude: 900000001}, {Latitude: -900000001}, {Longitude: 1800000001}, {Longitude: -1800000001}} {
+		_, err = recordTestRoute(t, routeGuideClient, &protoc.Point{}, invalid)
+		require.Equal(t, codes.InvalidArgument, status.Code(err), invalid)
+	}
+	routes, err := routeGuideClient.ListRoutes(t.Context(), &protoc.ListRoutesRequest{})
+	require.NoError(t, err)
+	require.Len(t, routes.GetRoutes(), 2)
+
+	// a route is kept in memory until the stream ends, its points are bounded
+	points = make([]*protoc.Point, testRouteMaxPoints+1)
+	for i := range points {
+		points[i] = &protoc.Point{Longitude: int32(i)}
+	}
+	_, err = recordTestRoute(t, routeGuideClient, points[:testRouteMaxPoints]...)
+	require.NoError(t, err)
+	_, err = recordTestRoute(t, routeGuideClient, points...)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	routes, err = routeGuideClient.ListRoutes(t.Context(), &protoc.ListRoutesRequest{})
+	require.NoError(t, err)
+	require.Len(t, routes.GetRoutes(), 3)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-http-server/grpc/protoc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrRouteNotFound = errors.New("route not found")
+
+// RouteFilter selects the routes of an owner, zero times match everything.
+type RouteFilter struct {
+	Owner, Tenant string
+	From          time.Time // inclusive, on the route start
+	To            time.Time // exclusive, on the route start
+}
+
+func (filter RouteFilter) match(route *protoc.Route) bool {
+	if route.GetOwner() != filter.Owner || route.GetTenant() != filter.Tenant {
+		return false
+	}
+
+	startedAt := route.GetStartedAt().AsTime()
+	if !filter.From.IsZero() && startedAt.Before(filter.From) {
+		return false
+	}
+	return filter.To.IsZero() || startedAt.Before(filter.To)
+}
+
+// RouteStore defines the interface for storing recorded routes.
+type RouteStore interface {
+	// Save persists a new route.
+	Save(route *protoc.Route) error
+
+	// Find retrieves a route by its ID.
+	Find(id string) (*protoc.Route, error)
+
+	// List returns the routes matching filter ordered by start time.
+	List(filter RouteFilter) ([]*protoc.Route, error)
+}
+
+// FileRouteStore is a RouteStore indexed in memory and persisted as JSON lines to an append-only file.
+type FileRouteStore struct {
+	mutex  sync.RWMutex
+	file   *os.File
+	routes map[string]*protoc.Route
+}
+
+// OpenFileRouteStore loads the routes of filename, creating it when missing.
+// An empty filename keeps the routes in memory only.
+func OpenFileRouteStore(filename string) (*FileRouteStore, error) {
+	store := &FileRouteStore{routes: make(map[string]*protoc.Route)}
+	if filename == "" {
+		return store, nil
+	}
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		route := &protoc.Route{}
+		err := protojson.Unmarshal(scanner.Bytes(), route)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("routes %s: cannot parse line %d: %w", filename, line, err)
+		}
+		store.routes[route.GetId()] = route
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	store.file = file
+	return store, nil
+}
+
+func (store *FileRouteStore) Save(route *protoc.Route) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.routes[route.GetId()] != nil {
+		return fmt.Errorf("route with id %s already exists", route.GetId())
+	}
+
+	if store.file != nil {
+		data, err := protojson.Marshal(route)
+		if err != nil {
+			return err
+		}
+
+		_, err = store.file.Write(append(data, '\n'))
+		if err != nil {
+			return fmt.Errorf("cannot write route: %w", err)
+		}
+	}
+
+	store.routes[route.GetId()] = proto.Clone(route).(*protoc.Route)
+	return nil
+}
+
+func (store *FileRouteStore) Find(id string) (*protoc.Route, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	route, ok := store.routes[id]
+	if !ok {
+		return nil, ErrRouteNotFound
+	}
+
+	return proto.Clone(route).(*protoc.Route), nil
+}
+
+func (store *FileRouteStore) List(filter RouteFilter) ([]*protoc.Route, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	var routes []*protoc.Route
+	for _, route := range store.routes {
+		if filter.match(route) {
+			routes = append(routes, proto.Clone(route).(*protoc.Route))
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		a, b := routes[i].GetStartedAt().AsTime(), routes[j].GetStartedAt().AsTime()
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return routes[i].GetId() < routes[j].GetId()
+	})
+
+	return routes, nil
+}
+
+// CheckHealth makes sure the routes file is still open and on disk.
+func (store *FileRouteStore) CheckHealth(ctx context.Context) error {
+	if store.file == nil {
+		return nil
+	}
+
+	_, err := os.Stat(store.file.Name())
+	return err
+}
+
+// Close closes the underlying file.
+func (store *FileRouteStore) Close() error {
+	if store.file == nil {
+		return nil
+	}
+	return errors.Join(store.file.Sync(), store.file.Close())
+}
@@ -0,0 +1,259 @@
+// Package tlsreload serves a TLS key pair and a CA bundle read from PEM files, and swaps them
+// when the files change, so certificates are rotated without restarting the server or client.
+package tlsreload
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Watcher holds the key pair and the CA bundle of the last successful load.
+// A reload failing, e.g. while the cert and key files are being replaced one after the other,
+// keeps the previous certificates until the files are consistent again.
+type Watcher struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	warnBefore time.Duration
+
+	current  atomic.Pointer[bundle]
+	versions map[string]fileVersion // version of each file at the last load, owned by Watch
+	warned   map[string]bool        // files whose near expiry was logged since the last load, owned by Watch
+}
+
+type bundle struct {
+	certificate *tls.Certificate
+	pool        *x509.CertPool
+	expiries    map[string]time.Time // file -> earliest NotAfter of its certificates
+}
+
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher loads the PEM key pair and CA bundle, call Watch to reload them when they change.
+// Certificates expiring within warnBefore are logged.
+func NewWatcher(certFile, keyFile, caFile string, warnBefore time.Duration) (*Watcher, error) {
+	watcher := &Watcher{
+		certFile:   certFile,
+		keyFile:    keyFile,
+		caFile:     caFile,
+		warnBefore: warnBefore,
+	}
+
+	err := watcher.reload()
+	if err != nil {
+		return nil, err
+	}
+	watcher.warnExpiry(time.Now())
+
+	return watcher, nil
+}
+
+// ServerConfig returns a TLS config presenting the current certificate and requiring client
+// certificates signed by the current CA bundle.
+func (watcher *Watcher) ServerConfig() *tls.Config {
+	return watcher.serverConfig(tls.RequireAndVerifyClientCert, nil)
+}
+
+// HTTPServerConfig returns a TLS config for an HTTP server presenting the current certificate and
+// verifying client certificates against the current CA bundle when the client sends one, as browsers
+// usually do not. HTTP/2 is offered along with HTTP/1.1.
+func (watcher *Watcher) HTTPServerConfig() *tls.Config {
+	return watcher.serverConfig(tls.VerifyClientCertIfGiven, []string{"h2", "http/1.1"})
+}
+
+func (watcher *Watcher) serverConfig(clientAuth tls.ClientAuthType, nextProtos []string) *tls.Config {
+	return &tls.Config{
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			current := watcher.current.Load()
+			return &tls.Config{
+				Certificates: []tls.Certificate{*current.certificate},
+				ClientAuth:   clientAuth,
+				ClientCAs:    current.pool,
+				NextProtos:   nextProtos,
+			}, nil
+		},
+	}
+}
+
+// ClientConfig returns a TLS config presenting the current certificate and verifying the server
+// certificate against the current CA bundle, for serverName, the DNS name or IP address of the server.
+func (watcher *Watcher) ClientConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		ServerName: serverName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return watcher.current.Load().certificate, nil
+		},
+		// RootCAs cannot change after the config is built, VerifyConnection checks the chain instead
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+			if serverName == "" {
+				// an empty name would skip the host check of Verify
+				return errors.New("no server name to verify the server certificate against")
+			}
+
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				DNSName:       serverName,
+				Roots:         watcher.current.Load().pool,
+				Intermediates: intermediates,
+			})
+			return err
+		},
+	}
+}
+
+// RegisterMetrics exports the time left before the certificates of each file expire.
+func (watcher *Watcher) RegisterMetrics(meter metric.Meter) error {
+	_, err := meter.Float64ObservableGauge("tls.certificate.expiry",
+		metric.WithDescription("Time left before the earliest certificate of the file expires, negative once expired."),
+		metric.WithUnit("s"),
+		metric.WithFloat64Callback(func(_ context.Context, observer metric.Float64Observer) error {
+			for file, notAfter := range watcher.current.Load().expiries {
+				observer.Observe(time.Until(notAfter).Seconds(), metric.WithAttributes(attribute.String("file", file)))
+			}
+			return nil
+		}),
+	)
+	return err
+}
+
+// Watch polls the files every interval until ctx is done.
+func (watcher *Watcher) Watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if watcher.changed() {
+			err := watcher.reload()
+			if err != nil {
+				log.Printf("keep previous certificates, cannot reload %s: %s", watcher.certFile, err)
+			} else {
+				log.Printf("certificates %s and CA %s reloaded", watcher.certFile, watcher.caFile)
+			}
+		}
+		watcher.warnExpiry(time.Now())
+	}
+}
+
+// changed reports whether a file changed since the last load.
+func (watcher *Watcher) changed() bool {
+	for _, filename := range []string{watcher.certFile, watcher.keyFile, watcher.caFile} {
+		info, err := os.Stat(filename)
+		if err != nil {
+			log.Printf("cannot stat %s: %s", filename, err)
+			return false
+		}
+		if watcher.versions[filename] != (fileVersion{modTime: info.ModTime(), size: info.Size()}) {
+			return true
+		}
+	}
+	return false
+}
+
+func (watcher *Watcher) reload() error {
+	// remember the file versions even when they are invalid, so a broken file is reported once
+	watcher.versions = make(map[string]fileVersion)
+	for _, filename := range []string{watcher.certFile, watcher.keyFile, watcher.caFile} {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		watcher.versions[filename] = fileVersion{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	certificate, err := tls.LoadX509KeyPair(watcher.certFile, watcher.keyFile)
+	if err != nil {
+		return err
+	}
+
+	pemCA, err := os.ReadFile(watcher.caFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemCA) {
+		return fmt.Errorf("no CA certificate found in %s", watcher.caFile)
+	}
+	caExpiry, err := earliestExpiry(pemCA)
+	if err != nil {
+		return fmt.Errorf("invalid CA %s: %w", watcher.caFile, err)
+	}
+
+	watcher.current.Store(&bundle{
+		certificate: &certificate,
+		pool:        pool,
+		expiries: map[string]time.Time{
+			watcher.certFile: certificate.Leaf.NotAfter,
+			watcher.caFile:   caExpiry,
+		},
+	})
+	watcher.warned = make(map[string]bool)
+	return nil
+}
+
+// warnExpiry logs once per load each file whose certificates expire within warnBefore of now.
+func (watcher *Watcher) warnExpiry(now time.Time) {
+	for file, notAfter := range watcher.current.Load().expiries {
+		left := notAfter.Sub(now)
+		if left > watcher.warnBefore || watcher.warned[file] {
+			continue
+		}
+
+		watcher.warned[file] = true
+		if left <= 0 {
+			log.Printf("WARNING: certificate %s expired at %s", file, notAfter.Format(time.RFC3339))
+		} else {
+			log.Printf("WARNING: certificate %s expires in %s, at %s", file, left.Round(time.Minute), notAfter.Format(time.RFC3339))
+		}
+	}
+}
+
+// earliestExpiry returns the earliest NotAfter of the PEM certificates.
+func earliestExpiry(data []byte) (time.Time, error) {
+	var earliest time.Time
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return earliest, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package tlsreload_test
+
+import (
+	"context"
+	"crypto/tls"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-http-server/grpc/certgen"
+	"github.com/go-http-server/grpc/tlsreload"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// writeFiles writes the key pair and the CA bundle to dir, and returns their paths.
+// Files of a later version have a later modification time.
+func writeFiles(t *testing.T, dir string, version int, pair *certgen.KeyPair, cas ...*certgen.Authority) (string, string, string) {
+	var bundle []byte
+	for _, ca := range cas {
+		bundle = append(bundle, ca.CertPEM()...)
+	}
+
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	require.NoError(t, pair.WriteFiles(certFile, keyFile))
+	require.NoError(t, os.WriteFile(caFile, bundle, 0o600))
+
+	// the polling compares modification times, which may not change within the same tick
+	later := time.Now().Add(time.Duration(version) * time.Second)
+	for _, filename := range []string{certFile, keyFile, caFile} {
+		require.NoError(t, os.Chtimes(filename, later, later))
+	}
+	return certFile, keyFile, caFile
+}
+
+func newAuthority(t *testing.T, lifetime time.Duration) *certgen.Authority {
+	ca, err := certgen.NewAuthority("test CA", certgen.ECDSA, lifetime)
+	require.NoError(t, err)
+	return ca
+}
+
+func issueServer(t *testing.T, ca *certgen.Authority, lifetime time.Duration) *certgen.KeyPair {
+	pair, err := ca.IssueServer("localhost", []string{"localhost", "127.0.0.1"}, certgen.ECDSA, lifetime)
+	require.NoError(t, err)
+	return pair
+}
+
+func issueClient(t *testing.T, ca *certgen.Authority) *certgen.KeyPair {
+	pair, err := ca.IssueClient(certgen.ClientIdentity{Name: "client", Role: "admin"}, certgen.ECDSA, time.Hour)
+	require.NoError(t, err)
+	return pair
+}
+
+// serveTLS accepts connections until the test ends, completing their handshake.
+func serveTLS(t *testing.T, config *tls.Config) string {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// handshake dials addr and returns the serial number of the server certificate.
+func handshake(addr string, config *tls.Config) (*big.Int, error) {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber, nil
+}
+
+func TestWatcherRotation(t *testing.T) {
+	t.Parallel()
+
+	oldCA := newAuthority(t, 24*time.Hour)
+	oldServer := issueServer(t, oldCA, time.Hour)
+	serverDir, clientDir := t.TempDir(), t.TempDir()
+	certFile, keyFile, caFile := writeFiles(t, serverDir, 1, oldServer, oldCA)
+	server, err := tlsreload.NewWatcher(certFile, keyFile, caFile, time.Hour)
+	require.NoError(t, err)
+	certFile, keyFile, caFile = writeFiles(t, clientDir, 1, issueClient(t, oldCA), oldCA)
+	client, err := tlsreload.NewWatcher(certFile, keyFile, caFile, time.Hour)
+	require.NoError(t, err)
+
+	addr := serveTLS(t, server.ServerConfig())
+	serial, err := handshake(addr, client.ClientConfig("localhost"))
+	require.NoError(t, err)
+	require.Equal(t, oldServer.Certificate.SerialNumber, serial)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Watch(ctx, 10*time.Millisecond)
+	go client.Watch(ctx, 10*time.Millisecond)
+
+	// a server certificate of a new CA is rejected until the client trusts the new CA
+	newCA := newAuthority(t, 24*time.Hour)
+	newServer := issueServer(t, newCA, time.Hour)
+	writeFiles(t, serverDir, 2, newServer, oldCA, newCA)
+	require.Eventually(t, func() bool {
+		_, err := handshake(addr, client.ClientConfig("localhost"))
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	writeFiles(t, clientDir, 2, issueClient(t, newCA), oldCA, newCA)
+	require.Eventually(t, func() bool {
+		serial, err := handshake(addr, client.ClientConfig("localhost"))
+		return err == nil && serial.Cmp(newServer.Certificate.SerialNumber) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// a key not matching the certificate keeps the previous key pair
+	certFile = filepath.Join(serverDir, "tls.crt")
+	require.NoError(t, os.WriteFile(certFile, issueServer(t, newCA, time.Hour).CertPEM(), 0o600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	time.Sleep(50 * time.Millisecond)
+	serial, err = handshake(addr, client.ClientConfig("localhost"))
+	require.NoError(t, err)
+	require.Equal(t, newServer.Certificate.SerialNumber, serial)
+}
+
+func TestWatcherServerName(t *testing.T) {
+	t.Parallel()
+
+	ca := newAuthority(t, 24*time.Hour)
+	certFile, keyFile, caFile := writeFiles(t, t.TempDir(), 1, issueServer(t, ca, time.Hour), ca)
+	server, err := tlsreload.NewWatcher(certFile, keyFile, caFile, time.Hour)
+	require.NoError(t, err)
+	certFile, keyFile, caFile = writeFiles(t, t.TempDir(), 1, issueClient(t, ca), ca)
+	client, err := tlsreload.NewWatcher(certFile, keyFile, caFile, time.Hour)
+	require.NoError(t, err)
+	addr := serveTLS(t, server.ServerConfig())
+
+	testCases := []struct {
+		serverName string
+		valid      bool
+	}{
+		{serverName: "localhost", valid: true},
+		{serverName: "127.0.0.1", valid: true},
+		{serverName: "example.com"},
+		{serverName: "10.0.0.1"},
+		{serverName: ""},
+	}
+	for _, tc := range testCases {
+		_, err := handshake(addr, client.ClientConfig(tc.serverName))
+		if tc.valid {
+			require.NoError(t, err, tc.serverName)
+		} else {
+			require.Error(t, err, tc.serverName)
+		}
+	}
+}
+
+func TestWatcherMetrics(t *testing.T) {
+	t.Parallel()
+
+	ca := newAuthority(t, 48*time.Hour)
+	certFile, keyFile, caFile := writeFiles(t, t.TempDir(), 1, issueServer(t, ca, time.Hour), ca)
+	watcher, err := tlsreload.NewWatcher(certFile, keyFile, caFile, 24*time.Hour)
+	require.NoError(t, err)
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	require.NoError(t, watcher.RegisterMetrics(provider.Meter("test")))
+
+	var metrics metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &metrics))
+	require.Len(t, metrics.ScopeMetrics, 1)
+	require.Len(t, metrics.ScopeMetrics[0].Metrics, 1)
+	gauge, ok := metrics.ScopeMetrics[0].Metrics[0].Data.(metricdata.Gauge[float64])
+	require.True(t, ok)
+
+	expiries := make(map[string]float64)
+	for _, point := range gauge.DataPoints {
+		file, _ := point.Attributes.Value("file")
+		expiries[file.AsString()] = point.Value
+	}
+	require.Len(t, expiries, 2)
+	require.InDelta(t, time.Hour.Seconds(), expiries[certFile], 60)
+	require.InDelta(t, (48 * time.Hour).Seconds(), expiries[caFile], 60)
+}
+
+func TestNewWatcherInvalid(t *testing.T) {
+	t.Parallel()
+
+	ca := newAuthority(t, time.Hour)
+	certFile, keyFile, caFile := writeFiles(t, t.TempDir(), 1, issueServer(t, ca, time.Hour), ca)
+	mismatchedCert := filepath.Join(t.TempDir(), "other.crt")
+	require.NoError(t, os.WriteFile(mismatchedCert, issueServer(t, ca, time.Hour).CertPEM(), 0o600))
+	emptyCA := filepath.Join(t.TempDir(), "empty.crt")
+	require.NoError(t, os.WriteFile(emptyCA, nil, 0o600))
+
+	testCases := []struct {
+		name                      string
+		certFile, keyFile, caFile string
+	}{
+		{name: "missing cert", certFile: "missing.crt", keyFile: keyFile, caFile: caFile},
+		{name: "mismatched key", certFile: mismatchedCert, keyFile: keyFile, caFile: caFile},
+		{name: "empty CA", certFile: certFile, keyFile: keyFile, caFile: emptyCA},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tlsreload.NewWatcher(tc.certFile, tc.keyFile, tc.caFile, time.Hour)
+			require.Error(t, err)
+		})
+	}
+}